@@ -1,26 +1,100 @@
 // Fetch 打印从URL获取的内容
+//
+// -rate 限制每秒的请求数；-breaker N 为每个主机启用熔断器，
+// 同一主机连续失败 N 次后在 -cooldown 时间内跳过它的 URL。
+// 启用熔断器时出错不退出，而是继续获取下一个 URL。
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"time"
+
+	"go-programming-language/chapter08/breaker"
+	"go-programming-language/chapter08/ratelimit"
+)
+
+var (
+	rate     = flag.Float64("rate", 0, "每秒最多请求数，0 表示不限制")
+	burst    = flag.Int("burst", 1, "限流器允许的突发请求数")
+	trip     = flag.Int("breaker", 0, "同一主机连续失败多少次后熔断，0 表示不启用")
+	cooldown = flag.Duration("cooldown", 10*time.Second, "熔断后多久允许探测请求")
 )
 
 func main() {
-	for _, url := range os.Args[1:] {
-		resp, err := http.Get(url)
-		if err != nil {
+	flag.Parse()
+	limiter := ratelimit.New(ratelimit.PerSecond(*rate), *burst)
+	breakers := make(map[string]*breaker.CircuitBreaker) // 主机 -> 熔断器
+	failed := false
+
+	for _, url := range flag.Args() {
+		if err := limiter.Wait(context.Background()); err != nil {
 			fmt.Fprintf(os.Stderr, "fetch: %v\n", err)
 			os.Exit(1)
 		}
-		b, err := ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
+		if *trip <= 0 {
+			b, _, err := get(url)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fetch: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s", b)
+			continue
+		}
+
+		host := hostOf(url)
+		if breakers[host] == nil {
+			breakers[host] = breaker.New(*trip, *cooldown)
+		}
+		var b []byte
+		err := breakers[host].Do(func() error {
+			var status int
+			var err error
+			b, status, err = get(url)
+			if err == nil && status >= 500 {
+				err = fmt.Errorf("%s: 服务器错误 %d", url, status) // 对熔断器来说服务器错误也是失败
+			}
+			return err
+		})
+		if errors.Is(err, breaker.ErrOpen) {
+			err = fmt.Errorf("skipping %s: %v", url, err)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "fetch: reading %s: %v\n", url, err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "fetch: %v\n", err)
+			failed = true
+			continue
 		}
 		fmt.Printf("%s", b)
 	}
-} 
\ No newline at end of file
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// get 获取 url 的内容和状态码
+func get(url string) ([]byte, int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading %s: %v", url, err)
+	}
+	return b, resp.StatusCode, nil
+}
+
+// hostOf 返回 URL 的主机部分，无法解析时返回原字符串
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
@@ -1,41 +1,100 @@
 // Fetchall 并发获取URL并报告时间和大小
+//
+// -rate 限制所有 goroutine 合计每秒的请求数；-breaker N 为每个主机启用熔断器，
+// 同一主机连续失败 N 次后在 -cooldown 时间内直接跳过它的 URL。
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
+	"net/url"
 	"time"
+
+	"go-programming-language/chapter08/breaker"
+	"go-programming-language/chapter08/ratelimit"
+)
+
+var (
+	rate     = flag.Float64("rate", 0, "每秒最多请求数，0 表示不限制")
+	burst    = flag.Int("burst", 1, "限流器允许的突发请求数")
+	trip     = flag.Int("breaker", 0, "同一主机连续失败多少次后熔断，0 表示不启用")
+	cooldown = flag.Duration("cooldown", 10*time.Second, "熔断后多久允许探测请求")
 )
 
 func main() {
+	flag.Parse()
 	start := time.Now()
+	limiter := ratelimit.New(ratelimit.PerSecond(*rate), *burst) // 所有goroutine共享
+	// 启动goroutine之前为每个主机创建熔断器，之后只读，不需要加锁
+	breakers := make(map[string]*breaker.CircuitBreaker)
+	if *trip > 0 {
+		for _, url := range flag.Args() {
+			if host := hostOf(url); breakers[host] == nil {
+				breakers[host] = breaker.New(*trip, *cooldown)
+			}
+		}
+	}
+
 	ch := make(chan string)
-	for _, url := range os.Args[1:] {
-		go fetch(url, ch) // 启动一个goroutine
+	for _, url := range flag.Args() {
+		go fetch(url, limiter, breakers[hostOf(url)], ch) // 启动一个goroutine
 	}
-	for range os.Args[1:] {
+	for range flag.Args() {
 		fmt.Println(<-ch) // 从通道ch接收
 	}
 	fmt.Printf("%.2fs elapsed\n", time.Since(start).Seconds())
 }
 
-func fetch(url string, ch chan<- string) {
+// fetch 获取 url 并把结果发送到 ch。cb 为 nil 时不使用熔断器
+func fetch(url string, limiter *ratelimit.Limiter, cb *breaker.CircuitBreaker, ch chan<- string) {
+	if err := limiter.Wait(context.Background()); err != nil {
+		ch <- fmt.Sprint(err)
+		return
+	}
+	if cb == nil {
+		msg, _ := get(url)
+		ch <- msg
+		return
+	}
+	done, err := cb.Allow()
+	if err != nil {
+		ch <- fmt.Sprintf("skipping %s: %v", url, err)
+		return
+	}
+	msg, ok := get(url)
+	done(ok)
+	ch <- msg
+}
+
+// get 获取 url，返回报告的内容以及请求是否成功（服务器错误 5xx 也算失败）
+func get(url string) (string, bool) {
 	start := time.Now()
 	resp, err := http.Get(url)
 	if err != nil {
-		ch <- fmt.Sprint(err) // 发送到通道ch
-		return
+		return fmt.Sprint(err), false
 	}
 
 	nbytes, err := io.Copy(ioutil.Discard, resp.Body)
 	resp.Body.Close() // 不要泄漏资源
 	if err != nil {
-		ch <- fmt.Sprintf("while reading %s: %v", url, err)
-		return
+		return fmt.Sprintf("while reading %s: %v", url, err), false
 	}
 	secs := time.Since(start).Seconds()
-	ch <- fmt.Sprintf("%.2fs  %7d  %s", secs, nbytes, url)
-} 
\ No newline at end of file
+	msg := fmt.Sprintf("%.2fs  %7d  %s", secs, nbytes, url)
+	if resp.StatusCode >= 500 {
+		return msg + "  " + resp.Status, false
+	}
+	return msg, true
+}
+
+// hostOf 返回 URL 的主机部分，无法解析时返回原字符串
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-programming-language/chapter06/money"
+)
+
+// daysPerYear 计息的天数基础：日利率 = 年利率 / 365
+const daysPerYear = 365
+
+// Clock 提供当前时间和定时器，测试中可以替换为可控的假时钟。
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock 使用系统时间
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// EntryKind 是账户流水的类型
+type EntryKind string
+
+const (
+	EntryDeposit    EntryKind = "deposit"
+	EntryWithdrawal EntryKind = "withdrawal"
+	EntryInterest   EntryKind = "interest"
+)
+
+// Entry 是一条带日期的账户流水，Amount 为正表示存入，为负表示支出
+type Entry struct {
+	Time    time.Time   `json:"time"`
+	Kind    EntryKind   `json:"kind"`
+	Amount  money.Money `json:"amount"`
+	Balance money.Money `json:"balance"` // 记账后的余额
+}
+
+// 3. 银行账户示例
+// 余额使用 money.Money 以分为单位保存，避免浮点误差；
+// 每笔变动记为一条带日期的流水，可以按时间段生成对账单
+type Account struct {
+	mu      sync.Mutex
+	number  string
+	balance money.Money
+	entries []Entry // 按时间排序
+	clock   Clock
+
+	// 计息状态
+	rate           *big.Rat  // 年利率，例如 0.035
+	accruedThrough time.Time // 已经计息到这一天的零点
+	accrued        *big.Rat  // 已计提、尚未入账的利息（以最小货币单位计）
+}
+
+// AccountOption 是 NewAccount 的可选配置
+type AccountOption func(*Account)
+
+// WithClock 设置账户使用的时钟，默认使用系统时间
+func WithClock(c Clock) AccountOption {
+	return func(a *Account) {
+		a.clock = c
+	}
+}
+
+// NewAccount 创建一个指定货币、余额为零的账户
+func NewAccount(number string, currency money.Currency, opts ...AccountOption) *Account {
+	a := &Account{
+		number:  number,
+		balance: money.New(0, currency),
+		clock:   realClock{},
+		rate:    new(big.Rat),
+		accrued: new(big.Rat),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.accruedThrough = midnight(a.clock.Now())
+	return a
+}
+
+func (a *Account) Deposit(amount money.Money) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("存款金额必须大于0")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	balance, err := a.balance.Add(amount)
+	if err != nil {
+		return err
+	}
+	a.record(EntryDeposit, a.clock.Now(), amount, balance)
+	return nil
+}
+
+func (a *Account) Withdraw(amount money.Money) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("取款金额必须大于0")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	balance, err := a.balance.Sub(amount)
+	if err != nil {
+		return err
+	}
+	if balance.Sign() < 0 {
+		return fmt.Errorf("余额不足")
+	}
+	a.record(EntryWithdrawal, a.clock.Now(), money.New(-amount.Amount(), amount.Currency()), balance)
+	return nil
+}
+
+// record 追加一条流水并更新余额，调用方必须持有 a.mu
+func (a *Account) record(kind EntryKind, t time.Time, amount, balance money.Money) {
+	a.entries = append(a.entries, Entry{Time: t, Kind: kind, Amount: amount, Balance: balance})
+	a.balance = balance
+}
+
+func (a *Account) Balance() money.Money {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.balance
+}
+
+func (a *Account) Number() string {
+	return a.number
+}
+
+// Entries 返回全部流水的副本
+func (a *Account) Entries() []Entry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Entry(nil), a.entries...)
+}
+
+// balanceBefore 返回 t 之前最后一条流水后的余额，调用方必须持有 a.mu
+func (a *Account) balanceBefore(t time.Time) money.Money {
+	i := sort.Search(len(a.entries), func(i int) bool { return !a.entries[i].Time.Before(t) })
+	if i == 0 {
+		return money.New(0, a.balance.Currency())
+	}
+	return a.entries[i-1].Balance
+}
+
+// SetInterestRate 设置年利率，例如 "0.035" 表示 3.5%。
+// 新利率从今天开始生效，之前的天数先按旧利率计息。
+func (a *Account) SetInterestRate(annual string) error {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(annual))
+	if !ok || rate.Sign() < 0 {
+		return fmt.Errorf("年利率必须是非负数: %q", annual)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.accrue(a.clock.Now()); err != nil {
+		return err
+	}
+	a.rate = rate
+	return nil
+}
+
+// AccrueInterest 为截至今天零点的每一个完整日计提利息。
+//
+// 利息按日复利：每天以当日日终余额加上已计提未入账的利息为基数，
+// 乘以 年利率/365 计入计提额；每月最后一天日终把计提额的整数部分（按最小货币单位）
+// 记为一条利息流水，不足一分的部分留到下个月。
+// 重复调用是安全的，已经计过息的日子不会重复计算。
+func (a *Account) AccrueInterest() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.accrue(a.clock.Now())
+}
+
+// accrue 计息到 now 所在日的零点，调用方必须持有 a.mu
+func (a *Account) accrue(now time.Time) error {
+	today := midnight(now)
+	daily := new(big.Rat).Quo(a.rate, big.NewRat(daysPerYear, 1))
+	for day := a.accruedThrough; day.Before(today); {
+		next := day.AddDate(0, 0, 1)
+		base := new(big.Rat).SetInt64(a.balanceBefore(next).Amount())
+		base.Add(base, a.accrued)
+		a.accrued.Add(a.accrued, base.Mul(base, daily))
+
+		if next.Day() == 1 {
+			if err := a.creditInterest(next.Add(-time.Second)); err != nil {
+				return err
+			}
+		}
+		day = next
+		a.accruedThrough = day
+	}
+	return nil
+}
+
+// creditInterest 把计提额的整数部分记入账户，流水日期为 t。
+// 计息可能滞后于存取款，因此流水按时间插入，并重新计算其后各条流水的余额
+func (a *Account) creditInterest(t time.Time) error {
+	whole := new(big.Int).Quo(a.accrued.Num(), a.accrued.Denom())
+	if whole.Sign() == 0 {
+		return nil
+	}
+	if !whole.IsInt64() {
+		return money.ErrOverflow
+	}
+	interest := money.New(whole.Int64(), a.balance.Currency())
+
+	i := sort.Search(len(a.entries), func(i int) bool { return a.entries[i].Time.After(t) })
+	entries := append([]Entry(nil), a.entries[:i]...)
+	balance, err := a.balanceBefore(t.Add(time.Nanosecond)).Add(interest)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, Entry{Time: t, Kind: EntryInterest, Amount: interest, Balance: balance})
+	for _, e := range a.entries[i:] {
+		if e.Balance, err = balance.Add(e.Amount); err != nil {
+			return err
+		}
+		balance = e.Balance
+		entries = append(entries, e)
+	}
+	a.entries = entries
+	a.balance = balance
+	a.accrued.Sub(a.accrued, new(big.Rat).SetInt(whole))
+	return nil
+}
+
+// midnight 返回 t 所在日的零点（使用 t 的时区）
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// ScheduleInterest 每天零点为 accounts 计息，直到 ctx 被取消。
+// 每次计息完成后，如果 done 不为 nil，会把本次计息的时间发送到 done；
+// 没有人接收时一直等待，但 ctx 被取消后立即返回。
+func ScheduleInterest(ctx context.Context, clock Clock, done chan<- time.Time, accounts ...*Account) error {
+	for {
+		now := clock.Now()
+		wait := midnight(now).AddDate(0, 0, 1).Sub(now)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case t := <-clock.After(wait):
+			for _, a := range accounts {
+				if err := a.AccrueInterest(); err != nil {
+					return fmt.Errorf("账户 %s 计息失败: %w", a.Number(), err)
+				}
+			}
+			if done != nil {
+				select {
+				case done <- t:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// Statement 是账户在 [From, To) 期间的对账单
+type Statement struct {
+	Number  string      `json:"number"`
+	From    time.Time   `json:"from"`
+	To      time.Time   `json:"to"`
+	Opening money.Money `json:"opening"`
+	Closing money.Money `json:"closing"`
+	Entries []Entry     `json:"entries"`
+}
+
+// Statement 返回 [from, to) 期间的对账单：期初余额、期末余额和期间的全部流水
+func (a *Account) Statement(from, to time.Time) Statement {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := Statement{
+		Number:  a.number,
+		From:    from,
+		To:      to,
+		Opening: a.balanceBefore(from),
+		Closing: a.balanceBefore(to),
+		Entries: []Entry{},
+	}
+	for _, e := range a.entries {
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			s.Entries = append(s.Entries, e)
+		}
+	}
+	return s
+}
+
+// WriteCSV 以 CSV 格式输出对账单，首行和末行分别是期初和期末余额
+func (s Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "kind", "amount", "balance"})
+	cw.Write([]string{s.From.Format(time.RFC3339), "opening", "", s.Opening.String()})
+	for _, e := range s.Entries {
+		cw.Write([]string{e.Time.Format(time.RFC3339), string(e.Kind), e.Amount.String(), e.Balance.String()})
+	}
+	cw.Write([]string{s.To.Format(time.RFC3339), "closing", "", s.Closing.String()})
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON 以 JSON 格式输出对账单
+func (s Statement) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
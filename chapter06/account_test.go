@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter06/money"
+)
+
+// fakeClock 是可以手动拨动的时钟
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(t time.Time) *fakeClock {
+	return &fakeClock{now: t}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 把时钟拨快 d，并触发到期的定时器
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+		} else {
+			w.ch <- c.now
+		}
+	}
+	c.waiters = waiters
+}
+
+// waitForWaiters 等待直到有 n 个定时器在等待
+func (c *fakeClock) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		got := len(c.waiters)
+		c.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d timers", n)
+}
+
+func date(y int, m time.Month, d, hour int) time.Time {
+	return time.Date(y, m, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestAccountStatement(t *testing.T) {
+	clock := newFakeClock(date(2024, 3, 1, 9))
+	a := NewAccount("001", money.CNY, WithClock(clock))
+	a.Deposit(money.MustParse("100 CNY"))
+	clock.Advance(24 * time.Hour)
+	a.Withdraw(money.MustParse("30.50 CNY"))
+	clock.Advance(31 * 24 * time.Hour)
+	a.Deposit(money.MustParse("10 CNY"))
+
+	s := a.Statement(date(2024, 3, 2, 0), date(2024, 4, 1, 0))
+	if s.Opening != money.MustParse("100 CNY") || s.Closing != money.MustParse("69.50 CNY") {
+		t.Errorf("opening, closing = %s, %s; want 100.00, 69.50 CNY", s.Opening, s.Closing)
+	}
+	if len(s.Entries) != 1 || s.Entries[0].Kind != EntryWithdrawal {
+		t.Fatalf("entries = %+v; want one withdrawal", s.Entries)
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "time,kind,amount,balance\n" +
+		"2024-03-02T00:00:00Z,opening,,100.00 CNY\n" +
+		"2024-03-02T09:00:00Z,withdrawal,-30.50 CNY,69.50 CNY\n" +
+		"2024-04-01T00:00:00Z,closing,,69.50 CNY\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := s.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Statement
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Closing != s.Closing || len(decoded.Entries) != 1 || decoded.Entries[0].Amount != s.Entries[0].Amount {
+		t.Errorf("JSON round trip = %+v; want %+v", decoded, s)
+	}
+}
+
+// TestAccrueInterestMonthEnd 日利率 0.01%（年利率 3.65%），
+// 1 月 15 日存入 1000 元，到 1 月 31 日日终共复利 17 天：
+// 100000 × (1.0001^17 - 1) ≈ 170.14 分，月末入账 1.70 元，不足一分的部分留到下月
+func TestAccrueInterestMonthEnd(t *testing.T) {
+	clock := newFakeClock(date(2024, 1, 15, 10))
+	a := NewAccount("002", money.CNY, WithClock(clock))
+	if err := a.SetInterestRate("0.0365"); err != nil {
+		t.Fatal(err)
+	}
+	a.Deposit(money.MustParse("1000 CNY"))
+
+	clock.Advance(16*24*time.Hour + 13*time.Hour) // 1 月 31 日 23:00，还没到月末
+	a.AccrueInterest()
+	if got := a.Balance(); got != money.MustParse("1000 CNY") {
+		t.Errorf("balance before month end = %s; want 1000.00 CNY", got)
+	}
+
+	clock.Advance(time.Hour) // 2 月 1 日零点
+	a.AccrueInterest()
+	a.AccrueInterest() // 重复调用不会重复计息
+	if got := a.Balance(); got != money.MustParse("1001.70 CNY") {
+		t.Errorf("balance after month end = %s; want 1001.70 CNY", got)
+	}
+
+	jan := a.Statement(date(2024, 1, 1, 0), date(2024, 2, 1, 0))
+	if n := len(jan.Entries); n != 2 || jan.Entries[1].Kind != EntryInterest {
+		t.Fatalf("January entries = %+v; want deposit and interest", jan.Entries)
+	}
+	if got := jan.Entries[1].Time; !got.Equal(time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("interest dated %v; want the last second of January", got)
+	}
+	feb := a.Statement(date(2024, 2, 1, 0), date(2024, 3, 1, 0))
+	if feb.Opening != jan.Closing {
+		t.Errorf("February opening = %s; want January closing %s", feb.Opening, jan.Closing)
+	}
+
+	if err := a.SetInterestRate("-0.01"); err == nil {
+		t.Error("SetInterestRate(-0.01) returned nil error")
+	}
+}
+
+// TestAccrueInterestLate 计息滞后于存款时，利息流水按日期插入，后续余额随之修正
+func TestAccrueInterestLate(t *testing.T) {
+	clock := newFakeClock(date(2024, 1, 31, 12))
+	a := NewAccount("003", money.USD, WithClock(clock))
+	a.SetInterestRate("3.65") // 日利率 1%
+	a.Deposit(money.MustParse("100 USD"))
+	clock.Advance(24 * time.Hour)
+	a.Deposit(money.MustParse("5 USD")) // 2 月 1 日，还没有计息
+
+	a.AccrueInterest()
+	entries := a.Entries()
+	kinds := make([]string, len(entries))
+	for i, e := range entries {
+		kinds[i] = string(e.Kind) + " " + e.Balance.String()
+	}
+	want := "deposit 100.00 USD,interest 101.00 USD,deposit 106.00 USD"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Errorf("entries = %s; want %s", got, want)
+	}
+	if got := a.Balance(); got != money.MustParse("106 USD") {
+		t.Errorf("balance = %s; want 106.00 USD", got)
+	}
+}
+
+func TestScheduleInterest(t *testing.T) {
+	clock := newFakeClock(date(2024, 4, 29, 18))
+	a := NewAccount("004", money.EUR, WithClock(clock))
+	a.SetInterestRate("3.65")
+	a.Deposit(money.MustParse("100 EUR"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan time.Time)
+	errc := make(chan error, 1)
+	go func() { errc <- ScheduleInterest(ctx, clock, done, a) }()
+
+	// 4 月 29 日、30 日两天按日复利，4 月 30 日日终入账：100 × (1.01² - 1) = 2.01
+	for i := 0; i < 2; i++ {
+		clock.waitForWaiters(t, 1)
+		clock.Advance(24 * time.Hour)
+		<-done
+	}
+	if got := a.Balance(); got != money.MustParse("102.01 EUR") {
+		t.Errorf("balance = %s; want 102.01 EUR", got)
+	}
+
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("ScheduleInterest returned %v; want context.Canceled", err)
+	}
+}
+
+// TestScheduleInterestUnreadDone 没有人接收 done 时取消 ctx，ScheduleInterest 仍然返回
+func TestScheduleInterestUnreadDone(t *testing.T) {
+	clock := newFakeClock(date(2024, 4, 29, 18))
+	a := NewAccount("005", money.EUR, WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- ScheduleInterest(ctx, clock, make(chan time.Time), a) }()
+
+	clock.waitForWaiters(t, 1)
+	clock.Advance(24 * time.Hour)
+	cancel()
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Errorf("ScheduleInterest returned %v; want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ScheduleInterest still blocked on done 2s after cancel")
+	}
+}
@@ -0,0 +1,171 @@
+// Package calc 提供一个累加器式的浮点计算器，
+// 每一步操作都以结构化的形式记录，因此可以撤销、保存和重放。
+package calc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDivisionByZero 表示除数为零
+var ErrDivisionByZero = errors.New("calc: 除数不能为零")
+
+// 操作符
+const (
+	OpAdd      = "+"
+	OpSubtract = "-"
+	OpMultiply = "*"
+	OpDivide   = "/"
+	OpSet      = "=" // 直接设置当前结果，例如表达式求值的结果
+)
+
+// Step 是一次操作的结构化记录。
+type Step struct {
+	Op      string  `json:"op"`
+	Operand float64 `json:"operand"`
+	// Expr 产生操作数的原始表达式，仅用于显示，可以为空
+	Expr string `json:"expr,omitempty"`
+	// Result 执行这一步之后的结果
+	Result float64 `json:"result"`
+}
+
+// String 返回这一步的可读形式，例如 "+ 10.00"。
+func (s Step) String() string {
+	if s.Expr != "" {
+		return fmt.Sprintf("%s %s (%.2f)", s.Op, s.Expr, s.Operand)
+	}
+	return fmt.Sprintf("%s %.2f", s.Op, s.Operand)
+}
+
+// Calculator 累加器式计算器，零值即可使用，初始结果为 0。
+type Calculator struct {
+	result float64
+	steps  []Step
+}
+
+// Add 将当前结果加上 value。
+func (c *Calculator) Add(value float64) {
+	c.Apply(Step{Op: OpAdd, Operand: value})
+}
+
+// Subtract 将当前结果减去 value。
+func (c *Calculator) Subtract(value float64) {
+	c.Apply(Step{Op: OpSubtract, Operand: value})
+}
+
+// Multiply 将当前结果乘以 value。
+func (c *Calculator) Multiply(value float64) {
+	c.Apply(Step{Op: OpMultiply, Operand: value})
+}
+
+// Divide 将当前结果除以 value，除数为零时结果保持不变并返回错误。
+func (c *Calculator) Divide(value float64) error {
+	return c.Apply(Step{Op: OpDivide, Operand: value})
+}
+
+// Set 将当前结果设置为 value，expr 记录 value 的来源。
+func (c *Calculator) Set(value float64, expr string) {
+	c.Apply(Step{Op: OpSet, Operand: value, Expr: expr})
+}
+
+// Apply 执行一步操作并记录下来，s.Result 会被重新计算。
+func (c *Calculator) Apply(s Step) error {
+	switch s.Op {
+	case OpAdd:
+		s.Result = c.result + s.Operand
+	case OpSubtract:
+		s.Result = c.result - s.Operand
+	case OpMultiply:
+		s.Result = c.result * s.Operand
+	case OpDivide:
+		if s.Operand == 0 {
+			return ErrDivisionByZero
+		}
+		s.Result = c.result / s.Operand
+	case OpSet:
+		s.Result = s.Operand
+	default:
+		return fmt.Errorf("calc: 未知的操作 %q", s.Op)
+	}
+	c.result = s.Result
+	c.steps = append(c.steps, s)
+	return nil
+}
+
+// Undo 撤销最后一步操作，没有可撤销的操作时返回 false。
+func (c *Calculator) Undo() bool {
+	if len(c.steps) == 0 {
+		return false
+	}
+	c.steps = c.steps[:len(c.steps)-1]
+	c.result = 0
+	if n := len(c.steps); n > 0 {
+		c.result = c.steps[n-1].Result
+	}
+	return true
+}
+
+// Clear 清空结果和全部历史。
+func (c *Calculator) Clear() {
+	c.result = 0
+	c.steps = nil
+}
+
+// Result 返回当前结果。
+func (c Calculator) Result() float64 {
+	return c.result
+}
+
+// Steps 返回全部操作记录的副本。
+func (c Calculator) Steps() []Step {
+	return append([]Step(nil), c.steps...)
+}
+
+// History 返回可读形式的操作历史。
+func (c Calculator) History() []string {
+	history := make([]string, len(c.steps))
+	for i, s := range c.steps {
+		history[i] = s.String()
+	}
+	return history
+}
+
+// Save 将操作历史以 JSON lines 格式写入 w，每行一个 Step。
+func (c Calculator) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, s := range c.steps {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load 从 r 读取 JSON lines 格式的操作历史，清空当前状态后逐步重放。
+//
+// 重放时结果会被重新计算，文件中记录的 Result 只作参考；
+// 任意一行解析或执行失败时返回带行号的错误，当前状态保持不变。
+func (c *Calculator) Load(r io.Reader) error {
+	var replay Calculator
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Step
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return fmt.Errorf("calc: 第%d行: %v", line, err)
+		}
+		if err := replay.Apply(s); err != nil {
+			return fmt.Errorf("calc: 第%d行: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	*c = replay
+	return nil
+}
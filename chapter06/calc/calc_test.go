@@ -0,0 +1,86 @@
+package calc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCalculator(t *testing.T) {
+	var c Calculator
+	c.Add(10)
+	c.Subtract(3)
+	c.Multiply(2)
+	if err := c.Divide(0); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Divide(0) err = %v; want ErrDivisionByZero", err)
+	}
+	if err := c.Divide(2); err != nil {
+		t.Errorf("Divide(2) returned error: %v", err)
+	}
+	if c.Result() != 7 {
+		t.Errorf("Result() = %g; want 7", c.Result())
+	}
+
+	want := []string{"+ 10.00", "- 3.00", "* 2.00", "/ 2.00"}
+	if !reflect.DeepEqual(c.History(), want) {
+		t.Errorf("History() = %q; want %q", c.History(), want)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	var c Calculator
+	if c.Undo() {
+		t.Error("Undo() on empty calculator should return false")
+	}
+
+	c.Add(5)
+	c.Set(42, "6 * 7")
+	c.Multiply(2)
+	tests := []float64{42, 5, 0}
+	for _, expected := range tests {
+		if !c.Undo() {
+			t.Fatal("Undo() returned false")
+		}
+		if c.Result() != expected {
+			t.Errorf("Result() after undo = %g; want %g", c.Result(), expected)
+		}
+	}
+	if len(c.Steps()) != 0 {
+		t.Errorf("Steps() = %v; want empty", c.Steps())
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	var c Calculator
+	c.Add(1.5)
+	c.Set(10, "2 * 5")
+	c.Divide(4)
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("Save wrote %d lines; want 3", lines)
+	}
+
+	var loaded Calculator
+	loaded.Add(100)
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Steps(), c.Steps()) || loaded.Result() != 2.5 {
+		t.Errorf("Load() = %v (%g); want %v (2.5)", loaded.Steps(), loaded.Result(), c.Steps())
+	}
+
+	// 失败的加载不改变当前状态
+	err := loaded.Load(strings.NewReader("{\"op\":\"+\",\"operand\":1}\n{\"op\":\"/\",\"operand\":0}\n"))
+	if !errors.Is(err, ErrDivisionByZero) || !strings.Contains(err.Error(), "第2行") {
+		t.Errorf("Load err = %v; want line 2 ErrDivisionByZero", err)
+	}
+	if loaded.Result() != 2.5 {
+		t.Errorf("Result() after failed load = %g; want 2.5", loaded.Result())
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"unicode"
+)
+
+// lineReader 逐行读取用户输入
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// newLineReader 在终端上返回支持行编辑的读取器，否则（例如管道输入）逐行读取
+func newLineReader(in *os.File, out io.Writer) lineReader {
+	if isTerminal(in) {
+		return &editor{in: in, r: bufio.NewReader(in), out: out}
+	}
+	return &plainReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+// plainReader 不带行编辑的读取器
+type plainReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (p *plainReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	if !p.scanner.Scan() {
+		if err := p.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return p.scanner.Text(), nil
+}
+
+// 编辑器识别的控制字符
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyBackspace = 8
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// editor 在原始模式下实现简单的行编辑：左右移动、删除、
+// Ctrl-A/Ctrl-E 跳到行首行尾、Ctrl-U 清空，以及上下键浏览历史输入
+type editor struct {
+	in      *os.File
+	r       *bufio.Reader
+	out     io.Writer
+	history []string
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.in)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	var buf []rune
+	pos := 0
+	index := len(e.history) // 正在浏览的历史位置，len(history) 表示当前输入
+	refresh := func() {
+		// 回到行首重绘，再把光标移到 pos 处
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K\r", prompt, string(buf))
+		if n := len([]rune(prompt)) + pos; n > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dC", n)
+		}
+	}
+	recall := func(i int) {
+		index = i
+		buf = nil
+		if i < len(e.history) {
+			buf = []rune(e.history[i])
+		}
+		pos = len(buf)
+	}
+	refresh()
+
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			line := string(buf)
+			if line != "" {
+				e.history = append(e.history, line)
+			}
+			return line, nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", nil
+		case keyCtrlD:
+			if len(buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case keyBackspace, keyDelete:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(buf)
+		case keyCtrlU:
+			buf, pos = buf[pos:], 0
+		case keyEscape:
+			switch e.readEscape() {
+			case 'A': // 上
+				if index > 0 {
+					recall(index - 1)
+				}
+			case 'B': // 下
+				if index < len(e.history) {
+					recall(index + 1)
+				}
+			case 'C': // 右
+				if pos < len(buf) {
+					pos++
+				}
+			case 'D': // 左
+				if pos > 0 {
+					pos--
+				}
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(buf)
+			case '~': // Delete 键
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+			pos++
+		}
+		refresh()
+	}
+}
+
+// readEscape 读取 ESC 之后的 CSI 序列，返回最后一个字符
+func (e *editor) readEscape() rune {
+	if r, _, err := e.r.ReadRune(); err != nil || r != '[' && r != 'O' {
+		return 0
+	}
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			return 0
+		}
+		// 参数部分是数字和分号，遇到其他字符时序列结束
+		if r != ';' && (r < '0' || r > '9') {
+			return r
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Calc 是一个交互式计算器，支持表达式、撤销和历史记录的保存与重放。
+//
+// 用法：
+//
+//	go run ./chapter06/cmd/calc [历史文件]
+//
+// 如果指定了历史文件，启动时会先重放其中的操作。
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	r := newREPL(os.Stdout)
+	if len(os.Args) > 1 {
+		r.exec("load " + os.Args[1])
+	}
+	fmt.Println("输入 help 查看命令，quit 退出")
+
+	in := newLineReader(os.Stdin, os.Stdout)
+	for {
+		line, err := in.ReadLine("calc> ")
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "calc: %v\n", err)
+			os.Exit(1)
+		}
+		if !r.exec(line) {
+			return
+		}
+	}
+}
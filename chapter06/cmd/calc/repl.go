@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-programming-language/chapter06/calc"
+	"go-programming-language/chapter10/mypackage"
+)
+
+const helpText = `命令：
+  + 表达式      将当前结果加上表达式的值（* / 同理），+5 和 + 5 相同
+  - 表达式      从当前结果减去表达式的值，- 后面必须有空格
+  表达式        计算表达式并设为当前结果，可以用 ans 引用当前结果；
+                负号后面没有空格时是负数：-5 把结果设为 -5，而 +5 把结果加上 5，
+                -2*3 得到 -6，而 - 2*3 从当前结果减去 6
+  history       显示操作历史
+  undo          撤销最后一步
+  clear         清空结果和历史
+  save 文件     以 JSON lines 格式保存历史
+  load 文件     从文件加载并重放历史
+  help          显示本帮助
+  quit          退出
+`
+
+// repl 保存一次交互会话的状态
+type repl struct {
+	calc calc.Calculator
+	expr *mypackage.Calculator
+	out  io.Writer
+}
+
+func newREPL(out io.Writer) *repl {
+	return &repl{expr: mypackage.NewCalculator("calc"), out: out}
+}
+
+// exec 执行一行输入，返回 false 表示会话应当结束
+func (r *repl) exec(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case "quit", "exit":
+		return false
+	case "help":
+		fmt.Fprint(r.out, helpText)
+	case "history":
+		for i, step := range r.calc.Steps() {
+			fmt.Fprintf(r.out, "%3d  %-24s = %g\n", i+1, step, step.Result)
+		}
+	case "undo":
+		if !r.calc.Undo() {
+			fmt.Fprintln(r.out, "没有可以撤销的操作")
+			return true
+		}
+		r.printResult()
+	case "clear":
+		r.calc.Clear()
+		r.printResult()
+	case "save":
+		if err := r.save(arg); err != nil {
+			fmt.Fprintf(r.out, "保存失败: %v\n", err)
+			return true
+		}
+		fmt.Fprintf(r.out, "已保存 %d 步到 %s\n", len(r.calc.Steps()), arg)
+	case "load":
+		if err := r.load(arg); err != nil {
+			fmt.Fprintf(r.out, "加载失败: %v\n", err)
+			return true
+		}
+		fmt.Fprintf(r.out, "已从 %s 重放 %d 步\n", arg, len(r.calc.Steps()))
+		r.printResult()
+	default:
+		if err := r.apply(line); err != nil {
+			fmt.Fprintf(r.out, "错误: %v\n", err)
+			return true
+		}
+		r.printResult()
+	}
+	return true
+}
+
+// apply 处理算术输入：以运算符开头时作用于当前结果，否则设置新结果。
+// 负号后面没有空格时是表达式的一元负号，这样才能输入 -2*3 这样的负数；
+// + * / 不区分有没有空格，所以 -5 设置结果，+5 却是加上 5
+func (r *repl) apply(line string) error {
+	op := calc.OpSet
+	expr := line
+	switch line[0] {
+	case '-':
+		if len(line) > 1 && line[1] != ' ' && line[1] != '\t' {
+			break
+		}
+		fallthrough
+	case '+', '*', '/':
+		op, expr = line[:1], strings.TrimSpace(line[1:])
+	}
+
+	root, err := mypackage.Parse(expr)
+	if err != nil {
+		return err
+	}
+	value, err := r.expr.EvalExpr(root, map[string]float64{"ans": r.calc.Result()})
+	if err != nil {
+		return err
+	}
+	step := calc.Step{Op: op, Operand: value}
+	// 只有非字面量的表达式才值得记录原文
+	if _, literal := root.(*mypackage.NumberLit); !literal {
+		step.Expr = expr
+	}
+	return r.calc.Apply(step)
+}
+
+func (r *repl) save(path string) error {
+	if path == "" {
+		return fmt.Errorf("缺少文件名")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.calc.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *repl) load(path string) error {
+	if path == "" {
+		return fmt.Errorf("缺少文件名")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.calc.Load(f)
+}
+
+func (r *repl) printResult() {
+	fmt.Fprintf(r.out, "= %g\n", r.calc.Result())
+}
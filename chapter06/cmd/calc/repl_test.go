@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  float64
+	}{
+		{[]string{"-2*3"}, -6},
+		{[]string{"10", "- 2*3"}, 4},
+		{[]string{"10", "-\t2*3"}, 4},
+		{[]string{"10", "-5"}, -5},
+		{[]string{"10", "+5"}, 15},
+		{[]string{"10", "+ 5"}, 15},
+		{[]string{"10", "*2", "/ 4"}, 5},
+		{[]string{"10", "-ans"}, -10},
+		{[]string{"10", "+ 5", "undo"}, 10},
+		{[]string{"10", "+ 5", "undo", "undo"}, 0},
+		{[]string{"10", "+ 5", "clear"}, 0},
+		{[]string{"10", "/ 0"}, 10},  // 错误的输入不改变结果
+		{[]string{"10", "+ (1"}, 10}, // 语法错误
+	}
+	for _, test := range tests {
+		r := newREPL(io.Discard)
+		for _, line := range test.lines {
+			r.exec(line)
+		}
+		if got := r.calc.Result(); got != test.want {
+			t.Errorf("%q: result = %g; want %g", test.lines, got, test.want)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	r := newREPL(io.Discard)
+	for _, line := range []string{"-2*3", "+ 10", "* ans", "save " + path} {
+		r.exec(line)
+	}
+	want := r.calc.Steps()
+
+	loaded := newREPL(io.Discard)
+	loaded.exec("load " + path)
+	if got := loaded.calc.Result(); got != 16 {
+		t.Errorf("result after load = %g; want 16", got)
+	}
+	got := loaded.calc.Steps()
+	if len(got) != len(want) {
+		t.Fatalf("loaded %d steps; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].String() != want[i].String() || got[i].Result != want[i].Result {
+			t.Errorf("step %d = %v (%g); want %v (%g)", i, got[i], got[i].Result, want[i], want[i].Result)
+		}
+	}
+	loaded.exec("undo")
+	if got := loaded.calc.Result(); got != 4 {
+		t.Errorf("result after undo = %g; want 4", got)
+	}
+}
+
+func TestQuit(t *testing.T) {
+	r := newREPL(io.Discard)
+	if !r.exec("help") || r.exec("quit") || r.exec("exit") {
+		t.Error("only quit and exit should end the session")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal 在其他平台上总是返回 false，输入按普通行读取
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func makeRaw(f *os.File) (restore func(), err error) {
+	return nil, errors.New("当前平台不支持行编辑")
+}
@@ -4,6 +4,11 @@ package main
 import (
 	"fmt"
 	"math"
+	"os"
+	"time"
+
+	"go-programming-language/chapter06/calc"
+	"go-programming-language/chapter06/money"
 )
 
 // 1. 基本类型的方法
@@ -39,38 +44,7 @@ func (p Point) String() string {
 	return fmt.Sprintf("Point(%.2f, %.2f)", p.X, p.Y)
 }
 
-// 3. 银行账户示例
-type Account struct {
-	number  string
-	balance float64
-}
-
-func (a *Account) Deposit(amount float64) error {
-	if amount <= 0 {
-		return fmt.Errorf("存款金额必须大于0")
-	}
-	a.balance += amount
-	return nil
-}
-
-func (a *Account) Withdraw(amount float64) error {
-	if amount <= 0 {
-		return fmt.Errorf("取款金额必须大于0")
-	}
-	if amount > a.balance {
-		return fmt.Errorf("余额不足")
-	}
-	a.balance -= amount
-	return nil
-}
-
-func (a Account) Balance() float64 {
-	return a.balance
-}
-
-func (a Account) Number() string {
-	return a.number
-}
+// 3. 银行账户示例见 account.go
 
 // 4. 几何图形示例
 type Circle struct {
@@ -164,27 +138,51 @@ func main() {
 
 	// 4. 银行账户示例
 	fmt.Printf("\n银行账户示例:\n")
-	account := Account{number: "123456", balance: 1000}
-	fmt.Printf("账户: %s, 余额: %.2f\n", account.Number(), account.Balance())
+	account := NewAccount("123456", money.CNY)
+	account.Deposit(money.MustParse("1000 CNY"))
+	fmt.Printf("账户: %s, 余额: %s\n", account.Number(), account.Balance())
 	
-	err := account.Deposit(500)
+	err := account.Deposit(money.MustParse("500 CNY"))
 	if err != nil {
 		fmt.Printf("存款错误: %v\n", err)
 	} else {
-		fmt.Printf("存款后余额: %.2f\n", account.Balance())
+		fmt.Printf("存款后余额: %s\n", account.Balance())
 	}
 	
-	err = account.Withdraw(200)
+	err = account.Withdraw(money.MustParse("200 CNY"))
 	if err != nil {
 		fmt.Printf("取款错误: %v\n", err)
 	} else {
-		fmt.Printf("取款后余额: %.2f\n", account.Balance())
+		fmt.Printf("取款后余额: %s\n", account.Balance())
 	}
 	
-	err = account.Withdraw(2000)
+	err = account.Withdraw(money.MustParse("2000 CNY"))
 	if err != nil {
 		fmt.Printf("取款错误: %v\n", err)
 	}
+	
+	// 整数分存储：存入十次 0.10 元余额恰好增加 1 元
+	for i := 0; i < 10; i++ {
+		account.Deposit(money.MustParse("0.10 CNY"))
+	}
+	fmt.Printf("存入十次0.10后余额: %s\n", account.Balance())
+	
+	// 货币不一致的操作返回错误
+	err = account.Deposit(money.MustParse("10 USD"))
+	if err != nil {
+		fmt.Printf("存款错误: %v\n", err)
+	}
+	
+	// 按比例分配不会丢失一分钱
+	parts, _ := money.MustParse("100 CNY").Split(3)
+	fmt.Printf("100元三等分: %v\n", parts)
+	
+	// 每笔变动都有带日期的流水，可以导出对账单
+	today := time.Now()
+	statement := account.Statement(today.AddDate(0, 0, -1), today.AddDate(0, 0, 1))
+	fmt.Printf("对账单: 期初 %s, 期末 %s, %d 笔流水\n",
+		statement.Opening, statement.Closing, len(statement.Entries))
+	statement.WriteCSV(os.Stdout)
 
 	// 5. 几何图形示例
 	fmt.Printf("\n几何图形示例:\n")
@@ -236,47 +234,13 @@ func main() {
 	
 	// 10. 方法的实际应用
 	fmt.Printf("\n方法的实际应用:\n")
-	calculator := &Calculator{}
+	calculator := &calc.Calculator{}
 	calculator.Add(10)
 	calculator.Subtract(3)
 	calculator.Multiply(2)
 	calculator.Divide(2)
 	fmt.Printf("计算结果: %.2f\n", calculator.Result())
 	fmt.Printf("计算历史: %v\n", calculator.History())
+	calculator.Undo()
+	fmt.Printf("撤销一步后: %.2f\n", calculator.Result())
 }
-
-// 计算器示例
-type Calculator struct {
-	result  float64
-	history []string
-}
-
-func (c *Calculator) Add(value float64) {
-	c.result += value
-	c.history = append(c.history, fmt.Sprintf("+ %.2f", value))
-}
-
-func (c *Calculator) Subtract(value float64) {
-	c.result -= value
-	c.history = append(c.history, fmt.Sprintf("- %.2f", value))
-}
-
-func (c *Calculator) Multiply(value float64) {
-	c.result *= value
-	c.history = append(c.history, fmt.Sprintf("* %.2f", value))
-}
-
-func (c *Calculator) Divide(value float64) {
-	if value != 0 {
-		c.result /= value
-		c.history = append(c.history, fmt.Sprintf("/ %.2f", value))
-	}
-}
-
-func (c Calculator) Result() float64 {
-	return c.result
-}
-
-func (c Calculator) History() []string {
-	return c.history
-} 
\ No newline at end of file
@@ -0,0 +1,337 @@
+// Package money 提供以最小货币单位（如分）存储金额的 Money 类型。
+//
+// 金额使用 int64 整数保存，因此反复存入 0.1 元不会产生浮点误差；
+// 每个金额都带有 ISO 4217 货币代码，不同货币之间的运算会返回错误。
+// 所有算术运算都检查溢出。
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// 运算返回的错误，可以通过 errors.Is 匹配。
+var (
+	ErrCurrencyMismatch = errors.New("money: 货币不一致")
+	ErrUnknownCurrency  = errors.New("money: 未知的货币")
+	ErrOverflow         = errors.New("money: 金额溢出")
+	ErrInvalidFormat    = errors.New("money: 金额格式错误")
+	ErrInvalidRatio     = errors.New("money: 分配比例无效")
+)
+
+// Currency 是 ISO 4217 货币代码，例如 "CNY"。
+type Currency string
+
+// 常用货币
+const (
+	CNY Currency = "CNY"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	JPY Currency = "JPY"
+	HKD Currency = "HKD"
+	KWD Currency = "KWD"
+)
+
+// digits 记录每种货币的小数位数，即 1 个主单位等于 10^digits 个最小单位
+var digits = map[Currency]int{
+	CNY: 2,
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	JPY: 0,
+	HKD: 2,
+	KWD: 3,
+}
+
+// Digits 返回货币的小数位数，未知货币返回 -1。
+func (c Currency) Digits() int {
+	d, ok := digits[c]
+	if !ok {
+		return -1
+	}
+	return d
+}
+
+// Valid 报告 c 是否是已知的货币。
+func (c Currency) Valid() bool {
+	_, ok := digits[c]
+	return ok
+}
+
+// Money 是某种货币的金额，amount 以最小单位计。
+//
+// Money 是不可变的值类型，可以直接比较和复制。
+// 零值没有货币，只能用于表示“未设置”。
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New 返回 amount 个最小单位的 currency，例如 New(1234, USD) 表示 12.34 USD。
+func New(amount int64, currency Currency) Money {
+	return Money{amount: amount, currency: currency}
+}
+
+// Amount 返回以最小单位计的金额。
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency 返回货币代码。
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero 报告金额是否为零。
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// Sign 返回 -1、0 或 1，分别表示金额为负、零或正。
+func (m Money) Sign() int {
+	switch {
+	case m.amount < 0:
+		return -1
+	case m.amount > 0:
+		return 1
+	}
+	return 0
+}
+
+// sameCurrency 检查两个金额的货币是否一致
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s 和 %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// Cmp 比较两个同币种的金额，返回 -1、0 或 1。
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Add 返回 m + other。
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	a, b := m.amount, other.amount
+	if b > 0 && a > math.MaxInt64-b || b < 0 && a < math.MinInt64-b {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, other)
+	}
+	return Money{amount: a + b, currency: m.currency}, nil
+}
+
+// Sub 返回 m - other。
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	a, b := m.amount, other.amount
+	if b < 0 && a > math.MaxInt64+b || b > 0 && a < math.MinInt64+b {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, other)
+	}
+	return Money{amount: a - b, currency: m.currency}, nil
+}
+
+// Mul 返回 m * n。
+func (m Money) Mul(n int64) (Money, error) {
+	a := m.amount
+	if a != 0 && n != 0 {
+		p := a * n
+		if p/n != a || (a == -1 && n == math.MinInt64) || (n == -1 && a == math.MinInt64) {
+			return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, n)
+		}
+	}
+	return Money{amount: a * n, currency: m.currency}, nil
+}
+
+// Allocate 按比例把 m 分成 len(ratios) 份，各份之和严格等于 m。
+//
+// 每份先按比例向零取整，剩下的最小单位依次分给余数最大的份额（余数相同时靠前的优先），
+// 因此不会凭空多出或丢失一分钱。例如 New(100, USD).Allocate(1, 1, 1)
+// 得到 0.34、0.33、0.33 USD。
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个比例", ErrInvalidRatio)
+	}
+	total := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidRatio, r)
+		}
+		total.Add(total, big.NewInt(int64(r)))
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("%w: 比例之和为零", ErrInvalidRatio)
+	}
+
+	// 对绝对值分配，最后恢复符号；big.Int 避免 amount*ratio 溢出
+	amount := big.NewInt(m.amount)
+	sign := amount.Sign()
+	amount.Abs(amount)
+
+	shares := make([]int64, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	left := new(big.Int).Set(amount)
+	for i, r := range ratios {
+		q, rem := new(big.Int).QuoRem(new(big.Int).Mul(amount, big.NewInt(int64(r))), total, new(big.Int))
+		shares[i] = q.Int64()
+		remainders[i] = rem
+		left.Sub(left, q)
+	}
+	// left 小于 len(ratios)，逐个分给余数最大的份额
+	for n := left.Int64(); n > 0; n-- {
+		best := -1
+		for i, rem := range remainders {
+			if ratios[i] == 0 {
+				continue
+			}
+			if best < 0 || rem.Cmp(remainders[best]) > 0 {
+				best = i
+			}
+		}
+		shares[best]++
+		remainders[best] = new(big.Int).Sub(remainders[best], total) // 已经分过，排到最后
+	}
+
+	result := make([]Money, len(ratios))
+	for i, s := range shares {
+		if sign < 0 {
+			s = -s
+		}
+		result[i] = Money{amount: s, currency: m.currency}
+	}
+	return result, nil
+}
+
+// Split 把 m 平均分成 n 份，参见 Allocate。
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: 份数 %d", ErrInvalidRatio, n)
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// String 返回 "12.34 USD" 形式的字符串，小数位数由货币决定。
+func (m Money) String() string {
+	var b strings.Builder
+	abs := uint64(m.amount)
+	if m.amount < 0 {
+		b.WriteByte('-')
+		abs = -abs
+	}
+	d := m.currency.Digits()
+	if d <= 0 {
+		fmt.Fprintf(&b, "%d", abs)
+	} else {
+		scale := uint64(1)
+		for i := 0; i < d; i++ {
+			scale *= 10
+		}
+		fmt.Fprintf(&b, "%d.%0*d", abs/scale, d, abs%scale)
+	}
+	if m.currency != "" {
+		b.WriteByte(' ')
+		b.WriteString(string(m.currency))
+	}
+	return b.String()
+}
+
+// Parse 解析 "12.34 USD" 形式的字符串。
+// 小数位数不能超过货币的小数位数，例如 "1.234 USD" 和 "1.5 JPY" 都会返回错误。
+func Parse(s string) (Money, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Money{}, fmt.Errorf("%w: %q，应为 \"12.34 USD\" 的形式", ErrInvalidFormat, s)
+	}
+	currency := Currency(strings.ToUpper(fields[1]))
+	d := currency.Digits()
+	if d < 0 {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, fields[1])
+	}
+
+	num := fields[0]
+	negative := false
+	if num != "" && (num[0] == '-' || num[0] == '+') {
+		negative = num[0] == '-'
+		num = num[1:]
+	}
+	whole, frac, hasPoint := strings.Cut(num, ".")
+	if whole == "" || hasPoint && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+	if len(frac) > d {
+		return Money{}, fmt.Errorf("%w: %q 超过 %s 的 %d 位小数", ErrInvalidFormat, s, currency, d)
+	}
+	frac += strings.Repeat("0", d-len(frac))
+
+	// 累加为负数，这样 math.MinInt64 也能表示
+	var amount int64
+	for _, c := range whole + frac {
+		digit := int64(c - '0')
+		if amount < (math.MinInt64+digit)/10 {
+			return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		amount = amount*10 - digit
+	}
+	if !negative {
+		if amount == math.MinInt64 {
+			return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		amount = -amount
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// MustParse 与 Parse 相同，但出错时 panic，用于常量和测试。
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// MarshalText 实现 encoding.TextMarshaler，JSON 中的金额形如 "12.34 USD"。
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler。
+func (m *Money) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// isDigits 报告 s 是否只包含十进制数字，空串返回 true
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
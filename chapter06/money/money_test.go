@@ -0,0 +1,142 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		str  string
+	}{
+		{"12.34 USD", New(1234, USD), "12.34 USD"},
+		{"12.3 usd", New(1230, USD), "12.30 USD"},
+		{"-0.05 EUR", New(-5, EUR), "-0.05 EUR"},
+		{"+7 CNY", New(700, CNY), "7.00 CNY"},
+		{"1500 JPY", New(1500, JPY), "1500 JPY"},
+		{"1.005 KWD", New(1005, KWD), "1.005 KWD"},
+		{"-92233720368547758.08 USD", New(math.MinInt64, USD), "-92233720368547758.08 USD"},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Parse(%q) = %#v; want %#v", test.in, got, test.want)
+		}
+		if got.String() != test.str {
+			t.Errorf("Parse(%q).String() = %q; want %q", test.in, got.String(), test.str)
+		}
+	}
+
+	errs := []struct {
+		in  string
+		err error
+	}{
+		{"12.34", ErrInvalidFormat},
+		{"12.345 USD", ErrInvalidFormat},
+		{"1.5 JPY", ErrInvalidFormat},
+		{"1. USD", ErrInvalidFormat},
+		{".5 USD", ErrInvalidFormat},
+		{"1e3 USD", ErrInvalidFormat},
+		{"12.34 XXX", ErrUnknownCurrency},
+		{"92233720368547758.08 USD", ErrOverflow},
+	}
+	for _, test := range errs {
+		if _, err := Parse(test.in); !errors.Is(err, test.err) {
+			t.Errorf("Parse(%q) err = %v; want %v", test.in, err, test.err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 十次存入 0.10 元恰好等于 1.00 元
+	sum := New(0, CNY)
+	dime := MustParse("0.10 CNY")
+	for i := 0; i < 10; i++ {
+		var err error
+		if sum, err = sum.Add(dime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sum != MustParse("1 CNY") {
+		t.Errorf("sum = %s; want 1.00 CNY", sum)
+	}
+
+	if _, err := sum.Add(New(1, USD)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies err = %v; want ErrCurrencyMismatch", err)
+	}
+	if _, err := sum.Cmp(New(1, USD)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp across currencies err = %v; want ErrCurrencyMismatch", err)
+	}
+	if _, err := New(math.MaxInt64, USD).Add(New(1, USD)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add overflow err = %v; want ErrOverflow", err)
+	}
+	if _, err := New(math.MinInt64, USD).Sub(New(1, USD)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sub overflow err = %v; want ErrOverflow", err)
+	}
+	if _, err := New(math.MinInt64, USD).Mul(-1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul overflow err = %v; want ErrOverflow", err)
+	}
+	if got, _ := New(-250, USD).Mul(3); got != New(-750, USD) {
+		t.Errorf("Mul = %s; want -7.50 USD", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		m      Money
+		ratios []int
+		want   []int64
+	}{
+		{New(100, USD), []int{1, 1, 1}, []int64{34, 33, 33}},
+		{New(-100, USD), []int{1, 1, 1}, []int64{-34, -33, -33}},
+		{New(5, USD), []int{3, 7}, []int64{2, 3}},
+		{New(100, USD), []int{1, 0, 1}, []int64{50, 0, 50}},
+		{New(math.MaxInt64, USD), []int{1, 1}, []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+	}
+	for _, test := range tests {
+		parts, err := test.m.Allocate(test.ratios...)
+		if err != nil {
+			t.Errorf("%s.Allocate(%v) returned error: %v", test.m, test.ratios, err)
+			continue
+		}
+		for i, p := range parts {
+			if p.Amount() != test.want[i] || p.Currency() != test.m.Currency() {
+				t.Errorf("%s.Allocate(%v) = %v; want %v", test.m, test.ratios, parts, test.want)
+				break
+			}
+		}
+	}
+
+	if _, err := New(1, USD).Allocate(0, 0); !errors.Is(err, ErrInvalidRatio) {
+		t.Errorf("Allocate(0, 0) err = %v; want ErrInvalidRatio", err)
+	}
+	if _, err := New(1, USD).Split(0); !errors.Is(err, ErrInvalidRatio) {
+		t.Errorf("Split(0) err = %v; want ErrInvalidRatio", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	in := struct {
+		Price Money `json:"price"`
+	}{MustParse("9.99 GBP")}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"price":"9.99 GBP"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+	out := in
+	out.Price = Money{}
+	if err := json.Unmarshal(data, &out); err != nil || out != in {
+		t.Errorf("Unmarshal = %+v, %v; want %+v", out, err, in)
+	}
+}
@@ -0,0 +1,82 @@
+// Render 把 JSON 场景描述中的图形画成 SVG 或 PNG 图片。
+//
+// 用法：
+//
+//	go run ./chapter07/cmd/render [-o 输出文件] scene.json
+//
+// 输出文件的扩展名（.svg 或 .png）决定格式；没有 -o 时在场景文件旁边同时生成两种格式。
+// 场景格式见 render.ParseScene，示例见 chapter07/scene.json。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-programming-language/chapter07/render"
+)
+
+func main() {
+	out := flag.String("o", "", "输出文件（.svg 或 .png）")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: render [-o 输出文件] scene.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *out); err != nil {
+		fmt.Fprintf(os.Stderr, "render: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, output string) error {
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	scene, err := render.ParseScene(data)
+	if err != nil {
+		return err
+	}
+	outputs := []string{output}
+	if output == "" {
+		base := strings.TrimSuffix(input, filepath.Ext(input))
+		outputs = []string{base + ".svg", base + ".png"}
+	}
+	for _, name := range outputs {
+		var write func(io.Writer) error
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".svg":
+			write = scene.WriteSVG
+		case ".png":
+			write = scene.WritePNG
+		default:
+			return fmt.Errorf("不支持的输出格式 %q，请使用 .svg 或 .png", name)
+		}
+		if err := writeFile(name, write); err != nil {
+			return err
+		}
+		fmt.Println("已生成", name)
+	}
+	return nil
+}
+
+// writeFile 创建文件并写入，关闭文件时的错误也会返回
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
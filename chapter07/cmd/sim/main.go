@@ -0,0 +1,91 @@
+// Sim 在没有图形界面的情况下运行实体模拟，把快照作为 JSON Lines 输出。
+//
+// 用法：
+//
+//	go run ./chapter07/cmd/sim [-seed 1] [-n 100] [-ticks 600] [-every 60] [-o out.jsonl]
+//	go run ./chapter07/cmd/sim -load snapshot.json [-ticks 600]
+//
+// 没有 -load 时用 -seed 生成 -n 个随机的球和一块地板；
+// 有 -load 时从快照继续运行，相同的快照总是得到相同的结果，可以用来重放和比较。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go-programming-language/chapter07/geometry"
+	"go-programming-language/chapter07/sim"
+)
+
+func main() {
+	seed := flag.Int64("seed", 1, "随机数种子")
+	n := flag.Int("n", 100, "随机生成的物体数量")
+	ticks := flag.Int("ticks", 600, "运行的步数")
+	every := flag.Int("every", 0, "每隔多少步输出一次快照，0 表示只输出最后的快照")
+	load := flag.String("load", "", "从快照文件继续运行")
+	output := flag.String("o", "", "输出文件，默认为标准输出")
+	flag.Parse()
+
+	if err := run(*seed, *n, *ticks, *every, *load, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "sim: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(seed int64, n, ticks, every int, load, output string) error {
+	w, err := newWorld(seed, n, load)
+	if err != nil {
+		return err
+	}
+	write := func(out io.Writer) error { return sim.RunHeadless(w, ticks, every, out) }
+	if output == "" {
+		err = write(os.Stdout)
+	} else {
+		err = writeFile(output, write)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "第 %d 步：%d 个物体，动能 %.1f，本步碰撞 %d 次\n",
+		w.Tick(), len(w.Bodies()), w.Energy(), len(w.Collisions()))
+	return nil
+}
+
+// writeFile 创建文件并写入，关闭文件时的错误也会返回
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newWorld(seed int64, n int, load string) (*sim.World, error) {
+	if load != "" {
+		f, err := os.Open(load)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		s, err := sim.ReadSnapshot(f)
+		if err != nil {
+			return nil, err
+		}
+		return sim.Restore(s)
+	}
+	w := sim.NewWorld(sim.Config{
+		Bounds:      geometry.R(0, 0, 200, 200),
+		Gravity:     geometry.Pt(0, -9.8),
+		Restitution: 0.8,
+		Seed:        seed,
+	})
+	w.Add(sim.Body{Name: "floor", Pos: geometry.Pt(100, 20), Size: geometry.Pt(120, 4)})
+	w.Spawn(n, 1, 4, 30)
+	return w, nil
+}
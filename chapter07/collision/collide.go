@@ -0,0 +1,124 @@
+// Package collision 提供二维碰撞检测：
+// 圆与圆、圆与矩形、矩形与矩形之间的精确测试（窄阶段），
+// 以及用均匀网格实现的空间索引 Grid（宽阶段），
+// 用来快速找出某个区域内的对象、离某点最近的对象和所有可能相交的对象对。
+//
+// 边界相接也算作碰撞，此时穿透深度为 0。
+package collision
+
+import (
+	"math"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+// Contact 描述两个图形的碰撞：Normal 是从第一个图形指向第二个图形的单位向量，
+// Depth 是穿透深度，把第二个图形沿 Normal 移动 Depth 后两者恰好分开。
+type Contact struct {
+	Normal geometry.Point
+	Depth  float64
+}
+
+// Flip 返回从第二个图形的角度描述的同一个碰撞。
+func (c Contact) Flip() Contact {
+	return Contact{c.Normal.Mul(-1), c.Depth}
+}
+
+// CircleCircle 报告两个圆是否相交。
+func CircleCircle(a, b geometry.Circle) bool {
+	d := b.Center.Sub(a.Center)
+	r := a.Radius + b.Radius
+	return d.Dot(d) <= r*r
+}
+
+// CircleRect 报告圆和轴对齐矩形是否相交。
+func CircleRect(c geometry.Circle, r geometry.Rect) bool {
+	d := c.Center.Sub(clamp(c.Center, r))
+	return d.Dot(d) <= c.Radius*c.Radius
+}
+
+// RectRect 报告两个轴对齐矩形是否相交。
+func RectRect(a, b geometry.Rect) bool {
+	return a.Overlaps(b)
+}
+
+// clamp 返回矩形中离 p 最近的点
+func clamp(p geometry.Point, r geometry.Rect) geometry.Point {
+	return geometry.Pt(math.Max(r.Min.X, math.Min(p.X, r.Max.X)), math.Max(r.Min.Y, math.Min(p.Y, r.Max.Y)))
+}
+
+// Collide 测试两个图形是否相交，相交时返回碰撞信息。
+// 圆和矩形按精确的形状测试，其他图形用它们的包围盒近似。
+func Collide(a, b geometry.Shape) (Contact, bool) {
+	switch a := a.(type) {
+	case geometry.Circle:
+		switch b := b.(type) {
+		case geometry.Circle:
+			return circleCircle(a, b)
+		default:
+			c, ok := circleRect(a, b.Bounds())
+			return c.Flip(), ok
+		}
+	default:
+		if b, ok := b.(geometry.Circle); ok {
+			return circleRect(b, a.Bounds())
+		}
+		return rectRect(a.Bounds(), b.Bounds())
+	}
+}
+
+func circleCircle(a, b geometry.Circle) (Contact, bool) {
+	if !CircleCircle(a, b) {
+		return Contact{}, false
+	}
+	d := b.Center.Sub(a.Center)
+	dist := d.Len()
+	normal := geometry.Pt(1, 0) // 圆心重合时任选一个方向
+	if dist > 0 {
+		normal = d.Mul(1 / dist)
+	}
+	return Contact{normal, a.Radius + b.Radius - dist}, true
+}
+
+// circleRect 返回从矩形指向圆的碰撞信息
+func circleRect(c geometry.Circle, r geometry.Rect) (Contact, bool) {
+	if !CircleRect(c, r) {
+		return Contact{}, false
+	}
+	closest := clamp(c.Center, r)
+	d := c.Center.Sub(closest)
+	if dist := d.Len(); dist > 0 {
+		return Contact{d.Mul(1 / dist), c.Radius - dist}, true
+	}
+	// 圆心在矩形内部：从离圆心最近的边推出去
+	best := Contact{geometry.Pt(-1, 0), c.Center.X - r.Min.X}
+	for _, e := range []Contact{
+		{geometry.Pt(1, 0), r.Max.X - c.Center.X},
+		{geometry.Pt(0, -1), c.Center.Y - r.Min.Y},
+		{geometry.Pt(0, 1), r.Max.Y - c.Center.Y},
+	} {
+		if e.Depth < best.Depth {
+			best = e
+		}
+	}
+	best.Depth += c.Radius
+	return best, true
+}
+
+// rectRect 沿需要移动距离最短的方向把第二个矩形推出去
+func rectRect(a, b geometry.Rect) (Contact, bool) {
+	if !a.Overlaps(b) {
+		return Contact{}, false
+	}
+	best := Contact{geometry.Pt(1, 0), a.Max.X - b.Min.X}
+	for _, c := range []Contact{
+		{geometry.Pt(-1, 0), b.Max.X - a.Min.X},
+		{geometry.Pt(0, 1), a.Max.Y - b.Min.Y},
+		{geometry.Pt(0, -1), b.Max.Y - a.Min.Y},
+	} {
+		if c.Depth < best.Depth {
+			best = c
+		}
+	}
+	return best, true
+}
@@ -0,0 +1,265 @@
+package collision
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+func TestCollide(t *testing.T) {
+	circle := func(x, y, r float64) geometry.Circle { return geometry.Circle{Center: geometry.Pt(x, y), Radius: r} }
+	tests := []struct {
+		name   string
+		a, b   geometry.Shape
+		ok     bool
+		normal geometry.Point
+		depth  float64
+	}{
+		{"circles overlap", circle(0, 0, 2), circle(3, 0, 2), true, geometry.Pt(1, 0), 1},
+		{"circles touch", circle(0, 0, 1), circle(0, 2, 1), true, geometry.Pt(0, 1), 0},
+		{"circles apart", circle(0, 0, 1), circle(3, 0, 1), false, geometry.Point{}, 0},
+		{"circle near rect corner", circle(3, 3, 1.5), geometry.R(0, 0, 2, 2), true, geometry.Pt(-math.Sqrt2/2, -math.Sqrt2/2), 1.5 - math.Sqrt2},
+		{"circle off rect corner", circle(3, 3, 1.4), geometry.R(0, 0, 2, 2), false, geometry.Point{}, 0},
+		{"rect then circle", geometry.R(0, 0, 2, 2), circle(1, 2.5, 1), true, geometry.Pt(0, 1), 0.5},
+		{"circle center inside rect", circle(1, 0.5, 1), geometry.R(0, 0, 4, 4), true, geometry.Pt(0, 1), 1.5},
+		{"rects overlap in x", geometry.R(0, 0, 2, 2), geometry.R(1.5, 0.5, 4, 1.5), true, geometry.Pt(1, 0), 0.5},
+		{"rects overlap in y", geometry.R(0, 0, 2, 2), geometry.R(0, -1.8, 2, 0.2), true, geometry.Pt(0, -1), 0.2},
+		{"rects apart", geometry.R(0, 0, 1, 1), geometry.R(2, 0, 3, 1), false, geometry.Point{}, 0},
+	}
+	for _, test := range tests {
+		c, ok := Collide(test.a, test.b)
+		if ok != test.ok {
+			t.Errorf("%s: Collide = %t; want %t", test.name, ok, test.ok)
+			continue
+		}
+		if ok && (!c.Normal.Eq(test.normal) || math.Abs(c.Depth-test.depth) > 1e-9) {
+			t.Errorf("%s: Contact = %+v; want normal %v depth %g", test.name, c, test.normal, test.depth)
+		}
+	}
+}
+
+// TestContactSeparates 沿法线移动 Depth 之后两个图形恰好接触：再多移动一点就分开
+func TestContactSeparates(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	shape := func() geometry.Shape {
+		p := geometry.Pt(rng.Float64()*4, rng.Float64()*4)
+		if rng.Intn(2) == 0 {
+			return geometry.Circle{Center: p, Radius: 0.5 + rng.Float64()}
+		}
+		return geometry.R(p.X, p.Y, p.X+0.5+rng.Float64()*2, p.Y+0.5+rng.Float64()*2)
+	}
+	for i := 0; i < 1000; i++ {
+		a, b := shape(), shape()
+		c, ok := Collide(a, b)
+		if !ok {
+			continue
+		}
+		moved := geometry.Translate(b, c.Normal.X*(c.Depth+1e-6), c.Normal.Y*(c.Depth+1e-6))
+		if _, still := Collide(a, moved); still {
+			t.Fatalf("%v and %v still collide after moving by %+v", a, b, c)
+		}
+	}
+}
+
+func randomRect(rng *rand.Rand, world float64) geometry.Rect {
+	x, y := rng.Float64()*world, rng.Float64()*world
+	return geometry.R(x, y, x+1+rng.Float64()*15, y+1+rng.Float64()*15)
+}
+
+// TestGridMatchesBruteForce 随机插入、移动和删除之后，查询结果与逐个比较的结果一致
+func TestGridMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	const n, world = 500, 300
+	g := NewGrid[int](10)
+	bounds := make(map[int]geometry.Rect)
+	for i := 0; i < n; i++ {
+		bounds[i] = randomRect(rng, world)
+		g.Set(i, bounds[i])
+	}
+	for i := 0; i < n; i += 2 {
+		b := bounds[i]
+		bounds[i] = geometry.Rect{Min: b.Min.Add(geometry.Pt(rng.Float64()*6-3, rng.Float64()*6-3)), Max: b.Max.Add(geometry.Pt(3, 3))}
+		g.Set(i, bounds[i])
+	}
+	for i := 1; i < n; i += 10 {
+		delete(bounds, i)
+		g.Remove(i)
+	}
+	if g.Len() != len(bounds) {
+		t.Fatalf("Len() = %d; want %d", g.Len(), len(bounds))
+	}
+
+	region := geometry.R(50, 80, 140, 120)
+	var got, want []int
+	g.Query(region, func(id int, _ geometry.Rect) bool {
+		got = append(got, id)
+		return true
+	})
+	for id, b := range bounds {
+		if b.Overlaps(region) {
+			want = append(want, id)
+		}
+	}
+	sort.Ints(got)
+	sort.Ints(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Query = %v\nwant %v", got, want)
+	}
+
+	pairs := make(map[[2]int]int)
+	g.Pairs(func(a, b int) bool {
+		if a > b {
+			a, b = b, a
+		}
+		pairs[[2]int{a, b}]++
+		return true
+	})
+	wantPairs := 0
+	for a, ba := range bounds {
+		for b, bb := range bounds {
+			if a < b && ba.Overlaps(bb) {
+				wantPairs++
+				if pairs[[2]int{a, b}] != 1 {
+					t.Errorf("pair (%d, %d) reported %d times; want 1", a, b, pairs[[2]int{a, b}])
+				}
+			}
+		}
+	}
+	if len(pairs) != wantPairs {
+		t.Errorf("Pairs reported %d pairs; want %d", len(pairs), wantPairs)
+	}
+
+	for _, p := range []geometry.Point{{X: 150, Y: 150}, {X: -200, Y: 40}, {X: 299, Y: 0}} {
+		near := g.Nearest(p, 7)
+		var all []float64
+		for _, b := range bounds {
+			all = append(all, distance(p, b))
+		}
+		sort.Float64s(all)
+		if len(near) != 7 {
+			t.Fatalf("Nearest(%v) returned %d; want 7", p, len(near))
+		}
+		for i, nb := range near {
+			if nb.Distance != all[i] || distance(p, bounds[nb.ID]) != nb.Distance {
+				t.Errorf("Nearest(%v)[%d] = %+v; want distance %g", p, i, nb, all[i])
+			}
+		}
+	}
+	if got := g.Nearest(geometry.Pt(0, 0), len(bounds)+5); len(got) != len(bounds) {
+		t.Errorf("Nearest with n > Len returned %d; want %d", len(got), len(bounds))
+	}
+}
+
+// TestNearestFar 对象离查询点很远时，Nearest 不会逐个搜索中间所有的空单元格
+func TestNearestFar(t *testing.T) {
+	g := NewGrid[int](1)
+	g.Set(1, geometry.R(1e6, 0, 1e6+1, 1))
+	g.Set(2, geometry.R(0, 2e6, 1, 2e6+1))
+	start := time.Now()
+	got := g.Nearest(geometry.Pt(0, 0), 1)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Nearest took %v", elapsed)
+	}
+	if len(got) != 1 || got[0].ID != 1 || got[0].Distance != 1e6 {
+		t.Errorf("Nearest = %+v; want object 1 at distance 1e6", got)
+	}
+}
+
+// 基准测试：10k 个移动的玩家，每一步移动所有玩家后找出所有碰撞。
+// 运行 go test -bench . ./collision，Naive 逐对比较是 O(n²)，Grid 是 O(n)。
+
+const playerRadius = 2
+
+type player struct {
+	pos, vel geometry.Point
+}
+
+func newPlayers(n int) ([]player, float64) {
+	rng := rand.New(rand.NewSource(3))
+	// 世界大小随人数增长，保持密度不变
+	world := math.Sqrt(float64(n)) * 20
+	players := make([]player, n)
+	for i := range players {
+		players[i] = player{
+			pos: geometry.Pt(rng.Float64()*world, rng.Float64()*world),
+			vel: geometry.Pt(rng.Float64()*2-1, rng.Float64()*2-1),
+		}
+	}
+	return players, world
+}
+
+func (p *player) step(world float64) {
+	p.pos = p.pos.Add(p.vel)
+	if p.pos.X < 0 || p.pos.X > world {
+		p.vel.X = -p.vel.X
+	}
+	if p.pos.Y < 0 || p.pos.Y > world {
+		p.vel.Y = -p.vel.Y
+	}
+}
+
+func (p *player) circle() geometry.Circle {
+	return geometry.Circle{Center: p.pos, Radius: playerRadius}
+}
+
+var benchSizes = []int{1000, 10000}
+
+func BenchmarkBroadPhase(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("Naive/n=%d", n), func(b *testing.B) {
+			players, world := newPlayers(n)
+			for i := 0; i < b.N; i++ {
+				hits := 0
+				for j := range players {
+					players[j].step(world)
+				}
+				for j := range players {
+					for k := j + 1; k < len(players); k++ {
+						if CircleCircle(players[j].circle(), players[k].circle()) {
+							hits++
+						}
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Grid/n=%d", n), func(b *testing.B) {
+			players, world := newPlayers(n)
+			g := NewGrid[int](4 * playerRadius)
+			for j := range players {
+				g.Set(j, players[j].circle().Bounds())
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hits := 0
+				for j := range players {
+					players[j].step(world)
+					g.Set(j, players[j].circle().Bounds())
+				}
+				g.Pairs(func(j, k int) bool {
+					if CircleCircle(players[j].circle(), players[k].circle()) {
+						hits++
+					}
+					return true
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkNearest(b *testing.B) {
+	players, world := newPlayers(10000)
+	g := NewGrid[int](4 * playerRadius)
+	for j := range players {
+		g.Set(j, players[j].circle().Bounds())
+	}
+	rng := rand.New(rand.NewSource(4))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Nearest(geometry.Pt(rng.Float64()*world, rng.Float64()*world), 10)
+	}
+}
@@ -0,0 +1,247 @@
+package collision
+
+import (
+	"container/heap"
+	"math"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+// Grid 是均匀网格空间索引：平面被分成边长为 cellSize 的正方形单元格，
+// 每个对象登记在它的包围盒覆盖的所有单元格中。
+// 对象大小相近、分布比较均匀时（例如大量移动的玩家），插入、移动和删除都是 O(1)，
+// 区域查询只需要检查区域覆盖的单元格，宽阶段找出所有相交对的代价与对象数量成线性关系。
+// cellSize 取对象直径的 1 到 2 倍效果最好。
+//
+// Grid 不是并发安全的。
+type Grid[K comparable] struct {
+	cellSize float64
+	cells    map[cell][]*entry[K]
+	items    map[K]*entry[K]
+}
+
+type cell struct {
+	x, y int
+}
+
+// cellRange 是包围盒覆盖的单元格范围（包含两端）
+type cellRange struct {
+	min, max cell
+}
+
+type entry[K comparable] struct {
+	id     K
+	bounds geometry.Rect
+	cells  cellRange
+}
+
+// NewGrid 创建单元格边长为 cellSize 的网格。
+func NewGrid[K comparable](cellSize float64) *Grid[K] {
+	if cellSize <= 0 {
+		panic("collision: 单元格边长必须为正")
+	}
+	return &Grid[K]{cellSize: cellSize, cells: make(map[cell][]*entry[K]), items: make(map[K]*entry[K])}
+}
+
+func (g *Grid[K]) cellOf(p geometry.Point) cell {
+	return cell{int(math.Floor(p.X / g.cellSize)), int(math.Floor(p.Y / g.cellSize))}
+}
+
+func (g *Grid[K]) cellsOf(r geometry.Rect) cellRange {
+	return cellRange{g.cellOf(r.Min), g.cellOf(r.Max)}
+}
+
+// Len 返回索引中的对象数量。
+func (g *Grid[K]) Len() int {
+	return len(g.items)
+}
+
+// Bounds 返回对象登记的包围盒。
+func (g *Grid[K]) Bounds(id K) (geometry.Rect, bool) {
+	e, ok := g.items[id]
+	if !ok {
+		return geometry.Rect{}, false
+	}
+	return e.bounds, true
+}
+
+// Set 插入对象或者更新它的包围盒。对象移动后没有跨越单元格时只更新包围盒。
+func (g *Grid[K]) Set(id K, bounds geometry.Rect) {
+	cells := g.cellsOf(bounds)
+	e, ok := g.items[id]
+	if ok {
+		e.bounds = bounds
+		if e.cells == cells {
+			return
+		}
+		g.unlink(e)
+		e.cells = cells
+	} else {
+		e = &entry[K]{id, bounds, cells}
+		g.items[id] = e
+	}
+	for x := cells.min.x; x <= cells.max.x; x++ {
+		for y := cells.min.y; y <= cells.max.y; y++ {
+			c := cell{x, y}
+			g.cells[c] = append(g.cells[c], e)
+		}
+	}
+}
+
+// Remove 删除对象，对象不存在时什么也不做。
+func (g *Grid[K]) Remove(id K) {
+	if e, ok := g.items[id]; ok {
+		g.unlink(e)
+		delete(g.items, id)
+	}
+}
+
+// unlink 把对象从单元格中移除
+func (g *Grid[K]) unlink(e *entry[K]) {
+	for x := e.cells.min.x; x <= e.cells.max.x; x++ {
+		for y := e.cells.min.y; y <= e.cells.max.y; y++ {
+			c := cell{x, y}
+			es := g.cells[c]
+			for i, other := range es {
+				if other == e {
+					es[i] = es[len(es)-1]
+					es[len(es)-1] = nil
+					es = es[:len(es)-1]
+					break
+				}
+			}
+			if len(es) == 0 {
+				delete(g.cells, c)
+			} else {
+				g.cells[c] = es
+			}
+		}
+	}
+}
+
+// Query 对包围盒与 region 相交的每个对象调用一次 fn，fn 返回 false 时停止。
+// 跨越多个单元格的对象只在包含“对象与区域交集左下角”的那个单元格中报告，因此不需要去重。
+func (g *Grid[K]) Query(region geometry.Rect, fn func(id K, bounds geometry.Rect) bool) {
+	cells := g.cellsOf(region)
+	for x := cells.min.x; x <= cells.max.x; x++ {
+		for y := cells.min.y; y <= cells.max.y; y++ {
+			c := cell{x, y}
+			for _, e := range g.cells[c] {
+				b := e.bounds
+				if !b.Overlaps(region) || g.cellOf(b.Intersect(region).Min) != c {
+					continue
+				}
+				if !fn(e.id, b) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Pairs 对包围盒相交的每一对对象调用一次 fn（宽阶段），fn 返回 false 时停止。
+// 只比较同一单元格中的对象，并且只在包含两者交集左下角的单元格中报告。
+func (g *Grid[K]) Pairs(fn func(a, b K) bool) {
+	for c, es := range g.cells {
+		for i, a := range es {
+			for _, b := range es[i+1:] {
+				if !a.bounds.Overlaps(b.bounds) || g.cellOf(a.bounds.Intersect(b.bounds).Min) != c {
+					continue
+				}
+				if !fn(a.id, b.id) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Neighbor 是 Nearest 的结果。
+type Neighbor[K comparable] struct {
+	ID       K
+	Distance float64 // 点到对象包围盒的距离，点在包围盒内时为 0
+}
+
+// Nearest 返回包围盒离 p 最近的至多 n 个对象，按距离从近到远排列。
+// 从 p 所在的单元格开始一圈一圈向外搜索，已经找到的第 n 近的对象
+// 比任何未搜索的单元格都近时停止。对象离 p 很远时，搜索过的空单元格会比对象还多，
+// 这时改为逐个检查剩下的对象，因此代价不会超过 O(Len)。
+func (g *Grid[K]) Nearest(p geometry.Point, n int) []Neighbor[K] {
+	if n <= 0 || len(g.items) == 0 {
+		return nil
+	}
+	center := g.cellOf(p)
+	best := &neighborHeap[K]{}
+	seen := make(map[K]bool)
+	consider := func(e *entry[K]) {
+		if seen[e.id] {
+			return
+		}
+		seen[e.id] = true
+		d := distance(p, e.bounds)
+		if best.Len() < n {
+			heap.Push(best, Neighbor[K]{e.id, d})
+		} else if d < (*best)[0].Distance {
+			(*best)[0] = Neighbor[K]{e.id, d}
+			heap.Fix(best, 0)
+		}
+	}
+	visited := 0
+	visit := func(c cell) {
+		visited++
+		for _, e := range g.cells[c] {
+			consider(e)
+		}
+	}
+	for ring := 0; ; ring++ {
+		if ring == 0 {
+			visit(center)
+		} else {
+			for i := -ring; i <= ring; i++ {
+				visit(cell{center.x + i, center.y - ring})
+				visit(cell{center.x + i, center.y + ring})
+				if i != -ring && i != ring {
+					visit(cell{center.x - ring, center.y + i})
+					visit(cell{center.x + ring, center.y + i})
+				}
+			}
+		}
+		// 第 ring+1 圈中的点离 p 至少 ring*cellSize
+		if best.Len() == n && (*best)[0].Distance <= float64(ring)*g.cellSize {
+			break
+		}
+		if len(seen) == len(g.items) {
+			break
+		}
+		if visited >= len(g.items) {
+			for _, e := range g.items {
+				consider(e)
+			}
+			break
+		}
+	}
+	result := make([]Neighbor[K], best.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(best).(Neighbor[K])
+	}
+	return result
+}
+
+// distance 返回点到矩形的距离
+func distance(p geometry.Point, r geometry.Rect) float64 {
+	return p.Distance(clamp(p, r))
+}
+
+// neighborHeap 是按距离排列的最大堆，堆顶是当前第 n 近的对象
+type neighborHeap[K comparable] []Neighbor[K]
+
+func (h neighborHeap[K]) Len() int            { return len(h) }
+func (h neighborHeap[K]) Less(i, j int) bool  { return h[i].Distance > h[j].Distance }
+func (h neighborHeap[K]) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap[K]) Push(x interface{}) { *h = append(*h, x.(Neighbor[K])) }
+func (h *neighborHeap[K]) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
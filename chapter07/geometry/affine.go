@@ -0,0 +1,85 @@
+package geometry
+
+import "math"
+
+// Affine 是平面上的仿射变换：
+//
+//	x' = A*x + B*y + C
+//	y' = D*x + E*y + F
+//
+// 零值不是恒等变换，使用 Identity 获得恒等变换。
+type Affine struct {
+	A, B, C float64
+	D, E, F float64
+}
+
+// Identity 返回恒等变换。
+func Identity() Affine {
+	return Affine{A: 1, E: 1}
+}
+
+// Translation 返回平移 (dx, dy) 的变换。
+func Translation(dx, dy float64) Affine {
+	return Affine{A: 1, C: dx, E: 1, F: dy}
+}
+
+// Rotation 返回绕原点逆时针旋转 angle 弧度的变换。
+func Rotation(angle float64) Affine {
+	sin, cos := math.Sincos(angle)
+	return Affine{A: cos, B: -sin, D: sin, E: cos}
+}
+
+// Scaling 返回以原点为中心、在 x、y 方向分别缩放 sx、sy 倍的变换。
+func Scaling(sx, sy float64) Affine {
+	return Affine{A: sx, E: sy}
+}
+
+// About 返回以 center 为不动点执行 m 的变换，例如 Rotation(a).About(c) 绕 c 旋转。
+func (m Affine) About(center Point) Affine {
+	return Translation(-center.X, -center.Y).Then(m).Then(Translation(center.X, center.Y))
+}
+
+// Then 返回先执行 m 再执行 n 的变换。
+func (m Affine) Then(n Affine) Affine {
+	return Affine{
+		A: n.A*m.A + n.B*m.D,
+		B: n.A*m.B + n.B*m.E,
+		C: n.A*m.C + n.B*m.F + n.C,
+		D: n.D*m.A + n.E*m.D,
+		E: n.D*m.B + n.E*m.E,
+		F: n.D*m.C + n.E*m.F + n.F,
+	}
+}
+
+// Apply 返回变换后的点。
+func (m Affine) Apply(p Point) Point {
+	return Point{m.A*p.X + m.B*p.Y + m.C, m.D*p.X + m.E*p.Y + m.F}
+}
+
+// Det 返回线性部分的行列式，其绝对值是面积的缩放倍数，为负时变换包含镜像。
+func (m Affine) Det() float64 {
+	return m.A*m.E - m.B*m.D
+}
+
+// Invert 返回逆变换。变换不可逆（行列式为 0）时 ok 为 false。
+func (m Affine) Invert() (inv Affine, ok bool) {
+	det := m.Det()
+	if math.Abs(det) <= Epsilon {
+		return Affine{}, false
+	}
+	inv = Affine{A: m.E / det, B: -m.B / det, D: -m.D / det, E: m.A / det}
+	inv.C = -(inv.A*m.C + inv.B*m.F)
+	inv.F = -(inv.D*m.C + inv.E*m.F)
+	return inv, true
+}
+
+// isSimilarity 报告变换是否只包含旋转、均匀缩放、镜像和平移（保持圆仍然是圆）
+func (m Affine) isSimilarity() bool {
+	return (math.Abs(m.A-m.E) <= Epsilon && math.Abs(m.B+m.D) <= Epsilon) ||
+		(math.Abs(m.A+m.E) <= Epsilon && math.Abs(m.B-m.D) <= Epsilon)
+}
+
+// isAxisAligned 报告变换是否保持坐标轴方向（只有缩放和平移，矩形仍然是轴对齐的矩形）
+func (m Affine) isAxisAligned() bool {
+	return m.B == 0 && m.D == 0
+}
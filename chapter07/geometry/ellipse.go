@@ -0,0 +1,100 @@
+package geometry
+
+import "math"
+
+// Circle 是圆心为 Center、半径为 Radius 的圆。
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// Area 返回圆的面积。
+func (c Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
+}
+
+// Perimeter 返回圆的周长。
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
+}
+
+// Bounds 返回圆的外接正方形。
+func (c Circle) Bounds() Rect {
+	return Rect{
+		Point{c.Center.X - c.Radius, c.Center.Y - c.Radius},
+		Point{c.Center.X + c.Radius, c.Center.Y + c.Radius},
+	}
+}
+
+// Contains 报告点 p 是否在圆内或圆周上。
+func (c Circle) Contains(p Point) bool {
+	return c.Center.Distance(p) <= c.Radius+Epsilon
+}
+
+// Transform 返回变换后的图形：相似变换（旋转、均匀缩放、镜像、平移）后仍然是 Circle，否则是 Ellipse。
+func (c Circle) Transform(m Affine) Shape {
+	if m.isSimilarity() {
+		return Circle{m.Apply(c.Center), c.Radius * math.Sqrt(math.Abs(m.Det()))}
+	}
+	return Ellipse{Center: c.Center, RX: c.Radius, RY: c.Radius}.Transform(m)
+}
+
+// Ellipse 是中心为 Center、两个半轴为 RX、RY 的椭圆，
+// RX 所在的轴相对 x 轴逆时针旋转了 Angle 弧度。
+type Ellipse struct {
+	Center Point
+	RX, RY float64
+	Angle  float64
+}
+
+// Area 返回椭圆的面积。
+func (e Ellipse) Area() float64 {
+	return math.Pi * e.RX * e.RY
+}
+
+// Perimeter 用 Ramanujan 的第二个近似公式计算椭圆的周长，
+// 接近圆时几乎精确，最扁的情况下相对误差约为 4e-5。
+func (e Ellipse) Perimeter() float64 {
+	a, b := e.RX, e.RY
+	if a+b == 0 {
+		return 0
+	}
+	h := (a - b) * (a - b) / ((a + b) * (a + b))
+	return math.Pi * (a + b) * (1 + 3*h/(10+math.Sqrt(4-3*h)))
+}
+
+// Bounds 返回旋转后的椭圆的包围盒。
+func (e Ellipse) Bounds() Rect {
+	sin, cos := math.Sincos(e.Angle)
+	w := math.Hypot(e.RX*cos, e.RY*sin)
+	h := math.Hypot(e.RX*sin, e.RY*cos)
+	return Rect{Point{e.Center.X - w, e.Center.Y - h}, Point{e.Center.X + w, e.Center.Y + h}}
+}
+
+// Contains 报告点 p 是否在椭圆内或边界上。
+func (e Ellipse) Contains(p Point) bool {
+	if e.RX <= 0 || e.RY <= 0 {
+		return false
+	}
+	q := p.Sub(e.Center).Rotate(-e.Angle)
+	x, y := q.X/e.RX, q.Y/e.RY
+	return x*x+y*y <= 1+Epsilon
+}
+
+// Transform 返回变换后的椭圆。椭圆在任意仿射变换下仍然是椭圆：
+// 新的半轴是线性部分 L 作用在原椭圆上之后的奇异值，由 L·Lᵀ 的特征分解得到。
+func (e Ellipse) Transform(m Affine) Shape {
+	// l = m 的线性部分 · 旋转 Angle · 缩放 (RX, RY)，把单位圆映射为变换后的椭圆
+	l := Scaling(e.RX, e.RY).Then(Rotation(e.Angle)).Then(Affine{A: m.A, B: m.B, D: m.D, E: m.E})
+	p := l.A*l.A + l.B*l.B
+	q := l.A*l.D + l.B*l.E
+	r := l.D*l.D + l.E*l.E
+	mid := (p + r) / 2
+	d := math.Hypot((p-r)/2, q)
+	return Ellipse{
+		Center: m.Apply(e.Center),
+		RX:     math.Sqrt(mid + d),
+		RY:     math.Sqrt(math.Max(mid-d, 0)),
+		Angle:  math.Atan2(2*q, p-r) / 2,
+	}
+}
@@ -0,0 +1,201 @@
+package geometry
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func nearRect(r, s Rect) bool {
+	return near(r.Min.X, s.Min.X) && near(r.Min.Y, s.Min.Y) && near(r.Max.X, s.Max.X) && near(r.Max.Y, s.Max.Y)
+}
+
+func TestMeasures(t *testing.T) {
+	tests := []struct {
+		name            string
+		s               Shape
+		area, perimeter float64
+		bounds          Rect
+	}{
+		{"rect", R(1, 2, 5, 5), 12, 14, R(1, 2, 5, 5)},
+		{"circle", Circle{Pt(1, 1), 2}, 4 * math.Pi, 4 * math.Pi, R(-1, -1, 3, 3)},
+		{"ellipse", Ellipse{Pt(0, 0), 3, 1, math.Pi / 2}, 3 * math.Pi, 13.364893220555258, R(-1, -3, 1, 3)},
+		{"triangle", Triangle{Pt(0, 0), Pt(4, 0), Pt(0, 3)}, 6, 12, R(0, 0, 4, 3)},
+		{"L polygon", Polygon{[]Point{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}}, 3, 8, R(0, 0, 2, 2)},
+		{"segment", Segment{Pt(0, 0), Pt(3, 4)}, 0, 5, R(0, 0, 3, 4)},
+	}
+	for _, test := range tests {
+		if a := test.s.Area(); !near(a, test.area) {
+			t.Errorf("%s: Area() = %g; want %g", test.name, a, test.area)
+		}
+		if p := test.s.Perimeter(); !near(p, test.perimeter) {
+			t.Errorf("%s: Perimeter() = %g; want %g", test.name, p, test.perimeter)
+		}
+		if b := test.s.Bounds(); !nearRect(b, test.bounds) {
+			t.Errorf("%s: Bounds() = %v; want %v", test.name, b, test.bounds)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	l := Polygon{[]Point{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}}
+	tests := []struct {
+		s    Shape
+		p    Point
+		want bool
+	}{
+		{l, Pt(0.5, 1.5), true},
+		{l, Pt(1.5, 1.5), false}, // L 形的缺口
+		{l, Pt(1.5, 1), true},    // 边上
+		{l, Pt(2, 2), false},
+		{Triangle{Pt(0, 0), Pt(4, 0), Pt(0, 3)}, Pt(1, 1), true},
+		{Triangle{Pt(0, 0), Pt(4, 0), Pt(0, 3)}, Pt(2, 1.5), true}, // 斜边上
+		{Triangle{Pt(0, 0), Pt(4, 0), Pt(0, 3)}, Pt(3, 3), false},
+		{Ellipse{Pt(0, 0), 3, 1, math.Pi / 4}, Pt(1.5, 1.5), true},
+		{Ellipse{Pt(0, 0), 3, 1, math.Pi / 4}, Pt(1.5, -1.5), false},
+		{Segment{Pt(0, 0), Pt(2, 2)}, Pt(1, 1), true},
+		{Segment{Pt(0, 0), Pt(2, 2)}, Pt(3, 3), false},
+	}
+	for _, test := range tests {
+		if got := test.s.Contains(test.p); got != test.want {
+			t.Errorf("%#v.Contains(%v) = %t; want %t", test.s, test.p, got, test.want)
+		}
+	}
+}
+
+// TestTransform 对每种图形施加随机仿射变换：面积按行列式缩放，
+// 内部的点变换后仍在新图形内，外部的点变换后仍在外部，所有内部点都落在新的包围盒中
+func TestTransform(t *testing.T) {
+	shapes := []Shape{
+		R(-1, -2, 3, 1),
+		Circle{Pt(1, -1), 2},
+		Ellipse{Pt(2, 1), 3, 1, 0.3},
+		Triangle{Pt(0, 0), Pt(4, 1), Pt(1, 3)},
+		Polygon{[]Point{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}},
+	}
+	rng := rand.New(rand.NewSource(1))
+	transforms := []Affine{
+		Translation(3, -2),
+		Rotation(math.Pi / 5).About(Pt(1, 1)),
+		Scaling(2, 0.5),
+		Scaling(-1, 1), // 镜像
+	}
+	for i := 0; i < 5; i++ {
+		transforms = append(transforms, Affine{
+			A: rng.Float64()*4 - 2, B: rng.Float64()*4 - 2, C: rng.Float64()*10 - 5,
+			D: rng.Float64()*4 - 2, E: rng.Float64()*4 - 2, F: rng.Float64()*10 - 5,
+		})
+	}
+	for _, s := range shapes {
+		b := s.Bounds()
+		for _, m := range transforms {
+			ts := s.Transform(m)
+			if want := s.Area() * math.Abs(m.Det()); !near(ts.Area(), want) {
+				t.Errorf("%T under %v: area %g; want %g", s, m, ts.Area(), want)
+			}
+			tb := ts.Bounds().Inset(-1e-6)
+			for j := 0; j < 200; j++ {
+				p := Pt(b.Min.X+rng.Float64()*b.Dx(), b.Min.Y+rng.Float64()*b.Dy())
+				in := s.Contains(p)
+				// 跳过边界附近的点，避免舍入误差
+				if in && !s.Contains(p.Add(Pt(1e-4, 0))) || !in && s.Contains(p.Add(Pt(1e-4, 0))) {
+					continue
+				}
+				q := m.Apply(p)
+				if ts.Contains(q) != in {
+					t.Errorf("%T under %v: Contains(%v) = %t; original Contains(%v) = %t", s, m, q, !in, p, in)
+				}
+				if in && !tb.Contains(q) {
+					t.Errorf("%T under %v: %v outside Bounds() %v", s, m, q, tb)
+				}
+			}
+		}
+	}
+}
+
+func TestTransformKinds(t *testing.T) {
+	if _, ok := Rotate(R(0, 0, 2, 1), math.Pi/4, Pt(0, 0)).(Polygon); !ok {
+		t.Error("rotated Rect is not a Polygon")
+	}
+	if _, ok := Scale(R(0, 0, 2, 1), 2, 3, Pt(0, 0)).(Rect); !ok {
+		t.Error("scaled Rect is not a Rect")
+	}
+	if c, ok := Rotate(Circle{Pt(1, 0), 1}, math.Pi/2, Pt(0, 0)).(Circle); !ok || !c.Center.Eq(Pt(0, 1)) {
+		t.Errorf("rotated Circle = %v; want Circle at (0, 1)", c)
+	}
+	e, ok := Scale(Circle{Pt(0, 0), 1}, 3, 1, Pt(0, 0)).(Ellipse)
+	if !ok || !near(e.RX, 3) || !near(e.RY, 1) || !near(math.Sin(e.Angle), 0) {
+		t.Errorf("non-uniformly scaled Circle = %+v; want Ellipse 3x1", e)
+	}
+	b := Rotate(R(-1, -1, 1, 1), math.Pi/4, Pt(0, 0)).Bounds()
+	if s := math.Sqrt2; !nearRect(b, R(-s, -s, s, s)) {
+		t.Errorf("Bounds of rotated square = %v", b)
+	}
+
+	m := Rotation(0.7).Then(Scaling(2, 3)).Then(Translation(1, 2))
+	inv, ok := m.Invert()
+	if p := inv.Apply(m.Apply(Pt(3, -4))); !ok || !p.Eq(Pt(3, -4)) {
+		t.Errorf("Invert round trip = %v, %t", p, ok)
+	}
+	if _, ok := Scaling(0, 1).Invert(); ok {
+		t.Error("singular transform inverted")
+	}
+}
+
+func TestConvexHull(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	var points []Point
+	for x := 0; x <= 4; x++ {
+		for y := 0; y <= 4; y++ {
+			points = append(points, Pt(float64(x), float64(y)))
+		}
+	}
+	for i := 0; i < 100; i++ {
+		points = append(points, Pt(rng.Float64()*4, rng.Float64()*4))
+	}
+	rng.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+
+	hull := ConvexHull(points)
+	if len(hull.Points) != 4 || hull.SignedArea() != 16 || !hull.IsConvex() {
+		t.Errorf("ConvexHull = %v (area %g); want the 4x4 square counter-clockwise", hull.Points, hull.SignedArea())
+	}
+	for _, p := range points {
+		if !hull.Contains(p) {
+			t.Errorf("hull does not contain %v", p)
+		}
+	}
+	if c := hull.Centroid(); !c.Eq(Pt(2, 2)) {
+		t.Errorf("Centroid() = %v; want (2, 2)", c)
+	}
+	if (Polygon{[]Point{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}}).IsConvex() {
+		t.Error("L shape reported convex")
+	}
+}
+
+func TestSegmentIntersect(t *testing.T) {
+	tests := []struct {
+		s, o Segment
+		p    Point
+		ok   bool
+	}{
+		{Segment{Pt(0, 0), Pt(2, 2)}, Segment{Pt(0, 2), Pt(2, 0)}, Pt(1, 1), true},
+		{Segment{Pt(0, 0), Pt(2, 0)}, Segment{Pt(2, 0), Pt(3, 5)}, Pt(2, 0), true}, // 端点相接
+		{Segment{Pt(0, 0), Pt(0.9, 0.9)}, Segment{Pt(0, 2), Pt(2, 0)}, Point{}, false},
+		{Segment{Pt(0, 0), Pt(2, 0)}, Segment{Pt(0, 1), Pt(2, 1)}, Point{}, false}, // 平行
+		{Segment{Pt(0, 0), Pt(4, 0)}, Segment{Pt(5, 0), Pt(2, 0)}, Pt(2, 0), true}, // 共线重合
+		{Segment{Pt(0, 0), Pt(1, 0)}, Segment{Pt(2, 0), Pt(3, 0)}, Point{}, false}, // 共线不重合
+	}
+	for _, test := range tests {
+		p, ok := test.s.Intersect(test.o)
+		if ok != test.ok || ok && !p.Eq(test.p) {
+			t.Errorf("%v.Intersect(%v) = %v, %t; want %v, %t", test.s, test.o, p, ok, test.p, test.ok)
+		}
+	}
+	if d := (Segment{Pt(0, 0), Pt(4, 0)}).DistanceTo(Pt(5, 3)); !near(d, math.Hypot(1, 3)) {
+		t.Errorf("DistanceTo = %g", d)
+	}
+}
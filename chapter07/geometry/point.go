@@ -0,0 +1,76 @@
+// Package geometry 提供带位置的二维几何图形：点、包围盒、圆、椭圆、三角形、多边形和线段。
+//
+// 每种图形都实现 Shape 接口：面积、周长、轴对齐包围盒（Bounds）、点包含测试，
+// 以及仿射变换（Transform）。变换可能改变图形的种类，例如旋转后的矩形变成多边形、
+// 非均匀缩放后的圆变成椭圆，因此 Transform 返回 Shape 而不是原来的类型。
+//
+// 坐标系的 x 轴向右、y 轴向上，角度以弧度为单位、逆时针为正。
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// Epsilon 是浮点比较的容差：相差不超过 Epsilon 的值视为相等。
+const Epsilon = 1e-9
+
+// Point 是平面上的点，也用作二维向量。
+type Point struct {
+	X, Y float64
+}
+
+// Pt 是 Point{x, y} 的简写。
+func Pt(x, y float64) Point {
+	return Point{x, y}
+}
+
+// Add 返回 p+q。
+func (p Point) Add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+// Sub 返回 p-q。
+func (p Point) Sub(q Point) Point {
+	return Point{p.X - q.X, p.Y - q.Y}
+}
+
+// Mul 返回 p 乘以 k。
+func (p Point) Mul(k float64) Point {
+	return Point{p.X * k, p.Y * k}
+}
+
+// Dot 返回点积 p·q。
+func (p Point) Dot(q Point) float64 {
+	return p.X*q.X + p.Y*q.Y
+}
+
+// Cross 返回叉积 p×q 的 z 分量：q 在 p 的逆时针方向时为正。
+func (p Point) Cross(q Point) float64 {
+	return p.X*q.Y - p.Y*q.X
+}
+
+// Len 返回向量的长度。
+func (p Point) Len() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Distance 返回两点之间的距离。
+func (p Point) Distance(q Point) float64 {
+	return p.Sub(q).Len()
+}
+
+// Rotate 返回 p 绕原点逆时针旋转 angle 弧度后的点。
+func (p Point) Rotate(angle float64) Point {
+	sin, cos := math.Sincos(angle)
+	return Point{p.X*cos - p.Y*sin, p.X*sin + p.Y*cos}
+}
+
+// Eq 报告两点的坐标是否在 Epsilon 之内相等。
+func (p Point) Eq(q Point) bool {
+	return math.Abs(p.X-q.X) <= Epsilon && math.Abs(p.Y-q.Y) <= Epsilon
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%g, %g)", p.X, p.Y)
+}
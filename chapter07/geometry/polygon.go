@@ -0,0 +1,204 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+)
+
+// Polygon 是由顶点依次连接、最后一个顶点连回第一个顶点组成的简单多边形。
+// 顶点可以按顺时针或逆时针排列；自相交的多边形的面积没有意义。
+type Polygon struct {
+	Points []Point
+}
+
+// SignedArea 用鞋带公式计算有向面积：顶点逆时针排列时为正，顺时针时为负。
+func (pg Polygon) SignedArea() float64 {
+	n := len(pg.Points)
+	sum := 0.0
+	for i, p := range pg.Points {
+		sum += p.Cross(pg.Points[(i+1)%n])
+	}
+	return sum / 2
+}
+
+// Area 返回多边形的面积。
+func (pg Polygon) Area() float64 {
+	return math.Abs(pg.SignedArea())
+}
+
+// Perimeter 返回多边形的周长。
+func (pg Polygon) Perimeter() float64 {
+	n := len(pg.Points)
+	sum := 0.0
+	for i, p := range pg.Points {
+		sum += p.Distance(pg.Points[(i+1)%n])
+	}
+	return sum
+}
+
+// Bounds 返回多边形的包围盒。
+func (pg Polygon) Bounds() Rect {
+	return RectAround(pg.Points...)
+}
+
+// Centroid 返回多边形的质心，面积为 0 时返回顶点的平均值。
+func (pg Polygon) Centroid() Point {
+	n := len(pg.Points)
+	if n == 0 {
+		return Point{}
+	}
+	a := pg.SignedArea()
+	if math.Abs(a) <= Epsilon {
+		var sum Point
+		for _, p := range pg.Points {
+			sum = sum.Add(p)
+		}
+		return sum.Mul(1 / float64(n))
+	}
+	var c Point
+	for i, p := range pg.Points {
+		q := pg.Points[(i+1)%n]
+		c = c.Add(p.Add(q).Mul(p.Cross(q)))
+	}
+	return c.Mul(1 / (6 * a))
+}
+
+// Contains 报告点 p 是否在多边形内部或边上。
+// 内部按奇偶规则判断：从 p 向右的射线与边相交奇数次时在内部。
+func (pg Polygon) Contains(p Point) bool {
+	n := len(pg.Points)
+	inside := false
+	for i, a := range pg.Points {
+		b := pg.Points[(i+1)%n]
+		if (Segment{a, b}).DistanceTo(p) <= Epsilon {
+			return true
+		}
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := a.X + (p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if p.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Edges 返回多边形的边。
+func (pg Polygon) Edges() []Segment {
+	n := len(pg.Points)
+	edges := make([]Segment, n)
+	for i, p := range pg.Points {
+		edges[i] = Segment{p, pg.Points[(i+1)%n]}
+	}
+	return edges
+}
+
+// IsConvex 报告多边形是否为凸多边形（共线的相邻边不影响结果）。
+func (pg Polygon) IsConvex() bool {
+	n := len(pg.Points)
+	if n < 3 {
+		return false
+	}
+	sign := 0.0
+	for i, a := range pg.Points {
+		b, c := pg.Points[(i+1)%n], pg.Points[(i+2)%n]
+		cross := b.Sub(a).Cross(c.Sub(b))
+		if math.Abs(cross) <= Epsilon {
+			continue
+		}
+		if sign != 0 && (cross > 0) != (sign > 0) {
+			return false
+		}
+		sign = cross
+	}
+	return sign != 0
+}
+
+// Transform 返回顶点经过变换后的多边形。
+func (pg Polygon) Transform(m Affine) Shape {
+	points := make([]Point, len(pg.Points))
+	for i, p := range pg.Points {
+		points[i] = m.Apply(p)
+	}
+	return Polygon{points}
+}
+
+// ConvexHull 用 Andrew 单调链算法返回包含所有点的最小凸多边形，
+// 顶点按逆时针排列，共线的点被省略。复杂度为 O(n log n)。
+func ConvexHull(points []Point) Polygon {
+	ps := append([]Point(nil), points...)
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].X != ps[j].X {
+			return ps[i].X < ps[j].X
+		}
+		return ps[i].Y < ps[j].Y
+	})
+	if len(ps) < 3 {
+		return Polygon{ps}
+	}
+	// turn 报告 o -> a -> b 是否向左（逆时针）转
+	turn := func(o, a, b Point) bool {
+		return a.Sub(o).Cross(b.Sub(o)) > Epsilon
+	}
+	hull := make([]Point, 0, 2*len(ps))
+	for _, p := range ps { // 下凸壳
+		for len(hull) >= 2 && !turn(hull[len(hull)-2], hull[len(hull)-1], p) {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(ps) - 2; i >= 0; i-- { // 上凸壳
+		p := ps[i]
+		for len(hull) >= lower && !turn(hull[len(hull)-2], hull[len(hull)-1], p) {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return Polygon{hull[:len(hull)-1]} // 最后一个点与第一个点相同
+}
+
+// Triangle 是以 A、B、C 为顶点的三角形。
+type Triangle struct {
+	A, B, C Point
+}
+
+// Area 返回三角形的面积。
+func (t Triangle) Area() float64 {
+	return math.Abs(t.B.Sub(t.A).Cross(t.C.Sub(t.A))) / 2
+}
+
+// Perimeter 返回三角形的周长。
+func (t Triangle) Perimeter() float64 {
+	return t.A.Distance(t.B) + t.B.Distance(t.C) + t.C.Distance(t.A)
+}
+
+// Bounds 返回三角形的包围盒。
+func (t Triangle) Bounds() Rect {
+	return RectAround(t.A, t.B, t.C)
+}
+
+// Contains 报告点 p 是否在三角形内部或边上：p 相对三条边都在同一侧。
+func (t Triangle) Contains(p Point) bool {
+	d1 := t.B.Sub(t.A).Cross(p.Sub(t.A))
+	d2 := t.C.Sub(t.B).Cross(p.Sub(t.B))
+	d3 := t.A.Sub(t.C).Cross(p.Sub(t.C))
+	neg := d1 < -Epsilon || d2 < -Epsilon || d3 < -Epsilon
+	pos := d1 > Epsilon || d2 > Epsilon || d3 > Epsilon
+	return !(neg && pos)
+}
+
+// Centroid 返回三角形的重心。
+func (t Triangle) Centroid() Point {
+	return t.A.Add(t.B).Add(t.C).Mul(1.0 / 3)
+}
+
+// Polygon 返回与三角形相同的多边形。
+func (t Triangle) Polygon() Polygon {
+	return Polygon{[]Point{t.A, t.B, t.C}}
+}
+
+// Transform 返回顶点经过变换后的三角形。
+func (t Triangle) Transform(m Affine) Shape {
+	return Triangle{m.Apply(t.A), m.Apply(t.B), m.Apply(t.C)}
+}
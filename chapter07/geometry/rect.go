@@ -0,0 +1,140 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rect 是轴对齐的矩形，包含 Min 和 Max 之间的所有点（含边界）。
+// 它既是所有图形的包围盒，本身也是一种 Shape。
+// Min 的坐标都不大于 Max 时称为规范的，用 R 构造的矩形总是规范的。
+type Rect struct {
+	Min, Max Point
+}
+
+// R 返回以 (x0, y0) 和 (x1, y1) 为对角的规范矩形。
+func R(x0, y0, x1, y1 float64) Rect {
+	return Rect{Point{x0, y0}, Point{x1, y1}}.Canon()
+}
+
+// RectAround 返回包含所有点的最小矩形，没有点时返回零值。
+func RectAround(points ...Point) Rect {
+	if len(points) == 0 {
+		return Rect{}
+	}
+	r := Rect{points[0], points[0]}
+	for _, p := range points[1:] {
+		r.Min.X = math.Min(r.Min.X, p.X)
+		r.Min.Y = math.Min(r.Min.Y, p.Y)
+		r.Max.X = math.Max(r.Max.X, p.X)
+		r.Max.Y = math.Max(r.Max.Y, p.Y)
+	}
+	return r
+}
+
+// Canon 返回交换坐标后使 Min 不大于 Max 的矩形。
+func (r Rect) Canon() Rect {
+	if r.Max.X < r.Min.X {
+		r.Min.X, r.Max.X = r.Max.X, r.Min.X
+	}
+	if r.Max.Y < r.Min.Y {
+		r.Min.Y, r.Max.Y = r.Max.Y, r.Min.Y
+	}
+	return r
+}
+
+// Dx 返回矩形的宽度。
+func (r Rect) Dx() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Dy 返回矩形的高度。
+func (r Rect) Dy() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Center 返回矩形的中心。
+func (r Rect) Center() Point {
+	return Point{(r.Min.X + r.Max.X) / 2, (r.Min.Y + r.Max.Y) / 2}
+}
+
+// Empty 报告矩形的面积是否为 0。
+func (r Rect) Empty() bool {
+	return r.Dx() <= 0 || r.Dy() <= 0
+}
+
+// Area 返回矩形的面积。
+func (r Rect) Area() float64 {
+	return r.Dx() * r.Dy()
+}
+
+// Perimeter 返回矩形的周长。
+func (r Rect) Perimeter() float64 {
+	return 2 * (r.Dx() + r.Dy())
+}
+
+// Bounds 返回矩形本身。
+func (r Rect) Bounds() Rect {
+	return r
+}
+
+// Contains 报告点 p 是否在矩形内部或边界上。
+func (r Rect) Contains(p Point) bool {
+	return r.Min.X <= p.X && p.X <= r.Max.X && r.Min.Y <= p.Y && p.Y <= r.Max.Y
+}
+
+// Overlaps 报告两个矩形是否有公共点（包括只接触边界）。
+func (r Rect) Overlaps(s Rect) bool {
+	return r.Min.X <= s.Max.X && s.Min.X <= r.Max.X && r.Min.Y <= s.Max.Y && s.Min.Y <= r.Max.Y
+}
+
+// Intersect 返回两个矩形的交集，不相交时返回零值。
+func (r Rect) Intersect(s Rect) Rect {
+	if !r.Overlaps(s) {
+		return Rect{}
+	}
+	return Rect{
+		Point{math.Max(r.Min.X, s.Min.X), math.Max(r.Min.Y, s.Min.Y)},
+		Point{math.Min(r.Max.X, s.Max.X), math.Min(r.Max.Y, s.Max.Y)},
+	}
+}
+
+// Union 返回同时包含两个矩形的最小矩形。
+func (r Rect) Union(s Rect) Rect {
+	return RectAround(r.Min, r.Max, s.Min, s.Max)
+}
+
+// Inset 返回每条边向内收缩 d 的矩形，d 为负时向外扩展。
+func (r Rect) Inset(d float64) Rect {
+	r.Min.X += d
+	r.Min.Y += d
+	r.Max.X -= d
+	r.Max.Y -= d
+	if r.Min.X > r.Max.X {
+		r.Min.X = (r.Min.X + r.Max.X) / 2
+		r.Max.X = r.Min.X
+	}
+	if r.Min.Y > r.Max.Y {
+		r.Min.Y = (r.Min.Y + r.Max.Y) / 2
+		r.Max.Y = r.Min.Y
+	}
+	return r
+}
+
+// Corners 按逆时针顺序返回矩形的四个顶点，从 Min 开始。
+func (r Rect) Corners() [4]Point {
+	return [4]Point{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+}
+
+func (r Rect) String() string {
+	return fmt.Sprintf("%v-%v", r.Min, r.Max)
+}
+
+// Transform 返回变换后的图形：只有缩放和平移时仍然是 Rect，否则是四边形 Polygon。
+func (r Rect) Transform(m Affine) Shape {
+	if m.isAxisAligned() {
+		return Rect{m.Apply(r.Min), m.Apply(r.Max)}.Canon()
+	}
+	c := r.Corners()
+	return Polygon{c[:]}.Transform(m)
+}
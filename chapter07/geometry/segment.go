@@ -0,0 +1,94 @@
+package geometry
+
+import "math"
+
+// Segment 是以 A、B 为端点的线段。
+// 作为 Shape 时面积为 0，周长为线段长度，Contains 报告点是否在线段上。
+type Segment struct {
+	A, B Point
+}
+
+// Len 返回线段的长度。
+func (s Segment) Len() float64 {
+	return s.A.Distance(s.B)
+}
+
+// Area 总是返回 0。
+func (s Segment) Area() float64 {
+	return 0
+}
+
+// Perimeter 返回线段的长度。
+func (s Segment) Perimeter() float64 {
+	return s.Len()
+}
+
+// Bounds 返回线段的包围盒。
+func (s Segment) Bounds() Rect {
+	return RectAround(s.A, s.B)
+}
+
+// Contains 报告点 p 是否在线段上（距离不超过 Epsilon）。
+func (s Segment) Contains(p Point) bool {
+	return s.DistanceTo(p) <= Epsilon
+}
+
+// Transform 返回端点经过变换后的线段。
+func (s Segment) Transform(m Affine) Shape {
+	return Segment{m.Apply(s.A), m.Apply(s.B)}
+}
+
+// ClosestPoint 返回线段上离 p 最近的点。
+func (s Segment) ClosestPoint(p Point) Point {
+	d := s.B.Sub(s.A)
+	l2 := d.Dot(d)
+	if l2 == 0 {
+		return s.A
+	}
+	t := math.Max(0, math.Min(1, p.Sub(s.A).Dot(d)/l2))
+	return s.A.Add(d.Mul(t))
+}
+
+// DistanceTo 返回点 p 到线段的距离。
+func (s Segment) DistanceTo(p Point) float64 {
+	return s.ClosestPoint(p).Distance(p)
+}
+
+// Intersect 返回两条线段的交点。不相交时 ok 为 false；
+// 两条线段共线且部分重合时有无数个交点，返回重合部分中离 s.A 最近的点。
+func (s Segment) Intersect(o Segment) (p Point, ok bool) {
+	r := s.B.Sub(s.A)
+	q := o.B.Sub(o.A)
+	denom := r.Cross(q)
+	w := o.A.Sub(s.A)
+	if math.Abs(denom) <= Epsilon {
+		if math.Abs(w.Cross(r)) > Epsilon {
+			return Point{}, false // 平行但不共线
+		}
+		return s.overlapStart(o)
+	}
+	t := w.Cross(q) / denom // 交点在 s 上的参数
+	u := w.Cross(r) / denom // 交点在 o 上的参数
+	const tol = Epsilon
+	if t < -tol || t > 1+tol || u < -tol || u > 1+tol {
+		return Point{}, false
+	}
+	return s.A.Add(r.Mul(t)), true
+}
+
+// overlapStart 处理共线的情况：把 o 投影到 s 的参数区间 [0, 1] 上求重合部分
+func (s Segment) overlapStart(o Segment) (Point, bool) {
+	r := s.B.Sub(s.A)
+	l2 := r.Dot(r)
+	if l2 == 0 { // s 退化为一个点
+		return s.A, o.Contains(s.A)
+	}
+	t0 := o.A.Sub(s.A).Dot(r) / l2
+	t1 := o.B.Sub(s.A).Dot(r) / l2
+	lo := math.Max(0, math.Min(t0, t1))
+	hi := math.Min(1, math.Max(t0, t1))
+	if lo > hi+Epsilon {
+		return Point{}, false
+	}
+	return s.A.Add(r.Mul(lo)), true
+}
@@ -0,0 +1,33 @@
+package geometry
+
+// Shape 是带位置的二维图形。
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+	// Bounds 返回包含整个图形的最小轴对齐矩形。
+	Bounds() Rect
+	// Contains 报告点 p 是否在图形内部或边界上。
+	Contains(p Point) bool
+	// Transform 返回经过仿射变换 m 之后的图形，原图形不变。
+	Transform(m Affine) Shape
+}
+
+// Translate 返回平移 (dx, dy) 之后的图形。
+func Translate(s Shape, dx, dy float64) Shape {
+	return s.Transform(Translation(dx, dy))
+}
+
+// Rotate 返回绕 center 逆时针旋转 angle 弧度之后的图形。
+func Rotate(s Shape, angle float64, center Point) Shape {
+	return s.Transform(Rotation(angle).About(center))
+}
+
+// Scale 返回以 center 为中心缩放 (sx, sy) 倍之后的图形。
+func Scale(s Shape, sx, sy float64, center Point) Shape {
+	return s.Transform(Scaling(sx, sy).About(center))
+}
+
+// Center 返回图形包围盒的中心，常用作旋转和缩放的中心。
+func Center(s Shape) Point {
+	return s.Bounds().Center()
+}
@@ -7,6 +7,10 @@ import (
 	"math"
 	"sort"
 	"strings"
+
+	"go-programming-language/chapter07/collision"
+	"go-programming-language/chapter07/geometry"
+	"go-programming-language/chapter07/sim"
 )
 
 // 1. 基本接口定义
@@ -79,6 +83,11 @@ func (p *Player) Move(x, y float64) {
 	p.Y += y
 }
 
+// Circle 返回玩家在世界中占据的圆，用于碰撞检测
+func (p Player) Circle() geometry.Circle {
+	return geometry.Circle{Center: geometry.Pt(p.X, p.Y), Radius: p.Radius}
+}
+
 // 5. 空接口
 func printAnything(value interface{}) {
 	fmt.Printf("值: %v, 类型: %T\n", value, value)
@@ -261,6 +270,46 @@ func main() {
 		animal.Speak()
 		animal.Move()
 	}
+
+	// 14. 带位置的图形
+	fmt.Printf("\n带位置的图形:\n")
+	geometryDemo()
+
+	// 15. 碰撞检测
+	fmt.Printf("\n碰撞检测:\n")
+	collisionDemo()
+
+	// 16. 实体模拟
+	fmt.Printf("\n实体模拟:\n")
+	simDemo()
+}
+
+// geometryDemo 演示 geometry 包：与上面的 Shape 不同，这些图形带有位置，
+// 有包围盒，并且可以平移、旋转和缩放；变换可能改变图形的具体类型
+func geometryDemo() {
+	shapes := []geometry.Shape{
+		geometry.R(0, 0, 4, 2),
+		geometry.Circle{Center: geometry.Pt(5, 5), Radius: 1},
+		geometry.Triangle{A: geometry.Pt(0, 0), B: geometry.Pt(3, 0), C: geometry.Pt(0, 4)},
+		geometry.ConvexHull([]geometry.Point{
+			geometry.Pt(0, 0), geometry.Pt(2, 1), geometry.Pt(4, 0), geometry.Pt(3, 3), geometry.Pt(1, 2), geometry.Pt(0, 4),
+		}),
+	}
+	for _, s := range shapes {
+		rotated := geometry.Rotate(s, math.Pi/4, geometry.Center(s))
+		b, rb := s.Bounds(), rotated.Bounds()
+		fmt.Printf("%T: 面积=%.2f, 包围盒 %.2fx%.2f, 旋转45°后: %T, 包围盒 %.2fx%.2f\n",
+			s, s.Area(), b.Dx(), b.Dy(), rotated, rb.Dx(), rb.Dy())
+	}
+
+	stretched := geometry.Scale(geometry.Circle{Radius: 1}, 3, 1, geometry.Pt(0, 0))
+	fmt.Printf("横向拉伸的圆: %T %+v\n", stretched, stretched)
+
+	a := geometry.Segment{A: geometry.Pt(0, 0), B: geometry.Pt(4, 4)}
+	b := geometry.Segment{A: geometry.Pt(0, 4), B: geometry.Pt(4, 0)}
+	if p, ok := a.Intersect(b); ok {
+		fmt.Printf("线段交点: %v\n", p)
+	}
 }
 
 // 动物接口示例
@@ -303,4 +352,67 @@ func (b Bird) Speak() {
 
 func (b Bird) Move() {
 	fmt.Printf("%s 飞来飞去\n", b.Name)
-} 
\ No newline at end of file
+}
+
+// collisionDemo 把玩家登记到网格空间索引中，移动之后只比较同一单元格里的玩家，
+// 而不是两两比较所有玩家
+func collisionDemo() {
+	players := []*Player{
+		{Name: "Alice", X: 0, Y: 0, Radius: 1},
+		{Name: "Bob", X: 5, Y: 0, Radius: 1},
+		{Name: "Carol", X: 5, Y: 3, Radius: 1.5},
+		{Name: "Dave", X: 20, Y: 20, Radius: 1},
+	}
+	grid := collision.NewGrid[int](4)
+	for i, p := range players {
+		grid.Set(i, p.Circle().Bounds())
+	}
+
+	players[0].Move(3.5, 0) // Alice 走向 Bob
+	grid.Set(0, players[0].Circle().Bounds())
+
+	grid.Pairs(func(i, j int) bool {
+		a, b := players[i].Circle(), players[j].Circle()
+		if c, ok := collision.Collide(a, b); ok {
+			fmt.Printf("%s 与 %s 相撞，穿透深度 %.2f\n", players[i].Name, players[j].Name, c.Depth)
+		}
+		return true
+	})
+
+	for _, n := range grid.Nearest(geometry.Pt(18, 18), 2) {
+		fmt.Printf("离 (18, 18) 最近: %s，距离 %.2f\n", players[n.ID].Name, n.Distance)
+	}
+}
+
+// simDemo 为每个玩家创建一个带速度的物体，按固定步长模拟一秒钟，
+// 再通过 Movable 接口把物体的位移同步回玩家
+func simDemo() {
+	players := []*Player{
+		{Name: "Alice", X: 2, Y: 5, Radius: 1},
+		{Name: "Bob", X: 8, Y: 5, Radius: 1},
+	}
+	world := sim.NewWorld(sim.Config{Bounds: geometry.R(0, 0, 10, 10), Restitution: 1, Seed: 1})
+	bodies := make([]*sim.Body, len(players))
+	for i, p := range players {
+		bodies[i] = world.Add(sim.Body{Name: p.Name, Pos: geometry.Pt(p.X, p.Y), Radius: p.Radius, Mass: 1})
+	}
+	bodies[0].Vel = geometry.Pt(6, 0) // Alice 冲向静止的 Bob
+
+	collisions := 0
+	for i := 0; i < 60; i++ {
+		world.Step()
+		collisions += len(world.Collisions())
+	}
+	fmt.Printf("%.1f 秒内发生 %d 次碰撞\n", world.Time(), collisions)
+
+	var objects []GameObject
+	for i, p := range players {
+		b := bodies[i]
+		p.Move(b.Pos.X-p.X, b.Pos.Y-p.Y)
+		objects = append(objects, p)
+		fmt.Printf("%s 的速度 (%.1f, %.1f)\n", p.Name, b.Vel.X, b.Vel.Y)
+	}
+	for _, obj := range objects {
+		obj.Draw()
+	}
+}
@@ -0,0 +1,74 @@
+// Package render 把 geometry 包的图形画到画布上。
+//
+// Canvas 是绘图后端的接口，只有路径和椭圆两种图元，坐标为像素坐标（y 轴向下）；
+// Draw 把世界坐标中的任意图形经过视图变换后分解为这两种图元。
+// 包中提供两个后端：SVG 输出矢量图，Raster 画到 image.RGBA 上并可以编码为 PNG，
+// 边缘通过计算每个像素被覆盖的面积做抗锯齿。
+// Scene 从 JSON 描述中加载一组图形及其样式，cmd/render 命令用它生成图片。
+package render
+
+import (
+	"fmt"
+	"image/color"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+// Style 描述图形的填充和描边，颜色为 nil 时不绘制相应部分。
+type Style struct {
+	Fill        color.Color
+	Stroke      color.Color
+	StrokeWidth float64 // 描边宽度（像素），<= 0 时为 1
+}
+
+func (s Style) strokeWidth() float64 {
+	if s.StrokeWidth <= 0 {
+		return 1
+	}
+	return s.StrokeWidth
+}
+
+// Canvas 是绘图后端。坐标为像素坐标，原点在左上角，y 轴向下。
+type Canvas interface {
+	// Path 绘制依次连接 points 的折线，closed 为 true 时连回起点。
+	// 填充时总是把路径当作闭合的，按非零环绕规则决定内部。
+	Path(points []geometry.Point, closed bool, style Style)
+	// Ellipse 绘制椭圆（包括圆）。
+	Ellipse(e geometry.Ellipse, style Style)
+}
+
+// Draw 把世界坐标中的图形 s 经过 view 变换到像素坐标后画到 c 上。
+// s 不是 geometry 包中的图形时返回错误。
+func Draw(c Canvas, view geometry.Affine, s geometry.Shape, style Style) error {
+	switch s := s.Transform(view).(type) {
+	case geometry.Rect:
+		corners := s.Corners()
+		c.Path(corners[:], true, style)
+	case geometry.Polygon:
+		c.Path(s.Points, true, style)
+	case geometry.Triangle:
+		c.Path([]geometry.Point{s.A, s.B, s.C}, true, style)
+	case geometry.Segment:
+		c.Path([]geometry.Point{s.A, s.B}, false, style)
+	case geometry.Circle:
+		c.Ellipse(geometry.Ellipse{Center: s.Center, RX: s.Radius, RY: s.Radius}, style)
+	case geometry.Ellipse:
+		c.Ellipse(s, style)
+	default:
+		return fmt.Errorf("render: 不支持的图形类型 %T", s)
+	}
+	return nil
+}
+
+// Fit 返回把世界坐标中的 view 矩形等比例缩放、居中放进 width×height 像素画布的变换，
+// 同时把 y 轴翻转为向下。
+func Fit(view geometry.Rect, width, height int) geometry.Affine {
+	scale := 1.0
+	if view.Dx() > 0 && view.Dy() > 0 {
+		scale = min(float64(width)/view.Dx(), float64(height)/view.Dy())
+	}
+	c := view.Center()
+	return geometry.Translation(-c.X, -c.Y).
+		Then(geometry.Scaling(scale, -scale)).
+		Then(geometry.Translation(float64(width)/2, float64(height)/2))
+}
@@ -0,0 +1,192 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+// subsamples 是每个像素行中的子扫描线数量：垂直方向用多条子扫描线取样，
+// 水平方向精确计算每条子扫描线覆盖像素的长度，两者合起来得到像素的覆盖率
+const subsamples = 4
+
+// Raster 是画到 image.RGBA 上的画布，边缘按像素覆盖率做抗锯齿。
+type Raster struct {
+	img *image.RGBA
+}
+
+// NewRaster 创建 width×height 像素的画布，background 为 nil 时背景透明。
+func NewRaster(width, height int, background color.Color) *Raster {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if background != nil {
+		draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	}
+	return &Raster{img}
+}
+
+// Image 返回画布的图像。
+func (r *Raster) Image() *image.RGBA {
+	return r.img
+}
+
+// WritePNG 把图像编码为 PNG。
+func (r *Raster) WritePNG(w io.Writer) error {
+	return png.Encode(w, r.img)
+}
+
+// Path 实现 Canvas。
+func (r *Raster) Path(points []geometry.Point, closed bool, style Style) {
+	if style.Fill != nil && len(points) >= 3 {
+		r.fill([][]geometry.Point{points}, style.Fill)
+	}
+	if style.Stroke != nil && len(points) >= 2 {
+		r.fill(strokePaths(points, closed, style.strokeWidth()), style.Stroke)
+	}
+}
+
+// Ellipse 实现 Canvas。椭圆被展开成足够细的多边形后绘制。
+func (r *Raster) Ellipse(e geometry.Ellipse, style Style) {
+	r.Path(flattenEllipse(e), true, style)
+}
+
+// flattenEllipse 返回近似椭圆的多边形顶点，相邻顶点相距约 2 像素
+func flattenEllipse(e geometry.Ellipse) []geometry.Point {
+	n := int(math.Ceil(e.Perimeter() / 2))
+	n = max(16, min(n, 4096))
+	points := make([]geometry.Point, n)
+	for i := range points {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		points[i] = geometry.Pt(e.RX*cos, e.RY*sin).Rotate(e.Angle).Add(e.Center)
+	}
+	return points
+}
+
+// strokePaths 把折线的描边转换为一组需要填充的闭合路径：
+// 每条线段是一个宽为 width 的矩形，每个顶点是一个直径为 width 的圆（圆形连接和端点）。
+// 所有路径的方向一致，按非零环绕规则填充时重叠部分只计算一次。
+func strokePaths(points []geometry.Point, closed bool, width float64) [][]geometry.Point {
+	half := width / 2
+	n := len(points)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	var paths [][]geometry.Point
+	for i := 0; i < segments; i++ {
+		a, b := points[i], points[(i+1)%n]
+		d := b.Sub(a)
+		l := d.Len()
+		if l == 0 {
+			continue
+		}
+		normal := geometry.Pt(-d.Y, d.X).Mul(half / l)
+		paths = append(paths, oriented([]geometry.Point{a.Add(normal), b.Add(normal), b.Sub(normal), a.Sub(normal)}))
+	}
+	dot := int(math.Max(8, math.Ceil(math.Pi*width)))
+	for _, p := range points {
+		circle := make([]geometry.Point, dot)
+		for k := range circle {
+			sin, cos := math.Sincos(2 * math.Pi * float64(k) / float64(dot))
+			circle[k] = geometry.Pt(p.X+half*cos, p.Y+half*sin)
+		}
+		paths = append(paths, oriented(circle))
+	}
+	return paths
+}
+
+// oriented 返回有向面积为正的路径
+func oriented(path []geometry.Point) []geometry.Point {
+	if (geometry.Polygon{Points: path}).SignedArea() < 0 {
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+	}
+	return path
+}
+
+// crossing 是子扫描线与边的交点，dir 是边的方向（向下为 +1）
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fill 按非零环绕规则用颜色 c 填充一组闭合路径
+func (r *Raster) fill(paths [][]geometry.Point, c color.Color) {
+	var all []geometry.Point
+	for _, p := range paths {
+		all = append(all, p...)
+	}
+	b := geometry.RectAround(all...)
+	area := image.Rect(int(math.Floor(b.Min.X)), int(math.Floor(b.Min.Y)),
+		int(math.Ceil(b.Max.X)), int(math.Ceil(b.Max.Y))).Intersect(r.img.Bounds())
+	if area.Empty() {
+		return
+	}
+	cover := make([]float64, area.Dx())
+	var xs []crossing
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for i := range cover {
+			cover[i] = 0
+		}
+		for k := 0; k < subsamples; k++ {
+			sy := float64(y) + (float64(k)+0.5)/subsamples
+			xs = xs[:0]
+			for _, path := range paths {
+				for i, p := range path {
+					q := path[(i+1)%len(path)]
+					if (p.Y <= sy) == (q.Y <= sy) {
+						continue
+					}
+					dir := 1
+					if q.Y < p.Y {
+						dir = -1
+					}
+					xs = append(xs, crossing{p.X + (sy-p.Y)*(q.X-p.X)/(q.Y-p.Y), dir})
+				}
+			}
+			sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+			wind, start := 0, 0.0
+			for _, cr := range xs {
+				prev := wind
+				wind += cr.dir
+				if prev == 0 && wind != 0 {
+					start = cr.x
+				} else if prev != 0 && wind == 0 {
+					addSpan(cover, area.Min.X, start, cr.x)
+				}
+			}
+		}
+		for i, a := range cover {
+			if a > 0 {
+				r.blend(area.Min.X+i, y, c, math.Min(a, 1))
+			}
+		}
+	}
+}
+
+// addSpan 把子扫描线上 [x0, x1) 的一段累加到覆盖率中，两端的像素只累加被覆盖的部分
+func addSpan(cover []float64, offset int, x0, x1 float64) {
+	x0 = math.Max(x0, float64(offset))
+	x1 = math.Min(x1, float64(offset+len(cover)))
+	for px := int(math.Floor(x0)); float64(px) < x1; px++ {
+		w := math.Min(x1, float64(px+1)) - math.Max(x0, float64(px))
+		cover[px-offset] += w / subsamples
+	}
+}
+
+// blend 把颜色 c 按覆盖率 a 叠加到像素 (x, y) 上（source-over 合成）
+func (r *Raster) blend(x, y int, c color.Color, a float64) {
+	sr, sg, sb, sa := c.RGBA() // 预乘 alpha，0..0xffff
+	keep := 1 - float64(sa)/0xffff*a
+	pix := r.img.Pix[r.img.PixOffset(x, y):]
+	for i, s := range [4]uint32{sr, sg, sb, sa} {
+		v := float64(s)/0x101*a + float64(pix[i])*keep
+		pix[i] = uint8(math.Min(255, math.Round(v)))
+	}
+}
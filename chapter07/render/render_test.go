@@ -0,0 +1,162 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+var red = color.NRGBA{0xff, 0, 0, 0xff}
+
+// alphaSum 返回图像中所有像素 alpha 之和（以像素为单位），即被覆盖的面积
+func alphaSum(r *Raster) float64 {
+	sum := 0.0
+	for i := 3; i < len(r.img.Pix); i += 4 {
+		sum += float64(r.img.Pix[i]) / 255
+	}
+	return sum
+}
+
+func TestRasterFill(t *testing.T) {
+	r := NewRaster(20, 20, nil)
+	// 左右边缘落在像素中间：第 2 列和第 12 列各覆盖一半
+	rect := geometry.R(2.5, 4, 12.5, 8).Corners()
+	r.Path(rect[:], true, Style{Fill: red})
+	tests := []struct {
+		x, y  int
+		alpha uint8
+	}{
+		{5, 5, 255}, {2, 5, 128}, {12, 5, 128}, {1, 5, 0}, {13, 5, 0}, {5, 3, 0}, {5, 8, 0},
+	}
+	for _, test := range tests {
+		if c := r.img.RGBAAt(test.x, test.y); c.A != test.alpha {
+			t.Errorf("pixel (%d, %d) alpha = %d; want %d", test.x, test.y, c.A, test.alpha)
+		}
+	}
+	if got := alphaSum(r); math.Abs(got-40) > 0.5 {
+		t.Errorf("covered area = %.2f; want 40", got)
+	}
+}
+
+// TestRasterAntialias 圆的覆盖面积接近 πr²，边缘上有半透明的像素
+func TestRasterAntialias(t *testing.T) {
+	r := NewRaster(64, 64, nil)
+	r.Ellipse(geometry.Ellipse{Center: geometry.Pt(32, 32), RX: 20, RY: 20}, Style{Fill: red})
+	if got, want := alphaSum(r), math.Pi*400; math.Abs(got-want)/want > 0.01 {
+		t.Errorf("covered area = %.1f; want %.1f", got, want)
+	}
+	partial := 0
+	for i := 3; i < len(r.img.Pix); i += 4 {
+		if a := r.img.Pix[i]; a > 0 && a < 255 {
+			partial++
+		}
+	}
+	if partial < 50 {
+		t.Errorf("only %d partially covered pixels; edge is not anti-aliased", partial)
+	}
+}
+
+// TestRasterStroke 折线描边：连接处重叠的部分只画一次，闭合路径的内部不填充
+func TestRasterStroke(t *testing.T) {
+	r := NewRaster(40, 40, nil)
+	square := geometry.R(10, 10, 30, 30).Corners()
+	r.Path(square[:], true, Style{Stroke: red, StrokeWidth: 2})
+	// 外框 22x22 减去内框 18x18，外侧四个角是圆角
+	if got, want := alphaSum(r), 22*22-18*18-(4-math.Pi); math.Abs(got-want) > 0.5 {
+		t.Errorf("stroke area = %.2f; want about %.2f", got, want)
+	}
+	if c := r.img.RGBAAt(20, 20); c.A != 0 {
+		t.Errorf("interior alpha = %d; want 0", c.A)
+	}
+	if c := r.img.RGBAAt(10, 20); c.A != 255 {
+		t.Errorf("edge alpha = %d; want 255", c.A)
+	}
+}
+
+func TestBlend(t *testing.T) {
+	r := NewRaster(1, 1, color.White)
+	r.blend(0, 0, color.NRGBA{0, 0, 0xff, 0x80}, 1)
+	want := color.RGBA{127, 127, 255, 255}
+	if got := r.img.RGBAAt(0, 0); got != want {
+		t.Errorf("half-transparent blue over white = %v; want %v", got, want)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var b bytes.Buffer
+	svg := NewSVG(&b, 100, 50, color.White)
+	view := Fit(geometry.R(0, 0, 10, 5), 100, 50)
+	shapes := []geometry.Shape{
+		geometry.R(1, 1, 3, 2),
+		geometry.Rotate(geometry.R(1, 1, 3, 2), math.Pi/2, geometry.Pt(2, 1.5)),
+		geometry.Circle{Center: geometry.Pt(5, 2.5), Radius: 1},
+		geometry.Segment{A: geometry.Pt(0, 0), B: geometry.Pt(10, 5)},
+	}
+	for _, s := range shapes {
+		if err := Draw(svg, view, s, Style{Fill: red, Stroke: color.Black}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svg.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<rect width="100%" height="100%" fill="#ffffff"/>`,
+		`<polygon points="10,30 30,30 30,40 10,40" fill="#ff0000" stroke="#000000"`, // y 轴翻转
+		`<ellipse cx="50" cy="25" rx="10" ry="10"`,
+		`<polyline points="0,50 100,0"`,
+		"</svg>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("SVG output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestScene(t *testing.T) {
+	scene, err := ParseScene([]byte(`{
+		"width": 40, "height": 20, "background": "#fff",
+		"shapes": [
+			{"type": "rect", "min": [0, 0], "max": [4, 2], "fill": "#00f"},
+			{"type": "circle", "center": [1, 1], "radius": 0.5, "fill": "red", "translate": [2, 0]},
+			{"type": "hull", "points": [[0, 0], [1, 0], [0, 1], [0.2, 0.2]], "stroke": "#00000080"}
+		]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.Items) != 3 {
+		t.Fatalf("parsed %d items; want 3", len(scene.Items))
+	}
+	if c := scene.Items[1].Shape.(geometry.Circle); !c.Center.Eq(geometry.Pt(3, 1)) {
+		t.Errorf("translated circle center = %v; want (3, 1)", c.Center)
+	}
+	var b bytes.Buffer
+	if err := scene.WritePNG(&b); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 视图为包围盒外扩 5%，圆心 (3, 1) 在图片的 (29, 10) 附近
+	if r, g, bl, _ := img.At(29, 10).RGBA(); r>>8 != 0xff || g != 0 || bl != 0 {
+		t.Errorf("pixel at circle center = %v; want red", img.At(29, 10))
+	}
+
+	for _, bad := range []string{
+		`{"shapes": [{"type": "star"}]}`,
+		`{"shapes": [{"type": "triangle", "points": [[0, 0], [1, 1]]}]}`,
+		`{"shapes": [{"type": "rect", "fill": "#12"}]}`,
+		`{"shapes": [{"type": "rect", "colour": "red"}]}`,
+	} {
+		if _, err := ParseScene([]byte(bad)); err == nil {
+			t.Errorf("ParseScene(%s) succeeded; want error", bad)
+		}
+	}
+}
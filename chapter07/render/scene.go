@@ -0,0 +1,250 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+// Scene 是一组带样式的图形，以及把它们放到图片上的方式。
+type Scene struct {
+	Width, Height int
+	// View 是图片显示的世界坐标范围，为空时使用所有图形包围盒的并集并在四周留出 5% 的边距。
+	View       geometry.Rect
+	Background color.Color // nil 表示透明
+	Items      []Item
+}
+
+// Item 是场景中的一个图形。
+type Item struct {
+	Shape geometry.Shape
+	Style Style
+}
+
+// jsonScene 是场景的 JSON 格式：
+//
+//	{
+//	  "width": 400, "height": 300, "view": [x0, y0, x1, y1], "background": "#ffffff",
+//	  "shapes": [
+//	    {"type": "circle", "center": [0, 0], "radius": 1, "fill": "red", "stroke": "#000", "strokeWidth": 2},
+//	    {"type": "rect", "min": [0, 0], "max": [2, 1], "rotate": 30}
+//	  ]
+//	}
+//
+// 图形类型有 rect（min、max）、circle（center、radius）、ellipse（center、rx、ry、angle）、
+// triangle、polygon、hull（points 的凸包）和 segment（points）。
+// 每个图形还可以带有 scale（[sx, sy]）、rotate 和 translate（[dx, dy]），
+// 按这个顺序以图形包围盒的中心为中心变换。角度都以度为单位。
+type jsonScene struct {
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	View       *[4]float64  `json:"view"`
+	Background string       `json:"background"`
+	Shapes     []*jsonShape `json:"shapes"`
+}
+
+type jsonShape struct {
+	Type        string       `json:"type"`
+	Min         [2]float64   `json:"min"`
+	Max         [2]float64   `json:"max"`
+	Center      [2]float64   `json:"center"`
+	Radius      float64      `json:"radius"`
+	RX          float64      `json:"rx"`
+	RY          float64      `json:"ry"`
+	Angle       float64      `json:"angle"`
+	Points      [][2]float64 `json:"points"`
+	Scale       *[2]float64  `json:"scale"`
+	Rotate      float64      `json:"rotate"`
+	Translate   *[2]float64  `json:"translate"`
+	Fill        string       `json:"fill"`
+	Stroke      string       `json:"stroke"`
+	StrokeWidth float64      `json:"strokeWidth"`
+}
+
+// ParseScene 解析 JSON 格式的场景描述，宽高缺省时为 400×300。
+func ParseScene(data []byte) (*Scene, error) {
+	var js jsonScene
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&js); err != nil {
+		return nil, fmt.Errorf("render: 解析场景: %w", err)
+	}
+	s := &Scene{Width: js.Width, Height: js.Height}
+	if s.Width <= 0 {
+		s.Width = 400
+	}
+	if s.Height <= 0 {
+		s.Height = 300
+	}
+	if js.View != nil {
+		s.View = geometry.R(js.View[0], js.View[1], js.View[2], js.View[3])
+	}
+	var err error
+	if s.Background, err = ParseColor(js.Background); err != nil {
+		return nil, err
+	}
+	for i, sh := range js.Shapes {
+		item, err := sh.item()
+		if err != nil {
+			return nil, fmt.Errorf("render: 第 %d 个图形: %w", i+1, err)
+		}
+		s.Items = append(s.Items, item)
+	}
+	return s, nil
+}
+
+func (js *jsonShape) item() (Item, error) {
+	shape, err := js.shape()
+	if err != nil {
+		return Item{}, err
+	}
+	center := geometry.Center(shape)
+	if js.Scale != nil {
+		shape = geometry.Scale(shape, js.Scale[0], js.Scale[1], center)
+	}
+	if js.Rotate != 0 {
+		shape = geometry.Rotate(shape, radians(js.Rotate), center)
+	}
+	if js.Translate != nil {
+		shape = geometry.Translate(shape, js.Translate[0], js.Translate[1])
+	}
+	style := Style{StrokeWidth: js.StrokeWidth}
+	if style.Fill, err = ParseColor(js.Fill); err != nil {
+		return Item{}, err
+	}
+	if style.Stroke, err = ParseColor(js.Stroke); err != nil {
+		return Item{}, err
+	}
+	if style.Fill == nil && style.Stroke == nil {
+		style.Stroke = color.Black // 没有指定样式时画黑色轮廓，而不是什么都不画
+	}
+	return Item{shape, style}, nil
+}
+
+func (js *jsonShape) shape() (geometry.Shape, error) {
+	pt := func(p [2]float64) geometry.Point { return geometry.Pt(p[0], p[1]) }
+	points := make([]geometry.Point, len(js.Points))
+	for i, p := range js.Points {
+		points[i] = pt(p)
+	}
+	need := func(n int) error {
+		if len(points) != n {
+			return fmt.Errorf("%s 需要 %d 个点，实际为 %d 个", js.Type, n, len(points))
+		}
+		return nil
+	}
+	switch js.Type {
+	case "rect":
+		return geometry.R(js.Min[0], js.Min[1], js.Max[0], js.Max[1]), nil
+	case "circle":
+		return geometry.Circle{Center: pt(js.Center), Radius: js.Radius}, nil
+	case "ellipse":
+		return geometry.Ellipse{Center: pt(js.Center), RX: js.RX, RY: js.RY, Angle: radians(js.Angle)}, nil
+	case "triangle":
+		if err := need(3); err != nil {
+			return nil, err
+		}
+		return geometry.Triangle{A: points[0], B: points[1], C: points[2]}, nil
+	case "polygon", "hull":
+		if len(points) < 3 {
+			return nil, fmt.Errorf("%s 至少需要 3 个点，实际为 %d 个", js.Type, len(points))
+		}
+		if js.Type == "hull" {
+			return geometry.ConvexHull(points), nil
+		}
+		return geometry.Polygon{Points: points}, nil
+	case "segment":
+		if err := need(2); err != nil {
+			return nil, err
+		}
+		return geometry.Segment{A: points[0], B: points[1]}, nil
+	}
+	return nil, fmt.Errorf("未知的图形类型 %q", js.Type)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// view 返回场景的世界坐标范围
+func (s *Scene) view() geometry.Rect {
+	if !s.View.Empty() || len(s.Items) == 0 {
+		return s.View
+	}
+	b := s.Items[0].Shape.Bounds()
+	for _, item := range s.Items[1:] {
+		b = b.Union(item.Shape.Bounds())
+	}
+	margin := math.Max(b.Dx(), b.Dy()) * 0.05
+	return b.Inset(-margin)
+}
+
+// Render 按顺序把场景中的图形画到画布上。
+func (s *Scene) Render(c Canvas) error {
+	view := Fit(s.view(), s.Width, s.Height)
+	for _, item := range s.Items {
+		if err := Draw(c, view, item.Shape, item.Style); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSVG 把场景输出为 SVG。
+func (s *Scene) WriteSVG(w io.Writer) error {
+	svg := NewSVG(w, s.Width, s.Height, s.Background)
+	if err := s.Render(svg); err != nil {
+		return err
+	}
+	return svg.Close()
+}
+
+// WritePNG 把场景光栅化后输出为 PNG。
+func (s *Scene) WritePNG(w io.Writer) error {
+	r := NewRaster(s.Width, s.Height, s.Background)
+	if err := s.Render(r); err != nil {
+		return err
+	}
+	return r.WritePNG(w)
+}
+
+var namedColors = map[string]color.Color{
+	"black":  color.Black,
+	"white":  color.White,
+	"red":    color.NRGBA{0xff, 0, 0, 0xff},
+	"green":  color.NRGBA{0, 0x80, 0, 0xff},
+	"blue":   color.NRGBA{0, 0, 0xff, 0xff},
+	"yellow": color.NRGBA{0xff, 0xff, 0, 0xff},
+	"gray":   color.NRGBA{0x80, 0x80, 0x80, 0xff},
+}
+
+// ParseColor 解析颜色：#rgb、#rrggbb、#rrggbbaa 或 black、white、red 等颜色名。
+// 空字符串和 "none" 返回 nil，表示不绘制。
+func ParseColor(s string) (color.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "none" {
+		return nil, nil
+	}
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if ok && len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if !ok || len(hex) != 8 || err != nil {
+		return nil, fmt.Errorf("render: 无效的颜色 %q", s)
+	}
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"go-programming-language/chapter07/geometry"
+)
+
+// SVG 是输出 SVG 文档的画布。图元按绘制顺序写出，Close 写出结束标签。
+type SVG struct {
+	w *bufio.Writer
+}
+
+// NewSVG 写出 width×height 像素的 SVG 文档头，background 不为 nil 时先铺满背景色。
+func NewSVG(w io.Writer, width, height int, background color.Color) *SVG {
+	s := &SVG{bufio.NewWriter(w)}
+	fmt.Fprintf(s.w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	if background != nil {
+		fmt.Fprintf(s.w, "<rect width=\"100%%\" height=\"100%%\"%s/>\n", paint("fill", background))
+	}
+	return s
+}
+
+// Path 实现 Canvas。
+func (s *SVG) Path(points []geometry.Point, closed bool, style Style) {
+	elem := "polyline"
+	if closed {
+		elem = "polygon"
+	}
+	var b strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(num(p.X) + "," + num(p.Y))
+	}
+	fmt.Fprintf(s.w, "<%s points=\"%s\"%s/>\n", elem, b.String(), attrs(style))
+}
+
+// Ellipse 实现 Canvas。
+func (s *SVG) Ellipse(e geometry.Ellipse, style Style) {
+	cx, cy := num(e.Center.X), num(e.Center.Y)
+	rotate := ""
+	if deg := e.Angle * 180 / math.Pi; num(deg) != "0" {
+		rotate = fmt.Sprintf(" transform=\"rotate(%s %s %s)\"", num(deg), cx, cy)
+	}
+	fmt.Fprintf(s.w, "<ellipse cx=\"%s\" cy=\"%s\" rx=\"%s\" ry=\"%s\"%s%s/>\n",
+		cx, cy, num(e.RX), num(e.RY), rotate, attrs(style))
+}
+
+// Close 写出结束标签，返回写入过程中遇到的第一个错误。
+func (s *SVG) Close() error {
+	fmt.Fprintln(s.w, "</svg>")
+	return s.w.Flush()
+}
+
+// attrs 返回样式对应的属性，线段连接处和端点都是圆的，与 Raster 一致
+func attrs(style Style) string {
+	var b strings.Builder
+	if style.Fill != nil {
+		b.WriteString(paint("fill", style.Fill))
+	} else {
+		b.WriteString(` fill="none"`)
+	}
+	if style.Stroke != nil {
+		b.WriteString(paint("stroke", style.Stroke))
+		fmt.Fprintf(&b, ` stroke-width="%s" stroke-linejoin="round" stroke-linecap="round"`, num(style.strokeWidth()))
+	}
+	return b.String()
+}
+
+// paint 返回颜色属性，半透明时附加 name-opacity
+func paint(name string, c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	s := fmt.Sprintf(` %s="#%02x%02x%02x"`, name, n.R, n.G, n.B)
+	if n.A != 0xff {
+		s += fmt.Sprintf(` %s-opacity="%s"`, name, num(float64(n.A)/0xff))
+	}
+	return s
+}
+
+// num 把坐标格式化为最多两位小数
+func num(f float64) string {
+	f = math.Round(f*100) / 100
+	if f == 0 {
+		f = 0 // 去掉 -0 的符号
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,50 @@
+package sim
+
+// Rand 是确定性的伪随机数生成器（SplitMix64）。
+// 与 math/rand 不同，它的全部状态只有一个 uint64，可以保存在快照中，
+// 从快照恢复后产生的随机数序列与原来完全相同。
+type Rand struct {
+	state uint64
+}
+
+// NewRand 返回以 seed 为种子的生成器，相同的种子产生相同的序列。
+func NewRand(seed int64) *Rand {
+	return &Rand{uint64(seed)}
+}
+
+// State 返回生成器的内部状态，用于保存快照。
+func (r *Rand) State() uint64 {
+	return r.state
+}
+
+// SetState 恢复 State 返回的状态。
+func (r *Rand) SetState(s uint64) {
+	r.state = s
+}
+
+// Uint64 返回一个均匀分布的 64 位随机数。
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Float64 返回 [0, 1) 中均匀分布的随机数。
+func (r *Rand) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// Range 返回 [lo, hi) 中均匀分布的随机数。
+func (r *Rand) Range(lo, hi float64) float64 {
+	return lo + r.Float64()*(hi-lo)
+}
+
+// Intn 返回 [0, n) 中的随机整数，n <= 0 时 panic。
+func (r *Rand) Intn(n int) int {
+	if n <= 0 {
+		panic("sim: Intn 的参数必须为正")
+	}
+	return int(r.Uint64() % uint64(n))
+}
@@ -0,0 +1,270 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter07/collision"
+	"go-programming-language/chapter07/geometry"
+)
+
+func newTestWorld(seed int64) *World {
+	w := NewWorld(Config{
+		Bounds:      geometry.R(0, 0, 100, 100),
+		Gravity:     geometry.Pt(0, -9.8),
+		Restitution: 0.9,
+		Seed:        seed,
+	})
+	w.Add(Body{Name: "floor", Pos: geometry.Pt(50, 5), Size: geometry.Pt(60, 2)})
+	w.Spawn(50, 1, 3, 20)
+	return w
+}
+
+func encode(t *testing.T, s Snapshot) string {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRand(t *testing.T) {
+	a, b := NewRand(42), NewRand(42)
+	for i := 0; i < 100; i++ {
+		if x, y := a.Uint64(), b.Uint64(); x != y {
+			t.Fatalf("第 %d 个数不同: %d != %d", i, x, y)
+		}
+	}
+	c := NewRand(0)
+	c.SetState(a.State())
+	if a.Uint64() != c.Uint64() {
+		t.Error("恢复状态后序列不同")
+	}
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		f := a.Float64()
+		if f < 0 || f >= 1 {
+			t.Fatalf("Float64() = %g，超出 [0, 1)", f)
+		}
+		counts[a.Intn(4)]++
+	}
+	for i, n := range counts {
+		if n < 800 || n > 1200 {
+			t.Errorf("Intn(4) 返回 %d 的次数 = %d，分布不均匀", i, n)
+		}
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	w := NewWorld(Config{Dt: 0.5})
+	b := w.Add(Body{Vel: geometry.Pt(1, 0), Acc: geometry.Pt(0, 2), Radius: 1, Mass: 1})
+	w.Run(2)
+	// 半隐式欧拉：v1 = (1, 1), p1 = (0.5, 0.5); v2 = (1, 2), p2 = (1, 1.5)
+	if !b.Pos.Eq(geometry.Pt(1, 1.5)) || !b.Vel.Eq(geometry.Pt(1, 2)) {
+		t.Errorf("两步之后 pos = %v, vel = %v; want (1, 1.5), (1, 2)", b.Pos, b.Vel)
+	}
+	if w.Tick() != 2 || w.Time() != 1 {
+		t.Errorf("Tick() = %d, Time() = %g; want 2, 1", w.Tick(), w.Time())
+	}
+
+	wall := w.Add(Body{Pos: geometry.Pt(-5, -5), Size: geometry.Pt(1, 1)})
+	w.Run(1)
+	if !wall.Pos.Eq(geometry.Pt(-5, -5)) {
+		t.Errorf("质量为 0 的物体移动到了 %v", wall.Pos)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	w := NewWorld(Config{Dt: 0.01})
+	if n := w.Advance(25 * time.Millisecond); n != 2 {
+		t.Errorf("Advance(25ms) 执行了 %d 步; want 2", n)
+	}
+	if a := w.Alpha(); math.Abs(a-0.5) > 1e-9 {
+		t.Errorf("Alpha() = %g; want 0.5", a)
+	}
+	if n := w.Advance(5 * time.Millisecond); n != 1 {
+		t.Errorf("累积到 10ms 后执行了 %d 步; want 1", n)
+	}
+	if n := w.Advance(time.Second); n != maxStepsPerAdvance || w.Alpha() != 0 {
+		t.Errorf("落后 1s 时执行了 %d 步，Alpha() = %g; want %d, 0", n, w.Alpha(), maxStepsPerAdvance)
+	}
+}
+
+func TestCollisionResponse(t *testing.T) {
+	// 质量相等的两个球完全弹性正碰后交换速度
+	w := NewWorld(Config{Dt: 0.01, Restitution: 1})
+	a := w.Add(Body{Pos: geometry.Pt(0, 0), Vel: geometry.Pt(10, 0), Radius: 1, Mass: 1})
+	b := w.Add(Body{Pos: geometry.Pt(2.05, 0), Radius: 1, Mass: 1})
+	before := w.Energy()
+	w.Run(1)
+	if got := w.Collisions(); len(got) != 1 || got[0].A != a.ID || got[0].B != b.ID {
+		t.Fatalf("Collisions() = %v; want 一次 %d 与 %d 的碰撞", got, a.ID, b.ID)
+	}
+	if !a.Vel.Eq(geometry.Pt(0, 0)) || !b.Vel.Eq(geometry.Pt(10, 0)) {
+		t.Errorf("碰撞后速度 %v, %v; want (0, 0), (10, 0)", a.Vel, b.Vel)
+	}
+	if after := w.Energy(); math.Abs(after-before) > 1e-9 {
+		t.Errorf("完全弹性碰撞后动能 %g; want %g", after, before)
+	}
+	if d := b.Pos.Distance(a.Pos); d < 2-1e-9 {
+		t.Errorf("碰撞后圆心距离 %g，仍然重叠", d)
+	}
+
+	// 撞上固定的墙时只有运动的物体被推开并反弹
+	w = NewWorld(Config{Dt: 0.01, Restitution: 0.5})
+	wall := w.Add(Body{Pos: geometry.Pt(0, 0), Size: geometry.Pt(2, 10)})
+	ball := w.Add(Body{Pos: geometry.Pt(2.05, 0), Vel: geometry.Pt(-10, 0), Radius: 1, Mass: 1})
+	w.Run(1)
+	if !wall.Pos.Eq(geometry.Pt(0, 0)) {
+		t.Errorf("墙被推到了 %v", wall.Pos)
+	}
+	if !ball.Vel.Eq(geometry.Pt(5, 0)) || ball.Pos.X < 2-1e-9 {
+		t.Errorf("球撞墙后 pos = %v, vel = %v; want x >= 2, vel (5, 0)", ball.Pos, ball.Vel)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	w := newTestWorld(1)
+	w.Run(600)
+	walls := w.Config().Bounds
+	for _, b := range w.Bodies() {
+		box := b.Shape().Bounds()
+		if box.Min.X < walls.Min.X-1e-9 || box.Min.Y < walls.Min.Y-1e-9 || box.Max.X > walls.Max.X+1e-9 || box.Max.Y > walls.Max.Y+1e-9 {
+			t.Errorf("物体 %d 在 %v 离开了边界", b.ID, box)
+		}
+	}
+}
+
+func TestSettles(t *testing.T) {
+	// 在重力和非弹性碰撞的作用下，物体最终应该堆在一起而不是相互穿透
+	w := newTestWorld(2)
+	w.Run(1200)
+	for _, b := range w.Bodies() {
+		for _, o := range w.Bodies() {
+			if b.ID >= o.ID {
+				continue
+			}
+			if c, ok := collision.Collide(b.Shape(), o.Shape()); ok && c.Depth > 0.5 {
+				t.Errorf("物体 %d 和 %d 重叠了 %g", b.ID, o.ID, c.Depth)
+			}
+		}
+	}
+}
+
+func TestDeterminism(t *testing.T) {
+	a, b := newTestWorld(7), newTestWorld(7)
+	a.Run(300)
+	b.Run(300)
+	if encode(t, a.Snapshot()) != encode(t, b.Snapshot()) {
+		t.Error("相同种子的两次运行结果不同")
+	}
+	c := newTestWorld(8)
+	c.Run(300)
+	if encode(t, a.Snapshot()) == encode(t, c.Snapshot()) {
+		t.Error("不同种子的运行结果相同")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	w := newTestWorld(3)
+	w.Run(150)
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	w.Run(150)
+	w.Spawn(5, 1, 2, 10) // 随机数生成器的状态也要一致
+
+	s, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := Restore(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.Run(150)
+	replay.Spawn(5, 1, 2, 10)
+	if got, want := encode(t, replay.Snapshot()), encode(t, w.Snapshot()); got != want {
+		t.Errorf("从第 150 步的快照重放的结果与原来不同:\n%s\n%s", got, want)
+	}
+}
+
+func TestRestoreErrors(t *testing.T) {
+	w := newTestWorld(1)
+	s := w.Snapshot()
+	s.Bodies[1].ID = s.Bodies[0].ID
+	if _, err := Restore(s); err == nil {
+		t.Error("Restore 接受了重复的 ID")
+	}
+	if _, err := ReadSnapshot(strings.NewReader("{")); err == nil {
+		t.Error("ReadSnapshot 接受了不完整的 JSON")
+	}
+}
+
+func TestRunHeadless(t *testing.T) {
+	w := newTestWorld(4)
+	var buf bytes.Buffer
+	if err := RunHeadless(w, 250, 100, &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var ticks []uint64
+	for _, line := range lines {
+		s, err := ReadSnapshot(strings.NewReader(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ticks = append(ticks, s.Tick)
+	}
+	if len(ticks) != 3 || ticks[0] != 100 || ticks[1] != 200 || ticks[2] != 250 {
+		t.Errorf("快照的步数 = %v; want [100 200 250]", ticks)
+	}
+}
+
+func TestMoveWall(t *testing.T) {
+	// 在两步之间移动固定的墙，下一步的碰撞检测必须使用墙的新位置
+	w := NewWorld(Config{Dt: 0.01, Restitution: 1})
+	wall := w.Add(Body{Pos: geometry.Pt(50, 0), Size: geometry.Pt(2, 10)})
+	ball := w.Add(Body{Pos: geometry.Pt(0, 0), Radius: 1, Mass: 1})
+	w.Run(1)
+	if len(w.Collisions()) != 0 {
+		t.Fatalf("墙移动之前 Collisions() = %v", w.Collisions())
+	}
+	wall.Move(-48.5, 0)
+	w.Run(1)
+	if got := w.Collisions(); len(got) != 1 || got[0].A != wall.ID || got[0].B != ball.ID {
+		t.Fatalf("墙移动之后 Collisions() = %v; want 一次 %d 与 %d 的碰撞", got, wall.ID, ball.ID)
+	}
+	if ball.Pos.X > -0.5+1e-9 {
+		t.Errorf("球没有被推出墙外: pos = %v", ball.Pos)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	w := NewWorld(Config{})
+	a := w.Add(Body{Pos: geometry.Pt(0, 0), Radius: 1, Mass: 1})
+	b := w.Add(Body{Pos: geometry.Pt(1, 0), Radius: 1, Mass: 1})
+	w.Remove(a.ID)
+	w.Run(1)
+	if len(w.Collisions()) != 0 || len(w.Bodies()) != 1 || w.Bodies()[0] != b {
+		t.Errorf("删除后 Bodies() = %v, Collisions() = %v", w.Bodies(), w.Collisions())
+	}
+	if _, ok := w.Body(a.ID); ok {
+		t.Error("Body 返回了已删除的物体")
+	}
+}
+
+func BenchmarkStep(b *testing.B) {
+	w := NewWorld(Config{Bounds: geometry.R(0, 0, 1000, 1000), Restitution: 1})
+	w.Spawn(2000, 1, 3, 50)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Step()
+	}
+}
@@ -0,0 +1,319 @@
+// Package sim 是一个确定性的二维实体模拟：固定时间步长的循环、
+// 带速度和加速度的物体、与边界和彼此之间的弹性碰撞，以及带种子的随机数。
+//
+// 同样的初始状态总是产生同样的结果：每一步的时间步长固定，
+// 碰撞按物体 ID 排序后依次处理，随机数生成器的状态保存在快照中。
+// 因此可以把某一时刻的快照保存为 JSON，之后恢复并继续运行来重放，
+// 也可以在测试中不依赖任何图形界面地运行 N 步并检查结果。
+package sim
+
+import (
+	"sort"
+	"time"
+
+	"go-programming-language/chapter07/collision"
+	"go-programming-language/chapter07/geometry"
+)
+
+// Body 是模拟中的物体。Radius 大于 0 时是圆，否则是中心在 Pos、大小为 Size 的矩形。
+// 它实现了第 7 章 Movable 接口的 Move 方法。
+type Body struct {
+	ID     int            `json:"id"`
+	Name   string         `json:"name,omitempty"`
+	Pos    geometry.Point `json:"pos"`
+	Vel    geometry.Point `json:"vel"`
+	Acc    geometry.Point `json:"acc"` // 除重力以外的加速度
+	Radius float64        `json:"radius,omitempty"`
+	Size   geometry.Point `json:"size"`
+	Mass   float64        `json:"mass"` // 0 表示固定不动的物体（例如墙），碰撞时不会被推动
+}
+
+// Shape 返回物体当前占据的图形。
+func (b *Body) Shape() geometry.Shape {
+	if b.Radius > 0 {
+		return geometry.Circle{Center: b.Pos, Radius: b.Radius}
+	}
+	half := b.Size.Mul(0.5)
+	return geometry.Rect{Min: b.Pos.Sub(half), Max: b.Pos.Add(half)}
+}
+
+// Move 把物体平移 (dx, dy)。
+func (b *Body) Move(dx, dy float64) {
+	b.Pos = b.Pos.Add(geometry.Pt(dx, dy))
+}
+
+func (b *Body) invMass() float64 {
+	if b.Mass <= 0 {
+		return 0
+	}
+	return 1 / b.Mass
+}
+
+// Config 是世界的参数，创建后不再改变。
+type Config struct {
+	Dt          float64        `json:"dt"`          // 每一步的时长（秒），默认 1/60
+	Bounds      geometry.Rect  `json:"bounds"`      // 四周的墙，为空时没有墙
+	Gravity     geometry.Point `json:"gravity"`     // 所有可移动物体共有的加速度
+	Restitution float64        `json:"restitution"` // 恢复系数：1 为完全弹性碰撞，0 为完全非弹性碰撞
+	CellSize    float64        `json:"cellSize"`    // 碰撞检测网格的单元格边长，默认 8
+	Seed        int64          `json:"seed"`
+}
+
+// Collision 是某一步中发生的一次碰撞，A < B。
+type Collision struct {
+	A, B  int
+	Depth float64
+}
+
+// maxStepsPerAdvance 限制 Advance 一次最多执行的步数，
+// 避免机器太慢时为了追赶时间而越来越慢
+const maxStepsPerAdvance = 8
+
+// World 是一组物体和它们所在的世界。World 不是并发安全的。
+type World struct {
+	cfg        Config
+	tick       uint64
+	rng        *Rand
+	nextID     int
+	bodies     []*Body
+	byID       map[int]*Body
+	grid       *collision.Grid[int]
+	collisions []Collision
+	accum      time.Duration
+}
+
+// NewWorld 创建空的世界。
+func NewWorld(cfg Config) *World {
+	if cfg.Dt <= 0 {
+		cfg.Dt = 1.0 / 60
+	}
+	if cfg.CellSize <= 0 {
+		cfg.CellSize = 8
+	}
+	return &World{
+		cfg:    cfg,
+		rng:    NewRand(cfg.Seed),
+		nextID: 1,
+		byID:   make(map[int]*Body),
+		grid:   collision.NewGrid[int](cfg.CellSize),
+	}
+}
+
+// Config 返回世界的参数。
+func (w *World) Config() Config {
+	return w.cfg
+}
+
+// Tick 返回已经执行的步数。
+func (w *World) Tick() uint64 {
+	return w.tick
+}
+
+// Time 返回模拟经过的时间（秒）。
+func (w *World) Time() float64 {
+	return float64(w.tick) * w.cfg.Dt
+}
+
+// Rand 返回世界的随机数生成器。需要随机性的逻辑都应该使用它，以保证可以重放。
+func (w *World) Rand() *Rand {
+	return w.rng
+}
+
+// Add 把物体加入世界并分配 ID，返回加入后的物体。
+func (w *World) Add(b Body) *Body {
+	b.ID = w.nextID
+	w.nextID++
+	w.insert(&b)
+	return &b
+}
+
+func (w *World) insert(b *Body) {
+	w.bodies = append(w.bodies, b)
+	w.byID[b.ID] = b
+	w.grid.Set(b.ID, b.Shape().Bounds())
+}
+
+// Body 返回 ID 对应的物体。
+func (w *World) Body(id int) (*Body, bool) {
+	b, ok := w.byID[id]
+	return b, ok
+}
+
+// Bodies 按 ID 顺序返回所有物体。返回的是物体本身，修改会影响模拟。
+func (w *World) Bodies() []*Body {
+	return w.bodies
+}
+
+// Remove 从世界中删除物体。
+func (w *World) Remove(id int) {
+	if _, ok := w.byID[id]; !ok {
+		return
+	}
+	delete(w.byID, id)
+	w.grid.Remove(id)
+	for i, b := range w.bodies {
+		if b.ID == id {
+			w.bodies = append(w.bodies[:i], w.bodies[i+1:]...)
+			break
+		}
+	}
+}
+
+// Spawn 用世界的随机数生成器在边界内随机放置 n 个半径在 [minR, maxR) 之间、
+// 速度不超过 maxSpeed 的圆形物体，质量与面积成正比。边界为空时放在 100×100 的范围内。
+func (w *World) Spawn(n int, minR, maxR, maxSpeed float64) []*Body {
+	area := w.cfg.Bounds
+	if area.Empty() {
+		area = geometry.R(0, 0, 100, 100)
+	}
+	bodies := make([]*Body, n)
+	for i := range bodies {
+		r := w.rng.Range(minR, maxR)
+		bodies[i] = w.Add(Body{
+			Pos:    geometry.Pt(w.rng.Range(area.Min.X+r, area.Max.X-r), w.rng.Range(area.Min.Y+r, area.Max.Y-r)),
+			Vel:    geometry.Pt(w.rng.Range(-1, 1), w.rng.Range(-1, 1)).Mul(maxSpeed / 1.5),
+			Radius: r,
+			Mass:   r * r,
+		})
+	}
+	return bodies
+}
+
+// Collisions 返回最近一步中发生的碰撞，按 (A, B) 排序。
+func (w *World) Collisions() []Collision {
+	return w.collisions
+}
+
+// Advance 把实际经过的时间累加起来，按固定步长执行尽可能多的步，返回执行的步数。
+// 渲染循环每一帧调用一次，帧率变化不会影响模拟结果；
+// 一次最多执行 8 步，落后太多时丢弃剩余的时间。
+func (w *World) Advance(elapsed time.Duration) int {
+	dt := time.Duration(w.cfg.Dt * float64(time.Second))
+	w.accum += elapsed
+	steps := 0
+	for w.accum >= dt {
+		if steps == maxStepsPerAdvance {
+			w.accum = 0
+			break
+		}
+		w.Step()
+		w.accum -= dt
+		steps++
+	}
+	return steps
+}
+
+// Alpha 返回 Advance 累积的剩余时间占一步的比例，渲染时可以用它在上一步和下一步之间插值。
+func (w *World) Alpha() float64 {
+	return w.accum.Seconds() / w.cfg.Dt
+}
+
+// Run 执行 n 步。
+func (w *World) Run(n int) {
+	for i := 0; i < n; i++ {
+		w.Step()
+	}
+}
+
+// Step 执行一步：积分速度和位置（半隐式欧拉法），处理与墙的碰撞，再处理物体之间的碰撞。
+// 固定的物体不参与积分，但调用方可能在两步之间移动过它们（例如移动一堵墙），
+// 所以每一步都会刷新所有物体在网格中的包围盒。
+func (w *World) Step() {
+	dt := w.cfg.Dt
+	for _, b := range w.bodies {
+		if b.Mass > 0 {
+			b.Vel = b.Vel.Add(b.Acc.Add(w.cfg.Gravity).Mul(dt))
+			b.Pos = b.Pos.Add(b.Vel.Mul(dt))
+			w.bounce(b)
+		}
+		w.grid.Set(b.ID, b.Shape().Bounds())
+	}
+	w.resolve()
+	w.tick++
+}
+
+// bounce 把越过墙的物体推回边界内，并按恢复系数反弹
+func (w *World) bounce(b *Body) {
+	walls := w.cfg.Bounds
+	if walls.Empty() {
+		return
+	}
+	e := w.cfg.Restitution
+	box := b.Shape().Bounds()
+	if d := walls.Min.X - box.Min.X; d > 0 {
+		b.Pos.X += d
+		b.Vel.X = abs(b.Vel.X) * e
+	} else if d := box.Max.X - walls.Max.X; d > 0 {
+		b.Pos.X -= d
+		b.Vel.X = -abs(b.Vel.X) * e
+	}
+	if d := walls.Min.Y - box.Min.Y; d > 0 {
+		b.Pos.Y += d
+		b.Vel.Y = abs(b.Vel.Y) * e
+	} else if d := box.Max.Y - walls.Max.Y; d > 0 {
+		b.Pos.Y -= d
+		b.Vel.Y = -abs(b.Vel.Y) * e
+	}
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// resolve 找出所有相交的物体对并处理碰撞。
+// 网格按 map 的随机顺序报告相交对，排序后再处理，保证结果可以重现。
+func (w *World) resolve() {
+	var pairs [][2]int
+	w.grid.Pairs(func(a, b int) bool {
+		if a > b {
+			a, b = b, a
+		}
+		pairs = append(pairs, [2]int{a, b})
+		return true
+	})
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	w.collisions = w.collisions[:0]
+	for _, p := range pairs {
+		a, b := w.byID[p[0]], w.byID[p[1]]
+		ia, ib := a.invMass(), b.invMass()
+		if ia+ib == 0 {
+			continue // 两个固定物体
+		}
+		c, ok := collision.Collide(a.Shape(), b.Shape())
+		if !ok || c.Depth <= 0 {
+			continue
+		}
+		w.collisions = append(w.collisions, Collision{a.ID, b.ID, c.Depth})
+
+		// 按质量的倒数分配位置修正，让两个物体恰好分开
+		correction := c.Normal.Mul(c.Depth / (ia + ib))
+		a.Pos = a.Pos.Sub(correction.Mul(ia))
+		b.Pos = b.Pos.Add(correction.Mul(ib))
+
+		// 两者正在靠近时沿法线方向施加冲量
+		if vn := b.Vel.Sub(a.Vel).Dot(c.Normal); vn < 0 {
+			j := -(1 + w.cfg.Restitution) * vn / (ia + ib)
+			a.Vel = a.Vel.Sub(c.Normal.Mul(j * ia))
+			b.Vel = b.Vel.Add(c.Normal.Mul(j * ib))
+		}
+		w.grid.Set(a.ID, a.Shape().Bounds())
+		w.grid.Set(b.ID, b.Shape().Bounds())
+	}
+}
+
+// Energy 返回所有物体的动能之和，用于检查模拟是否合理。
+func (w *World) Energy() float64 {
+	e := 0.0
+	for _, b := range w.bodies {
+		e += 0.5 * b.Mass * b.Vel.Dot(b.Vel)
+	}
+	return e
+}
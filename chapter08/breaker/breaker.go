@@ -0,0 +1,191 @@
+// Package breaker 提供熔断器 CircuitBreaker，保护对外部服务的调用。
+//
+// 熔断器有三种状态：
+//
+//	Closed   正常放行，连续失败达到阈值后转为 Open
+//	Open     拒绝所有调用，冷却时间过后转为 HalfOpen
+//	HalfOpen 放行少量探测调用：探测全部成功后转为 Closed，任何一次失败重新转为 Open
+//
+// 调用方用 Allow 申请放行，调用结束后通过返回的 done 报告结果；也可以直接使用 Do。
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+// ErrOpen 表示熔断器处于打开状态（或半开状态下探测名额已满），调用被拒绝。
+var ErrOpen = errors.New("breaker: 熔断器已打开")
+
+// State 是熔断器的状态。
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Option 是 New 的可选配置。
+type Option func(*CircuitBreaker)
+
+// WithClock 设置熔断器使用的时钟，测试中使用虚拟时钟。
+func WithClock(c clock.Clock) Option {
+	return func(b *CircuitBreaker) {
+		b.clock = c
+	}
+}
+
+// WithProbes 设置半开状态下放行的探测调用数量，默认 1。
+// 这些探测全部成功后熔断器才关闭。
+func WithProbes(n int) Option {
+	return func(b *CircuitBreaker) {
+		if n > 0 {
+			b.probes = n
+		}
+	}
+}
+
+// WithStateChange 设置状态变化时的回调，回调在持有熔断器内部锁时调用，不能再调用熔断器的方法。
+func WithStateChange(fn func(from, to State)) Option {
+	return func(b *CircuitBreaker) {
+		b.onChange = fn
+	}
+}
+
+// CircuitBreaker 是并发安全的熔断器。
+type CircuitBreaker struct {
+	clock     clock.Clock
+	threshold int
+	cooldown  time.Duration
+	probes    int
+	onChange  func(from, to State)
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // 每次状态变化加 1，旧状态下开始的调用结果被忽略
+	failures   int    // Closed 状态下的连续失败次数
+	openedAt   time.Time
+	inFlight   int // HalfOpen 状态下已放行的探测数
+	succeeded  int // HalfOpen 状态下成功的探测数
+}
+
+// New 创建连续失败 threshold 次后打开、打开 cooldown 后进入半开状态的熔断器。
+func New(threshold int, cooldown time.Duration, opts ...Option) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	b := &CircuitBreaker{clock: clock.Real(), threshold: threshold, cooldown: cooldown, probes: 1}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// State 返回当前状态；冷却时间已过的 Open 状态返回 HalfOpen。
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.checkCooldown()
+	return b.state
+}
+
+// Allow 申请放行一次调用。被拒绝时返回 ErrOpen；
+// 放行时调用方必须在调用结束后调用一次 done，报告调用是否成功。
+func (b *CircuitBreaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.checkCooldown()
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.inFlight >= b.probes {
+			return nil, ErrOpen
+		}
+		b.inFlight++
+	}
+	gen := b.generation
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.report(gen, success) })
+	}, nil
+}
+
+// Do 在熔断器放行时调用 fn，fn 返回 nil 视为成功。被拒绝时返回 ErrOpen。
+// fn 发生 panic 时记为失败，panic 继续向上传播。
+func (b *CircuitBreaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	success := false
+	defer func() { done(success) }() // 否则 panic 时半开状态的探测名额永远不会释放
+	err = fn()
+	success = err == nil
+	return err
+}
+
+func (b *CircuitBreaker) report(gen uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.generation {
+		return
+	}
+	switch b.state {
+	case Closed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.setState(Open)
+		}
+	case HalfOpen:
+		if !success {
+			b.setState(Open)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.probes {
+			b.setState(Closed)
+		}
+	}
+}
+
+// checkCooldown 打开状态的冷却时间已过时转为半开，调用方必须持有 b.mu
+func (b *CircuitBreaker) checkCooldown() {
+	if b.state == Open && !b.clock.Now().Before(b.openedAt.Add(b.cooldown)) {
+		b.setState(HalfOpen)
+	}
+}
+
+// setState 切换状态并重置计数，调用方必须持有 b.mu
+func (b *CircuitBreaker) setState(s State) {
+	from := b.state
+	b.state = s
+	b.generation++
+	b.failures, b.inFlight, b.succeeded = 0, 0, 0
+	if s == Open {
+		b.openedAt = b.clock.Now()
+	}
+	if b.onChange != nil {
+		b.onChange(from, s)
+	}
+}
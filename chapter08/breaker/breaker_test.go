@@ -0,0 +1,128 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+var errBoom = errors.New("boom")
+
+func fail() error { return errBoom }
+func ok() error   { return nil }
+
+func TestTripAndRecover(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var changes []string
+	b := New(3, 10*time.Second, WithClock(clk), WithStateChange(func(from, to State) {
+		changes = append(changes, fmt.Sprintf("%s->%s", from, to))
+	}))
+
+	// 成功会清零连续失败计数
+	b.Do(fail)
+	b.Do(fail)
+	b.Do(ok)
+	b.Do(fail)
+	b.Do(fail)
+	if b.State() != Closed {
+		t.Fatalf("state = %s after non-consecutive failures; want closed", b.State())
+	}
+	b.Do(fail)
+	if b.State() != Open {
+		t.Fatalf("state = %s after 3 consecutive failures; want open", b.State())
+	}
+	if err := b.Do(ok); !errors.Is(err, ErrOpen) {
+		t.Errorf("Do while open = %v; want ErrOpen", err)
+	}
+
+	// 冷却后半开，探测失败重新打开并重新计时
+	clk.Advance(10 * time.Second)
+	if err := b.Do(fail); !errors.Is(err, errBoom) {
+		t.Errorf("probe = %v; want boom", err)
+	}
+	clk.Advance(9 * time.Second)
+	if b.State() != Open {
+		t.Errorf("state = %s 9s after failed probe; want open", b.State())
+	}
+	clk.Advance(time.Second)
+	if err := b.Do(ok); err != nil {
+		t.Errorf("probe = %v; want nil", err)
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("state changes = %v; want %v", changes, want)
+	}
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(1, time.Second, WithClock(clk), WithProbes(2))
+	b.Do(fail)
+	clk.Advance(time.Second)
+
+	done1, err1 := b.Allow()
+	done2, err2 := b.Allow()
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Allow in half-open = %v, %v; want 2 probes", err1, err2)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("third probe = %v; want ErrOpen", err)
+	}
+	done1(true)
+	if b.State() != HalfOpen {
+		t.Errorf("state = %s after 1 of 2 probes; want half-open", b.State())
+	}
+	done2(true)
+	done2(false) // 重复报告被忽略
+	if b.State() != Closed {
+		t.Errorf("state = %s after 2 successful probes; want closed", b.State())
+	}
+}
+
+// TestPanicReleasesProbe fn 发生 panic 时记为失败并释放半开状态的探测名额
+func TestPanicReleasesProbe(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(1, time.Second, WithClock(clk))
+	b.Do(fail)
+	clk.Advance(time.Second)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was not propagated")
+			}
+		}()
+		b.Do(func() error { panic("boom") })
+	}()
+	if b.State() != Open {
+		t.Errorf("state = %s after panicking probe; want open", b.State())
+	}
+	clk.Advance(time.Second)
+	if err := b.Do(ok); err != nil {
+		t.Errorf("probe after cooldown = %v; want nil", err)
+	}
+}
+
+// TestStaleResult 打开之前开始的慢调用，结果在打开之后才报告，不影响新状态
+func TestStaleResult(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(1, time.Second, WithClock(clk))
+	slow, _ := b.Allow()
+	b.Do(fail)
+	clk.Advance(time.Second)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow(false)
+	if b.State() != HalfOpen {
+		t.Errorf("stale failure changed state to %s", b.State())
+	}
+	probe(true)
+	if b.State() != Closed {
+		t.Errorf("state = %s; want closed", b.State())
+	}
+}
@@ -0,0 +1,45 @@
+// Package clock 把时间抽象成可以注入的 Clock 接口。
+//
+// 程序使用 Real()，测试使用 NewFake 创建的虚拟时钟：虚拟时间只在测试调用
+// Advance 时前进，到期的 After、Sleep 和 Ticker 按时间顺序触发，
+// 依赖超时和延迟的并发代码因此可以瞬间、确定地测试，不再需要真实的 time.Sleep。
+package clock
+
+import (
+	"time"
+)
+
+// Clock 提供当前时间和定时器。
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker 是 time.Ticker 的接口形式。
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real 返回使用系统时间的 Clock。
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time   { return t.t.C }
+func (t realTicker) Stop()                 { t.t.Stop() }
+func (t realTicker) Reset(d time.Duration) { t.t.Reset(d) }
@@ -0,0 +1,82 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeAfterOrder(t *testing.T) {
+	f := NewFake(start)
+	late := f.After(2 * time.Second)
+	early := f.After(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-early:
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+	f.Advance(5 * time.Second)
+	if got := <-early; !got.Equal(start.Add(time.Second)) {
+		t.Errorf("early fired at %v; want %v", got, start.Add(time.Second))
+	}
+	if got := <-late; !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("late fired at %v; want %v", got, start.Add(2*time.Second))
+	}
+	if got := f.Now(); !got.Equal(start.Add(5999 * time.Millisecond)) {
+		t.Errorf("Now() = %v", got)
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters() = %d; want 0", f.Waiters())
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan time.Time)
+	go func() {
+		f.Sleep(time.Minute)
+		done <- f.Now()
+	}()
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	if got := <-done; !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("woke at %v; want %v", got, start.Add(time.Minute))
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(start)
+	tk := f.NewTicker(time.Second)
+	f.Advance(time.Second)
+	if got := <-tk.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("tick at %v", got)
+	}
+	// 没有接收的触发被丢弃，通道里只保留一个
+	f.Advance(3 * time.Second)
+	if got := <-tk.C(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("tick at %v; want the first undelivered one", got)
+	}
+	select {
+	case got := <-tk.C():
+		t.Errorf("extra tick at %v", got)
+	default:
+	}
+
+	tk.Reset(10 * time.Second)
+	f.Advance(9 * time.Second)
+	select {
+	case <-tk.C():
+		t.Error("tick before reset period elapsed")
+	default:
+	}
+	tk.Stop()
+	f.Advance(time.Hour)
+	select {
+	case <-tk.C():
+		t.Error("tick after Stop")
+	default:
+	}
+}
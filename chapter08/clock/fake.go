@@ -0,0 +1,157 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake 是虚拟时钟，时间只在调用 Advance 时前进。可以被多个 goroutine 并发使用。
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // 等待者数量变化时广播，供 BlockUntil 使用
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter 是一个尚未到期的 After、Sleep 或 Ticker
+type waiter struct {
+	at     time.Time
+	ch     chan time.Time
+	period time.Duration // 大于 0 表示 Ticker
+}
+
+// NewFake 创建从 start 开始的虚拟时钟。
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now 返回虚拟的当前时间。
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After 返回的通道在虚拟时间前进 d 之后收到当时的时间。d <= 0 时立即收到。
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.add(&waiter{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Sleep 阻塞到虚拟时间前进 d。
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTicker 返回每隔 d 虚拟时间触发一次的 Ticker。
+// 与 time.Ticker 一样，接收方来不及接收时多余的触发被丢弃。
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: NewTicker 的间隔必须大于 0")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &waiter{at: f.now.Add(d), ch: make(chan time.Time, 1), period: d}
+	f.add(w)
+	return &fakeTicker{f: f, w: w}
+}
+
+// add 登记等待者，调用方必须持有 f.mu
+func (f *Fake) add(w *waiter) {
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+// remove 删除等待者，调用方必须持有 f.mu
+func (f *Fake) remove(w *waiter) bool {
+	for i, x := range f.waiters {
+		if x == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// Advance 把虚拟时间拨快 d，按到期时间的先后依次触发到期的等待者；
+// 触发每个等待者时 Now 返回它的到期时间，Ticker 在 d 内可能触发多次。
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool {
+			return f.waiters[i].at.Before(f.waiters[j].at)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].at.After(end) {
+			break
+		}
+		w := f.waiters[0]
+		f.now = w.at
+		select {
+		case w.ch <- f.now:
+		default: // 只有 Ticker 会走到这里：丢弃来不及接收的触发
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			f.remove(w)
+		}
+	}
+	f.now = end
+}
+
+// Waiters 返回尚未到期的 After、Sleep 和 Ticker 的数量。
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil 阻塞到至少有 n 个尚未到期的等待者。
+// 测试在 Advance 之前调用它，确认被测的 goroutine 都已经开始等待，避免竞争。
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+type fakeTicker struct {
+	f *Fake
+	w *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	t.f.remove(t.w)
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: Reset 的间隔必须大于 0")
+	}
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	t.f.remove(t.w)
+	t.w.period = d
+	t.w.at = t.f.now.Add(d)
+	t.f.add(t.w)
+}
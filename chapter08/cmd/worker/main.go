@@ -0,0 +1,149 @@
+// Worker 从 jobqueue 持久化队列中取任务并处理，进程随时退出都不会丢失任务。
+//
+// 用法：
+//
+//	go run ./chapter08/cmd/worker [-dir 目录] enqueue 内容...
+//	go run ./chapter08/cmd/worker [-dir 目录] run [-workers N] [-once]
+//	go run ./chapter08/cmd/worker [-dir 目录] stats
+//	go run ./chapter08/cmd/worker [-dir 目录] dead
+//	go run ./chapter08/cmd/worker [-dir 目录] retry ID...
+//
+// run 用 pool.Pool 并发处理任务，成功时 Ack，失败时 Nack（内容包含 "fail" 的任务总是失败），
+// 按 Ctrl-C 后不再租用新任务，等已经租用的任务处理完再退出；-once 在队列中暂时没有可用任务时退出。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-programming-language/chapter08/jobqueue"
+	"go-programming-language/chapter08/pool"
+)
+
+func main() {
+	dir := flag.String("dir", "jobs", "队列数据目录")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: worker [-dir 目录] enqueue|run|stats|dead|retry [参数]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	q, err := jobqueue.Open(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "worker: %v\n", err)
+		os.Exit(1)
+	}
+	defer q.Close()
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "enqueue":
+		for _, payload := range args {
+			id, err := q.Enqueue([]byte(payload))
+			if err != nil {
+				fatal(q, err)
+			}
+			fmt.Printf("入队 %d: %s\n", id, payload)
+		}
+	case "run":
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		workers := fs.Int("workers", 3, "并发处理的任务数")
+		once := fs.Bool("once", false, "没有可用任务时退出")
+		fs.Parse(args)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := run(ctx, q, *workers, *once); err != nil {
+			fatal(q, err)
+		}
+	case "stats":
+		s := q.Stats()
+		fmt.Printf("就绪 %d，租用中 %d，死信 %d\n", s.Ready, s.Leased, s.Dead)
+	case "dead":
+		for _, j := range q.DeadLetters() {
+			fmt.Printf("%d\t尝试 %d 次\t%s\t%s\n", j.ID, j.Attempts, j.Payload, j.LastError)
+		}
+	case "retry":
+		for _, arg := range args {
+			id, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				fatal(q, fmt.Errorf("无效的任务 ID %q", arg))
+			}
+			if err := q.Retry(id); err != nil {
+				fatal(q, err)
+			}
+			fmt.Printf("重新入队 %d\n", id)
+		}
+	default:
+		flag.Usage()
+		q.Close()
+		os.Exit(2)
+	}
+}
+
+// fatal 关闭队列后退出，os.Exit 不会执行 defer
+func fatal(q *jobqueue.Queue, err error) {
+	q.Close()
+	fmt.Fprintf(os.Stderr, "worker: %v\n", err)
+	os.Exit(1)
+}
+
+// run 租用任务交给工作池处理，直到 ctx 取消（或者 once 时队列暂时为空）。
+// 工作池的队列容量为 1，最多只比 worker 多租用一个任务，避免提前租用的任务在等待中超时。
+func run(ctx context.Context, q *jobqueue.Queue, workers int, once bool) error {
+	handle := func(_ context.Context, l jobqueue.Lease) (struct{}, error) {
+		if err := process(l.Payload); err != nil {
+			fmt.Printf("任务 %d 第 %d 次失败: %v\n", l.ID, l.Attempts, err)
+			return struct{}{}, q.Nack(l.ID, l.Token, err.Error())
+		}
+		fmt.Printf("任务 %d 完成: %s\n", l.ID, l.Payload)
+		return struct{}{}, q.Ack(l.ID, l.Token)
+	}
+	// 处理函数不使用 pool 的 ctx：收到中断信号后已经租用的任务仍然处理完并确认
+	p := pool.New(context.Background(), handle, pool.Options{Workers: workers, QueueSize: 1})
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	var err error
+	for {
+		var l jobqueue.Lease
+		if once {
+			l, err = q.TryLease()
+		} else {
+			l, err = q.Lease(ctx, time.Second)
+		}
+		if err != nil {
+			break
+		}
+		if err = p.Submit(ctx, l); err != nil {
+			break // 中断时这个任务没有确认，可见性超时后会重新投递
+		}
+	}
+	p.Close()
+	werr := p.Wait()
+	if errors.Is(err, jobqueue.ErrEmpty) || errors.Is(err, context.Canceled) {
+		err = nil
+	}
+	return errors.Join(err, werr)
+}
+
+// process 模拟处理一个任务
+func process(payload []byte) error {
+	time.Sleep(100 * time.Millisecond)
+	if strings.Contains(string(payload), "fail") {
+		return fmt.Errorf("无法处理 %q", payload)
+	}
+	return nil
+}
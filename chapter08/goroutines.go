@@ -2,27 +2,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sync"
 	"time"
+
+	"go-programming-language/chapter08/clock"
+	"go-programming-language/chapter08/pipeline"
+	"go-programming-language/chapter08/pool"
+	"go-programming-language/chapter08/pubsub"
 )
 
 func main() {
+	// 所有延迟和超时都通过 clk 获得，测试中换成虚拟时钟
+	clk := clock.Real()
+
 	// 1. 基本Goroutine
 	fmt.Printf("基本Goroutine:\n")
-	go sayHello("Goroutine")
-	sayHello("Main")
-	time.Sleep(time.Second) // 等待goroutine完成
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sayHello(clk, "Goroutine")
+	}()
+	sayHello(clk, "Main")
+	wg.Wait() // 等待goroutine完成
 
 	// 2. 多个Goroutines
 	fmt.Printf("\n多个Goroutines:\n")
 	for i := 0; i < 3; i++ {
+		wg.Add(1)
 		go func(id int) {
+			defer wg.Done()
 			fmt.Printf("Goroutine %d 正在运行\n", id)
 		}(i)
 	}
-	time.Sleep(time.Second)
+	wg.Wait()
 
 	// 3. 基本通道
 	fmt.Printf("\n基本通道:\n")
@@ -60,52 +76,23 @@ func main() {
 
 	// 6. select语句
 	fmt.Printf("\nselect语句:\n")
-	ch1 := make(chan string)
-	ch2 := make(chan string)
-	
-	go func() {
-		time.Sleep(time.Millisecond * 500)
-		ch1 <- "来自ch1"
-	}()
-	
-	go func() {
-		time.Sleep(time.Millisecond * 200)
-		ch2 <- "来自ch2"
-	}()
-	
-	select {
-	case msg1 := <-ch1:
-		fmt.Printf("收到: %s\n", msg1)
-	case msg2 := <-ch2:
-		fmt.Printf("收到: %s\n", msg2)
-	case <-time.After(time.Second):
+	if msg, ok := selectFirst(clk, time.Millisecond*500, time.Millisecond*200, time.Second); ok {
+		fmt.Printf("收到: %s\n", msg)
+	} else {
 		fmt.Printf("超时!\n")
 	}
 
 	// 7. Worker Pool模式
 	fmt.Printf("\nWorker Pool模式:\n")
-	jobs := make(chan int, 100)
-	results := make(chan int, 100)
-	
-	// 启动3个worker
-	for w := 1; w <= 3; w++ {
-		go worker(w, jobs, results)
-	}
-	
-	// 发送5个任务
-	for j := 1; j <= 5; j++ {
-		jobs <- j
-	}
-	close(jobs)
-	
-	// 收集结果
-	for a := 1; a <= 5; a++ {
-		<-results
-	}
+	workerPoolDemo(clk)
 
 	// 8. 生产者-消费者模式
 	fmt.Printf("\n生产者-消费者模式:\n")
-	producerConsumerDemo()
+	producerConsumerDemo(clk)
+
+	// 8.1 流水线
+	fmt.Printf("\n流水线:\n")
+	pipelineDemo()
 
 	// 9. 通道方向
 	fmt.Printf("\n通道方向:\n")
@@ -114,65 +101,166 @@ func main() {
 	// 10. 同步原语
 	fmt.Printf("\n同步原语:\n")
 	syncDemo()
+
+	// 11. 发布/订阅
+	fmt.Printf("\n发布/订阅:\n")
+	pubsubDemo()
 }
 
-func sayHello(from string) {
+func sayHello(clk clock.Clock, from string) {
 	for i := 0; i < 3; i++ {
 		fmt.Printf("Hello from %s\n", from)
-		time.Sleep(time.Millisecond * 100)
+		clk.Sleep(time.Millisecond * 100)
+	}
+}
+
+// selectFirst 两个 goroutine 分别在 delay1、delay2 之后发送消息，返回先到的一条；
+// timeout 之内都没有到达时返回 false
+func selectFirst(clk clock.Clock, delay1, delay2, timeout time.Duration) (string, bool) {
+	// 带缓冲的通道让落选的 goroutine 也能发送成功并退出
+	ch1 := make(chan string, 1)
+	ch2 := make(chan string, 1)
+	timer := clk.After(timeout)
+	
+	go func() {
+		clk.Sleep(delay1)
+		ch1 <- "来自ch1"
+	}()
+	
+	go func() {
+		clk.Sleep(delay2)
+		ch2 <- "来自ch2"
+	}()
+	
+	select {
+	case msg1 := <-ch1:
+		return msg1, true
+	case msg2 := <-ch2:
+		return msg2, true
+	case <-timer:
+		return "", false
+	}
+}
+
+// workerPoolDemo 用 pool.Pool 处理任务：3 个 worker、有界队列、按提交顺序输出结果，
+// 任务错误随结果返回，Wait 等待所有任务结束而不是用 Sleep 猜测
+func workerPoolDemo(clk clock.Clock) {
+	cost := func(int) time.Duration {
+		return time.Millisecond * time.Duration(rand.Intn(300))
+	}
+	results, err := runWorkerPool(clk, []int{1, 2, 3, 4, 5}, cost)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("结果 %d: 错误 %v\n", r.Input, r.Err)
+			continue
+		}
+		fmt.Printf("结果 %d: %d\n", r.Input, r.Value)
+	}
+	if err != nil {
+		fmt.Printf("失败的任务:\n%v\n", err)
 	}
 }
 
-func worker(id int, jobs <-chan int, results chan<- int) {
-	for j := range jobs {
-		fmt.Printf("Worker %d 开始任务 %d\n", id, j)
-		time.Sleep(time.Millisecond * time.Duration(rand.Intn(1000)))
-		fmt.Printf("Worker %d 完成任务 %d\n", id, j)
-		results <- j * 2
+// runWorkerPool 用 3 个 worker 处理 jobs，任务 j 耗时 cost(j)，结果为 j*2，任务 4 总是失败
+func runWorkerPool(clk clock.Clock, jobs []int, cost func(int) time.Duration) ([]pool.Result[int, int], error) {
+	double := func(ctx context.Context, j int) (int, error) {
+		fmt.Printf("开始任务 %d\n", j)
+		select {
+		case <-clk.After(cost(j)):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+		if j == 4 {
+			return 0, fmt.Errorf("任务 %d 失败", j)
+		}
+		fmt.Printf("完成任务 %d\n", j)
+		return j * 2, nil
+	}
+	p := pool.New(context.Background(), double, pool.Options{Workers: 3, QueueSize: 2, Ordered: true})
+	
+	// 发送任务，队列满时 Submit 阻塞
+	go func() {
+		defer p.Close()
+		for _, j := range jobs {
+			p.Submit(context.Background(), j)
+		}
+	}()
+	
+	// 收集结果
+	var results []pool.Result[int, int]
+	for r := range p.Results() {
+		results = append(results, r)
 	}
+	return results, p.Wait()
 }
 
-func producerConsumerDemo() {
+func producerConsumerDemo(clk clock.Clock) {
 	ch := make(chan int, 5)
+	done := make(chan struct{})
 	
 	// 生产者
 	go func() {
 		for i := 1; i <= 10; i++ {
 			fmt.Printf("生产: %d\n", i)
 			ch <- i
-			time.Sleep(time.Millisecond * 100)
+			clk.Sleep(time.Millisecond * 100)
 		}
 		close(ch)
 	}()
 	
-	// 消费者
+	// 消费者：通道关闭、全部消费完后通知 main
 	go func() {
+		defer close(done)
 		for item := range ch {
 			fmt.Printf("消费: %d\n", item)
-			time.Sleep(time.Millisecond * 200)
+			clk.Sleep(time.Millisecond * 200)
 		}
 	}()
 	
-	time.Sleep(time.Second * 3)
+	<-done
+}
+
+// pipelineDemo 用 pipeline 包组合出多个生产者和消费者：
+// 生成 -> 过滤 -> 扇出给 3 个 worker 平方 -> 扇入 -> 限速 -> 分批
+func pipelineDemo() {
+	ctx, g := pipeline.WithGroup(context.Background())
+	
+	nums := pipeline.Generate(ctx, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	even := pipeline.Filter(ctx, nums, func(n int) bool { return n%2 == 0 })
+	var squares []<-chan int
+	for _, in := range pipeline.FanOut(ctx, even, 3) {
+		squares = append(squares, pipeline.Map(ctx, in, func(ctx context.Context, n int) (int, error) {
+			return n * n, nil
+		}))
+	}
+	limited := pipeline.RateLimit(ctx, pipeline.FanIn(ctx, squares...), 50*time.Millisecond, 2)
+	for batch := range pipeline.Batch(ctx, limited, 2, 200*time.Millisecond) {
+		fmt.Printf("消费一批: %v\n", batch)
+	}
+	if err := g.Wait(); err != nil {
+		fmt.Printf("流水线出错: %v\n", err)
+	}
 }
 
 // 通道方向示例
 func channelDirectionDemo() {
 	ch := make(chan string)
+	done := make(chan struct{})
 	
 	go send(ch)
-	go receive(ch)
+	go receive(ch, done)
 	
-	time.Sleep(time.Second)
+	<-done
 }
 
 func send(ch chan<- string) { // 只能发送
 	ch <- "数据"
 }
 
-func receive(ch <-chan string) { // 只能接收
+func receive(ch <-chan string, done chan<- struct{}) { // 只能接收
 	msg := <-ch
 	fmt.Printf("接收到: %s\n", msg)
+	close(done)
 }
 
 // 同步原语示例
@@ -196,4 +284,37 @@ func syncDemo() {
 	
 	wg.Wait()
 	fmt.Printf("最终计数: %d\n", counter)
-} 
\ No newline at end of file
+}
+
+// pubsubDemo 用 pubsub.Bus 把一条消息广播给多个订阅者：
+// 订阅者可以使用通配符，缓冲区满时按各自的策略丢弃消息，Close 关闭所有订阅通道
+func pubsubDemo() {
+	bus := pubsub.New[string]()
+	
+	all, _ := bus.Subscribe("orders.#")
+	created, _ := bus.Subscribe("orders.created")
+	latest, _ := bus.Subscribe("orders.*", pubsub.WithBuffer(1), pubsub.WithPolicy(pubsub.DropOldest, 0))
+	
+	var wg sync.WaitGroup
+	for name, ch := range map[string]<-chan pubsub.Message[string]{"全部": all, "新建": created, "最新": latest} {
+		wg.Add(1)
+		go func(name string, ch <-chan pubsub.Message[string]) {
+			defer wg.Done()
+			var got []string
+			for m := range ch { // Close 后通道关闭，循环结束
+				got = append(got, m.Topic+"="+m.Payload)
+			}
+			fmt.Printf("订阅者[%s] 收到: %v\n", name, got)
+		}(name, ch)
+	}
+	
+	bus.Publish("orders.created", "A001")
+	bus.Publish("orders.paid", "A001")
+	bus.Publish("orders.created", "A002")
+	bus.Publish("users.created", "U001") // 没有订阅者
+	bus.Close()
+	wg.Wait()
+	
+	stats := bus.Stats()
+	fmt.Printf("发布 %d 条，投递 %d 次，丢弃 %d 次\n", stats.Published, stats.Delivered, stats.Dropped)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSelectFirst(t *testing.T) {
+	tests := []struct {
+		delay1, delay2 time.Duration
+		want           string
+		ok             bool
+	}{
+		{500 * time.Millisecond, 200 * time.Millisecond, "来自ch2", true},
+		{100 * time.Millisecond, 200 * time.Millisecond, "来自ch1", true},
+		{2 * time.Second, 3 * time.Second, "", false},
+	}
+	for _, test := range tests {
+		clk := clock.NewFake(epoch)
+		type result struct {
+			msg string
+			ok  bool
+		}
+		done := make(chan result)
+		go func() {
+			msg, ok := selectFirst(clk, test.delay1, test.delay2, time.Second)
+			done <- result{msg, ok}
+		}()
+		// 超时定时器和两个 Sleep 都登记后，只把时间拨到最早的到期时间，
+		// 一次拨过多个到期时间会让多个 case 同时就绪
+		clk.BlockUntil(3)
+		first := time.Second
+		for _, d := range []time.Duration{test.delay1, test.delay2} {
+			if d < first {
+				first = d
+			}
+		}
+		clk.Advance(first)
+		if got := <-done; got.msg != test.want || got.ok != test.ok {
+			t.Errorf("selectFirst(%v, %v) = %q, %t; want %q, %t",
+				test.delay1, test.delay2, got.msg, got.ok, test.want, test.ok)
+		}
+	}
+}
+
+// TestWorkerPoolSchedule 按 100ms 的步长推进虚拟时间，检查 3 个 worker 的调度：
+// 任务 j 耗时 j*100ms，任务 4 在 100ms 开始、500ms 结束，任务 5 在 200ms 开始、700ms 结束
+func TestWorkerPoolSchedule(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	cost := func(j int) time.Duration { return time.Duration(j) * 100 * time.Millisecond }
+	type result struct {
+		values []int
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		results, err := runWorkerPool(clk, []int{1, 2, 3, 4, 5}, cost)
+		var values []int
+		for _, r := range results {
+			values = append(values, r.Value)
+		}
+		done <- result{values, err}
+	}()
+
+	// 每一步之前正在执行的任务数
+	running := []int{3, 3, 3, 2, 2, 1, 1}
+	for step, n := range running {
+		clk.BlockUntil(n)
+		select {
+		case r := <-done:
+			t.Fatalf("pool finished after %d steps with %v", step, r.values)
+		default:
+		}
+		clk.Advance(100 * time.Millisecond)
+	}
+	r := <-done
+	if want := []int{2, 4, 6, 0, 10}; !reflect.DeepEqual(r.values, want) {
+		t.Errorf("values = %v; want %v", r.values, want)
+	}
+	if r.err == nil {
+		t.Error("err = nil; want job 4's error")
+	}
+	if got := clk.Now().Sub(epoch); got != 700*time.Millisecond {
+		t.Errorf("finished at %v; want 700ms", got)
+	}
+}
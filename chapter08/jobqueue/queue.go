@@ -0,0 +1,493 @@
+// Package jobqueue 实现一个持久化的任务队列。
+//
+// 每次状态变化都先写入 chapter09/wal 的预写日志并 fsync，然后才返回，
+// 因此进程在任何时刻退出，重新打开后都能恢复到最后一次成功操作之后的状态：
+// 已经入队的任务不会丢失，已经确认（Ack）的任务不会再次投递。
+//
+// 任务的生命周期：
+//
+//	Enqueue -> 就绪 -> Lease -> 租用中 -> Ack   -> 删除
+//	                              |-> Nack / 租约超时 -> 指数退避后重新就绪
+//	                              |-> 尝试次数用完     -> 死信队列
+//
+// 租用的任务在可见性超时之前对其他 worker 不可见；worker 崩溃、没有 Ack 时，
+// 超时后任务会被重新投递。投递语义是“至少一次”：处理完成但 Ack 之前崩溃的任务会被再次处理。
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+	"go-programming-language/chapter09/wal"
+)
+
+var (
+	// ErrEmpty 表示当前没有可以租用的任务。
+	ErrEmpty = errors.New("jobqueue: 没有可用的任务")
+	// ErrLeaseLost 表示租约已经失效：任务已被确认、已被重新租用或者不存在。
+	ErrLeaseLost = errors.New("jobqueue: 租约已失效")
+	// ErrNotFound 表示任务不存在。
+	ErrNotFound = errors.New("jobqueue: 任务不存在")
+	// ErrClosed 表示队列已经关闭。
+	ErrClosed = errors.New("jobqueue: 队列已关闭")
+	// ErrQueueFailed 表示日志写入失败，队列不再接受修改。
+	ErrQueueFailed = errors.New("jobqueue: 日志写入失败")
+)
+
+// State 是任务的状态。
+type State string
+
+const (
+	Ready  State = "ready"
+	Leased State = "leased"
+	Dead   State = "dead"
+)
+
+// Job 是队列中的一个任务。
+type Job struct {
+	ID         uint64    `json:"id"`
+	Payload    []byte    `json:"payload"`
+	State      State     `json:"state"`
+	Attempts   int       `json:"attempts"`   // 已经租用的次数
+	VisibleAt  time.Time `json:"visible_at"` // 就绪任务可以被租用的时间，或租用中任务的租约到期时间
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// Lease 是一次租用：Token 用来在 Ack 和 Nack 时证明租约仍然有效。
+type Lease struct {
+	Job
+	Token int // 等于租用时的 Attempts
+}
+
+// Option 是 Open 的可选配置。
+type Option func(*Queue)
+
+// WithClock 设置队列使用的时钟，测试中使用虚拟时钟控制超时和退避。
+func WithClock(c clock.Clock) Option {
+	return func(q *Queue) {
+		q.clock = c
+	}
+}
+
+// WithVisibilityTimeout 设置租约的可见性超时，默认 30 秒。
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(q *Queue) {
+		q.visibility = d
+	}
+}
+
+// WithMaxAttempts 设置任务最多被租用的次数，用完后进入死信队列，默认 5 次。
+func WithMaxAttempts(n int) Option {
+	return func(q *Queue) {
+		q.maxAttempts = n
+	}
+}
+
+// WithBackoff 设置 Nack 后重试的退避时间：第 n 次失败后等待 base*2^(n-1)，最多 max。
+// 默认 base 为 1 秒，max 为 1 分钟。
+func WithBackoff(base, max time.Duration) Option {
+	return func(q *Queue) {
+		q.backoffBase, q.backoffMax = base, max
+	}
+}
+
+// WithCompactEvery 设置每写入多少条日志后生成一次快照并清空日志，默认 1000，0 表示不自动生成。
+func WithCompactEvery(n int) Option {
+	return func(q *Queue) {
+		q.compactEvery = n
+	}
+}
+
+// Stats 是各状态的任务数量。
+type Stats struct {
+	Ready  int
+	Leased int
+	Dead   int
+}
+
+// Queue 是持久化的任务队列，可以被多个 goroutine 并发使用。
+type Queue struct {
+	dir          string
+	clock        clock.Clock
+	visibility   time.Duration
+	maxAttempts  int
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	compactEvery int
+
+	mu      sync.Mutex
+	log     *wal.Log
+	jobs    map[uint64]*Job
+	nextID  uint64
+	seq     uint64        // 最后一条日志记录的序号
+	pending int           // 上次快照之后写入的日志条数
+	err     error         // 第一次日志写入失败的原因
+	notify  chan struct{} // 有新任务入队时关闭并替换，唤醒等待的 Lease
+	closed  bool
+}
+
+const (
+	logFile      = "queue.wal"
+	snapshotFile = "snapshot.json"
+)
+
+// record 是一条日志记录：Job 非空表示写入任务的新状态，否则删除 ID 对应的任务。
+// Seq 单调递增，快照记录它包含的最后一个 Seq
+type record struct {
+	Seq uint64 `json:"seq"`
+	ID  uint64 `json:"id"`
+	Job *Job   `json:"job,omitempty"`
+}
+
+// snapshot 是快照文件的内容
+type snapshot struct {
+	Seq    uint64 `json:"seq"`
+	NextID uint64 `json:"next_id"`
+	Jobs   []*Job `json:"jobs"`
+}
+
+// Open 打开 dir 中的队列，目录不存在时会被创建。
+func Open(dir string, opts ...Option) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		dir:          dir,
+		clock:        clock.Real(),
+		visibility:   30 * time.Second,
+		maxAttempts:  5,
+		backoffBase:  time.Second,
+		backoffMax:   time.Minute,
+		compactEvery: 1000,
+		jobs:         make(map[uint64]*Job),
+		nextID:       1,
+		notify:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var snap snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, fmt.Errorf("jobqueue: 快照损坏: %w", err)
+		}
+		q.nextID, q.seq = snap.NextID, snap.Seq
+		for _, j := range snap.Jobs {
+			q.jobs[j.ID] = j
+		}
+	}
+
+	q.log, err = wal.Open(filepath.Join(dir, logFile))
+	if err != nil {
+		return nil, err
+	}
+	err = q.log.Replay(func(data []byte) error {
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		q.pending++
+		// 快照写入后、日志清空前崩溃时，日志中的旧记录已经包含在快照中，
+		// 再次应用会让已经确认的任务复活
+		if rec.Seq <= q.seq {
+			return nil
+		}
+		q.apply(rec)
+		return nil
+	})
+	if err != nil {
+		q.log.Close()
+		return nil, fmt.Errorf("jobqueue: 重放日志失败: %w", err)
+	}
+	return q, nil
+}
+
+// apply 把一条记录应用到内存状态，调用方必须持有 q.mu（或者在 Open 中独占 q）
+func (q *Queue) apply(rec record) {
+	q.seq = rec.Seq
+	if rec.Job == nil {
+		delete(q.jobs, rec.ID)
+		return
+	}
+	j := *rec.Job
+	q.jobs[j.ID] = &j
+	if j.ID >= q.nextID {
+		q.nextID = j.ID + 1
+	}
+}
+
+// commit 先把记录写入日志，成功后再应用到内存状态，调用方必须持有 q.mu
+func (q *Queue) commit(rec record) error {
+	if q.closed {
+		return ErrClosed
+	}
+	if q.err != nil {
+		return fmt.Errorf("%w: %v", ErrQueueFailed, q.err)
+	}
+	rec.Seq = q.seq + 1
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := q.log.Append(data); err != nil {
+		q.err = err
+		return fmt.Errorf("%w: %v", ErrQueueFailed, err)
+	}
+	q.apply(rec)
+	q.pending++
+	if q.compactEvery > 0 && q.pending >= q.compactEvery {
+		// 日志已经落盘，快照失败不影响本次操作的持久性
+		q.compact()
+	}
+	return nil
+}
+
+// put 提交任务的新状态
+func (q *Queue) put(j Job) error {
+	return q.commit(record{ID: j.ID, Job: &j})
+}
+
+// Enqueue 添加一个任务，返回任务 ID。返回 nil 时任务已经持久化。
+func (q *Queue) Enqueue(payload []byte) (uint64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.clock.Now()
+	j := Job{
+		ID:         q.nextID,
+		Payload:    append([]byte(nil), payload...),
+		State:      Ready,
+		VisibleAt:  now,
+		EnqueuedAt: now,
+	}
+	if err := q.put(j); err != nil {
+		return 0, err
+	}
+	close(q.notify)
+	q.notify = make(chan struct{})
+	return j.ID, nil
+}
+
+// TryLease 租用一个可用的任务，没有时返回 ErrEmpty，队列关闭后返回 ErrClosed。
+// 可用的任务包括到了可见时间的就绪任务和租约已经超时的任务，按可见时间、ID 的顺序选取。
+// 租约超时且尝试次数已经用完的任务被移入死信队列。
+func (q *Queue) TryLease() (Lease, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return Lease{}, ErrClosed
+	}
+	now := q.clock.Now()
+	for {
+		j := q.next(now)
+		if j == nil {
+			return Lease{}, ErrEmpty
+		}
+		next := *j
+		if next.State == Leased && next.Attempts >= q.maxAttempts {
+			next.State = Dead
+			next.LastError = "租约超时"
+			if err := q.put(next); err != nil {
+				return Lease{}, err
+			}
+			continue
+		}
+		next.State = Leased
+		next.Attempts++
+		next.VisibleAt = now.Add(q.visibility)
+		if err := q.put(next); err != nil {
+			return Lease{}, err
+		}
+		return Lease{Job: next, Token: next.Attempts}, nil
+	}
+}
+
+// next 返回最早可用的任务，调用方必须持有 q.mu
+func (q *Queue) next(now time.Time) *Job {
+	var best *Job
+	for _, j := range q.jobs {
+		if j.State == Dead || j.VisibleAt.After(now) {
+			continue
+		}
+		if best == nil || j.VisibleAt.Before(best.VisibleAt) ||
+			j.VisibleAt.Equal(best.VisibleAt) && j.ID < best.ID {
+			best = j
+		}
+	}
+	return best
+}
+
+// Lease 租用一个任务，没有可用任务时等待新任务入队或者 poll 时间后重试，
+// 直到 ctx 结束或者队列关闭（返回 ErrClosed）。
+func (q *Queue) Lease(ctx context.Context, poll time.Duration) (Lease, error) {
+	for {
+		q.mu.Lock()
+		notify := q.notify
+		q.mu.Unlock()
+		l, err := q.TryLease()
+		if !errors.Is(err, ErrEmpty) {
+			return l, err
+		}
+		select {
+		case <-notify:
+		case <-q.clock.After(poll):
+		case <-ctx.Done():
+			return Lease{}, ctx.Err()
+		}
+	}
+}
+
+// leased 返回租约对应的任务，租约失效时返回 ErrLeaseLost，调用方必须持有 q.mu
+func (q *Queue) leased(id uint64, token int) (*Job, error) {
+	j, ok := q.jobs[id]
+	if !ok || j.State != Leased || j.Attempts != token {
+		return nil, fmt.Errorf("%w: 任务 %d", ErrLeaseLost, id)
+	}
+	return j, nil
+}
+
+// Ack 确认任务已经完成并删除它。返回 nil 后任务不会再被投递。
+// 租约超时但还没有被其他 worker 重新租用时仍然可以确认。
+func (q *Queue) Ack(id uint64, token int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := q.leased(id, token); err != nil {
+		return err
+	}
+	return q.commit(record{ID: id})
+}
+
+// Nack 报告任务处理失败。尝试次数用完时任务进入死信队列，
+// 否则按指数退避推迟可见时间，之后重新投递。
+func (q *Queue) Nack(id uint64, token int, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, err := q.leased(id, token)
+	if err != nil {
+		return err
+	}
+	next := *j
+	next.LastError = reason
+	if next.Attempts >= q.maxAttempts {
+		next.State = Dead
+	} else {
+		next.State = Ready
+		next.VisibleAt = q.clock.Now().Add(q.backoff(next.Attempts))
+	}
+	return q.put(next)
+}
+
+// backoff 返回第 n 次失败后的退避时间
+func (q *Queue) backoff(n int) time.Duration {
+	d := q.backoffBase
+	for i := 1; i < n && d < q.backoffMax; i++ {
+		d *= 2
+	}
+	if d > q.backoffMax {
+		d = q.backoffMax
+	}
+	return d
+}
+
+// DeadLetters 返回死信队列中的任务，按 ID 排序。
+func (q *Queue) DeadLetters() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var dead []Job
+	for _, j := range q.jobs {
+		if j.State == Dead {
+			dead = append(dead, *j)
+		}
+	}
+	sort.Slice(dead, func(i, k int) bool { return dead[i].ID < dead[k].ID })
+	return dead
+}
+
+// Retry 把死信队列中的任务重新放回队列，尝试次数清零。
+func (q *Queue) Retry(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok || j.State != Dead {
+		return fmt.Errorf("%w: 死信队列中没有任务 %d", ErrNotFound, id)
+	}
+	next := *j
+	next.State = Ready
+	next.Attempts = 0
+	next.VisibleAt = q.clock.Now()
+	return q.put(next)
+}
+
+// Stats 返回各状态的任务数量。租约已经超时的任务仍然计为租用中。
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var s Stats
+	for _, j := range q.jobs {
+		switch j.State {
+		case Ready:
+			s.Ready++
+		case Leased:
+			s.Leased++
+		case Dead:
+			s.Dead++
+		}
+	}
+	return s
+}
+
+// Compact 立即把当前状态写入快照并清空日志。
+func (q *Queue) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	return q.compact()
+}
+
+func (q *Queue) compact() error {
+	snap := snapshot{Seq: q.seq, NextID: q.nextID, Jobs: make([]*Job, 0, len(q.jobs))}
+	for _, j := range q.jobs {
+		snap.Jobs = append(snap.Jobs, j)
+	}
+	sort.Slice(snap.Jobs, func(i, k int) bool { return snap.Jobs[i].ID < snap.Jobs[k].ID })
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := wal.WriteFileAtomic(filepath.Join(q.dir, snapshotFile), data); err != nil {
+		return err
+	}
+	// 快照已经持久化，清空日志失败时下次打开会按序号跳过快照已经包含的记录
+	if err := q.log.Reset(); err != nil {
+		return err
+	}
+	q.pending = 0
+	return nil
+}
+
+// Close 关闭队列。之后的修改操作和租用返回 ErrClosed，正在等待的 Lease 立即返回。
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.notify)
+	return q.log.Close()
+}
@@ -0,0 +1,296 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func openTestQueue(t *testing.T, dir string, clk clock.Clock, opts ...Option) *Queue {
+	t.Helper()
+	opts = append([]Option{WithClock(clk), WithVisibilityTimeout(time.Minute), WithMaxAttempts(3),
+		WithBackoff(time.Second, 10*time.Second)}, opts...)
+	q, err := Open(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func mustLease(t *testing.T, q *Queue) Lease {
+	t.Helper()
+	l, err := q.TryLease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestLifecycle(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	q := openTestQueue(t, t.TempDir(), clk)
+	defer q.Close()
+
+	a, _ := q.Enqueue([]byte("a"))
+	b, _ := q.Enqueue([]byte("b"))
+
+	la := mustLease(t, q)
+	lb := mustLease(t, q)
+	if la.ID != a || lb.ID != b || string(la.Payload) != "a" {
+		t.Fatalf("leased %d, %d; want %d, %d in order", la.ID, lb.ID, a, b)
+	}
+	if _, err := q.TryLease(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("TryLease with everything leased = %v; want ErrEmpty", err)
+	}
+	if err := q.Ack(la.ID, la.Token); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack(la.ID, la.Token); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("second Ack = %v; want ErrLeaseLost", err)
+	}
+
+	// Nack 后按 1s、2s 退避重试，第 3 次失败进入死信队列
+	for attempt, wait := range []time.Duration{time.Second, 2 * time.Second} {
+		if err := q.Nack(lb.ID, lb.Token, fmt.Sprint("失败 ", attempt+1)); err != nil {
+			t.Fatal(err)
+		}
+		clk.Advance(wait - time.Millisecond)
+		if _, err := q.TryLease(); !errors.Is(err, ErrEmpty) {
+			t.Fatalf("attempt %d: job visible before its backoff elapsed", attempt+1)
+		}
+		clk.Advance(time.Millisecond)
+		lb = mustLease(t, q)
+	}
+	if lb.Attempts != 3 {
+		t.Errorf("Attempts = %d; want 3", lb.Attempts)
+	}
+	q.Nack(lb.ID, lb.Token, "最后一次失败")
+	dead := q.DeadLetters()
+	if len(dead) != 1 || dead[0].ID != b || dead[0].LastError != "最后一次失败" {
+		t.Fatalf("DeadLetters() = %+v", dead)
+	}
+	if s := q.Stats(); s != (Stats{Dead: 1}) {
+		t.Errorf("Stats() = %+v; want 1 dead", s)
+	}
+
+	if err := q.Retry(b); err != nil {
+		t.Fatal(err)
+	}
+	if l := mustLease(t, q); l.ID != b || l.Attempts != 1 {
+		t.Errorf("after Retry leased job %d attempt %d; want %d attempt 1", l.ID, l.Attempts, b)
+	}
+}
+
+// TestVisibilityTimeout 没有确认的任务在超时后重新投递，旧租约失效；尝试次数用完后进入死信队列
+func TestVisibilityTimeout(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	q := openTestQueue(t, t.TempDir(), clk)
+	defer q.Close()
+	id, _ := q.Enqueue([]byte("x"))
+
+	first := mustLease(t, q)
+	clk.Advance(time.Minute)
+	second := mustLease(t, q)
+	if second.ID != id || second.Token == first.Token {
+		t.Fatalf("re-leased %+v after timeout", second)
+	}
+	if err := q.Ack(first.ID, first.Token); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Ack with expired lease = %v; want ErrLeaseLost", err)
+	}
+
+	clk.Advance(time.Minute)
+	mustLease(t, q)
+	clk.Advance(time.Minute)
+	if _, err := q.TryLease(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("TryLease after attempts exhausted = %v; want ErrEmpty", err)
+	}
+	if dead := q.DeadLetters(); len(dead) != 1 || dead[0].LastError != "租约超时" {
+		t.Errorf("DeadLetters() = %+v", dead)
+	}
+}
+
+func TestLeaseWaits(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), clock.Real())
+	defer q.Close()
+	got := make(chan Lease)
+	go func() {
+		l, err := q.Lease(context.Background(), time.Hour)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- l
+	}()
+	time.Sleep(10 * time.Millisecond)
+	id, _ := q.Enqueue([]byte("wake"))
+	if l := <-got; l.ID != id {
+		t.Errorf("Lease returned job %d; want %d", l.ID, id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Lease(ctx, time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lease on empty queue = %v; want DeadlineExceeded", err)
+	}
+}
+
+// TestLeaseClosed 关闭队列会唤醒等待中的 Lease，之后的租用返回 ErrClosed
+func TestLeaseClosed(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), clock.Real())
+	errc := make(chan error)
+	go func() {
+		_, err := q.Lease(context.Background(), time.Hour)
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Lease after Close = %v; want ErrClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Lease still blocked 2s after Close")
+	}
+	if _, err := q.TryLease(); !errors.Is(err, ErrClosed) {
+		t.Errorf("TryLease after Close = %v; want ErrClosed", err)
+	}
+}
+
+// summary 返回队列中每个任务的状态，用来比较崩溃前后
+func summary(q *Queue) map[uint64]string {
+	m := make(map[uint64]string)
+	for id, j := range q.jobs {
+		m[id] = fmt.Sprintf("%s/%d/%s", j.State, j.Attempts, j.Payload)
+	}
+	return m
+}
+
+// TestCrash 在日志的每一个字节处截断，模拟写到一半时崩溃：
+// 恢复后的状态必须恰好是截断点之前最后一条完整记录之后的状态
+func TestCrash(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(epoch)
+	q := openTestQueue(t, filepath.Join(dir, "full"), clk, WithCompactEvery(0))
+	var (
+		states = []map[uint64]string{{}}
+		ends   []int64
+	)
+	record := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, summary(q))
+		ends = append(ends, q.log.Size())
+	}
+	_, err := q.Enqueue([]byte("a"))
+	record(err)
+	_, err = q.Enqueue([]byte("b"))
+	record(err)
+	la, err := q.TryLease()
+	record(err)
+	record(q.Ack(la.ID, la.Token))
+	lb, err := q.TryLease()
+	record(err)
+	record(q.Nack(lb.ID, lb.Token, "boom"))
+	q.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "full", logFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cut := 0; cut <= len(data); cut++ {
+		torn := filepath.Join(dir, fmt.Sprintf("torn-%d", cut))
+		os.Mkdir(torn, 0o755)
+		if err := os.WriteFile(filepath.Join(torn, logFile), data[:cut], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		q := openTestQueue(t, torn, clk)
+		complete := 0
+		for complete < len(ends) && ends[complete] <= int64(cut) {
+			complete++
+		}
+		if got := summary(q); !reflect.DeepEqual(got, states[complete]) {
+			t.Fatalf("cut %d: jobs = %v; want %v", cut, got, states[complete])
+		}
+		q.Close()
+	}
+}
+
+// TestCompactCrash 快照写入后、日志清空前崩溃：重放旧日志不能让已经确认的任务复活
+func TestCompactCrash(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(epoch)
+	q := openTestQueue(t, dir, clk, WithCompactEvery(0))
+	id, _ := q.Enqueue([]byte("once"))
+	l := mustLease(t, q)
+	q.Ack(l.ID, l.Token)
+	oldLog, err := os.ReadFile(filepath.Join(dir, logFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	q.Enqueue([]byte("next"))
+	q.Close()
+
+	// 把清空前的日志放回去，模拟 Reset 没有完成
+	if err := os.WriteFile(filepath.Join(dir, logFile), oldLog, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q = openTestQueue(t, dir, clk)
+	defer q.Close()
+	if _, ok := q.jobs[id]; ok {
+		t.Errorf("acknowledged job %d came back after replay", id)
+	}
+	if id2, _ := q.Enqueue([]byte("after")); id2 <= id {
+		t.Errorf("new job got ID %d; want > %d", id2, id)
+	}
+}
+
+// TestRestart 重启后未确认的任务保留下来，已确认的任务不再出现
+func TestRestart(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(epoch)
+	q := openTestQueue(t, dir, clk, WithCompactEvery(3))
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		id, _ := q.Enqueue([]byte(fmt.Sprint(i)))
+		ids = append(ids, id)
+	}
+	for i := 0; i < 2; i++ {
+		l := mustLease(t, q)
+		q.Ack(l.ID, l.Token)
+	}
+	leased := mustLease(t, q) // 租用后“崩溃”，没有确认
+	q.Close()
+
+	q = openTestQueue(t, dir, clk)
+	defer q.Close()
+	if s := q.Stats(); s != (Stats{Ready: 2, Leased: 1}) {
+		t.Errorf("Stats() after restart = %+v; want 2 ready, 1 leased", s)
+	}
+	clk.Advance(time.Minute)
+	var got []uint64
+	for {
+		l, err := q.TryLease()
+		if errors.Is(err, ErrEmpty) {
+			break
+		}
+		got = append(got, l.ID)
+		q.Ack(l.ID, l.Token)
+	}
+	if want := []uint64{ids[3], ids[4], leased.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("leased after restart %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,347 @@
+// Package pipeline 提供可以组合的流水线阶段：Generate、Map、Filter、Batch、
+// FanOut、FanIn、Tee 和 RateLimit。
+//
+// 每个阶段接收一个 context.Context 和只读的输入通道，返回只读的输出通道，
+// 在自己的 goroutine 中运行，输入耗尽或 ctx 取消时关闭输出通道并退出。
+// 用 WithGroup 创建的 ctx 把各个阶段组织在一起：任何阶段第一次出错时取消 ctx，
+// 其余阶段随之退出，Group.Wait 等待所有阶段结束并返回第一个错误。
+//
+// 下游不再接收时必须取消 ctx，否则上游阶段会阻塞在发送上，造成 goroutine 泄漏。
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Group 跟踪流水线中所有阶段的 goroutine，并记录第一个错误。
+type Group struct {
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+type groupKey struct{}
+
+// WithGroup 返回派生的 ctx 和 Group。用这个 ctx 创建的阶段都由 Group 跟踪。
+func WithGroup(parent context.Context) (context.Context, *Group) {
+	ctx, cancel := context.WithCancelCause(parent)
+	g := &Group{cancel: cancel}
+	return context.WithValue(ctx, groupKey{}, g), g
+}
+
+// Go 在新的 goroutine 中运行 fn，fn 返回错误时取消整条流水线。
+// 自定义的阶段可以用它加入 Group。
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel(err)
+			})
+		}
+	}()
+}
+
+// Wait 等待所有阶段结束，返回第一个错误。返回前取消 ctx。
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
+
+// start 运行一个阶段：ctx 带有 Group 时由 Group 跟踪，否则错误只会让该阶段提前结束
+func start(ctx context.Context, fn func() error) {
+	if g, ok := ctx.Value(groupKey{}).(*Group); ok {
+		g.Go(fn)
+		return
+	}
+	go fn()
+}
+
+// send 把 v 发送到 out，ctx 先被取消时返回 false
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Generate 依次发送 values。
+func Generate[T any](ctx context.Context, values ...T) <-chan T {
+	return GenerateFunc(ctx, func(yield func(T) bool) error {
+		for _, v := range values {
+			if !yield(v) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// GenerateFunc 用 gen 产生数据：gen 调用 yield 发送一个值，yield 返回 false 时 gen 应该停止。
+// gen 返回的错误会传播给 Group。
+func GenerateFunc[T any](ctx context.Context, gen func(yield func(T) bool) error) <-chan T {
+	out := make(chan T)
+	start(ctx, func() error {
+		defer close(out)
+		return gen(func(v T) bool {
+			return send(ctx, out, v)
+		})
+	})
+	return out
+}
+
+// Map 对每个输入调用 fn，发送其结果。fn 出错时该阶段停止并把错误传播给 Group。
+func Map[In, Out any](ctx context.Context, in <-chan In, fn func(context.Context, In) (Out, error)) <-chan Out {
+	out := make(chan Out)
+	start(ctx, func() error {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			r, err := fn(ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(ctx, out, r) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Filter 只发送 keep 返回 true 的输入。
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	start(ctx, func() error {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok || keep(v) && !send(ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Batch 把输入按 size 个一组打包发送。maxWait 大于 0 时，
+// 一组中的第一个元素等待超过 maxWait 后即使不满也会发送；输入结束时发送最后不满的一组。
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size <= 0 {
+		panic("pipeline: Batch 的 size 必须大于 0")
+	}
+	out := make(chan []T)
+	start(ctx, func() error {
+		defer close(out)
+		var batch []T
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, timeout = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			b := batch
+			batch = nil
+			return send(ctx, out, b)
+		}
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return nil
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					timeout = timer.C
+				}
+				if len(batch) == size && !flush() {
+					return nil
+				}
+			case <-timeout:
+				timer, timeout = nil, nil
+				if !flush() {
+					return nil
+				}
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// FanOut 启动 n 个 goroutine 竞争读取 in，每个输入只出现在其中一个输出通道上，
+// 通常每个输出通道接一个下游 worker。
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]<-chan T, n)
+	for i := range outs {
+		out := make(chan T)
+		outs[i] = out
+		start(ctx, func() error {
+			defer close(out)
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	return outs
+}
+
+// FanIn 把多个输入合并到一个输出通道，所有输入都关闭后关闭输出。不保证顺序。
+func FanIn[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		in := in
+		wg.Add(1)
+		start(ctx, func() error {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	start(ctx, func() error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Merge 是 FanIn 的别名。
+func Merge[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	return FanIn(ctx, ins...)
+}
+
+// Tee 把每个输入复制到 n 个输出通道。所有输出都收到一个值之后才读取下一个输入，
+// 因此最慢的下游决定整体速度。
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	chans := make([]chan T, n)
+	outs := make([]<-chan T, n)
+	for i := range chans {
+		chans[i] = make(chan T)
+		outs[i] = chans[i]
+	}
+	start(ctx, func() error {
+		defer func() {
+			for _, ch := range chans {
+				close(ch)
+			}
+		}()
+		// 输出通道的数量在运行时才知道，用 reflect.Select 同时等待它们；
+		// 最后一个 case 是 ctx.Done()
+		cases := make([]reflect.SelectCase, n+1)
+		cases[n] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			for i, ch := range chans {
+				cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch), Send: reflect.ValueOf(&v).Elem()}
+			}
+			for left := n; left > 0; left-- {
+				chosen, _, _ := reflect.Select(cases)
+				if chosen == n {
+					return nil
+				}
+				// 已经发送过的输出换成 nil 通道，不会再被选中
+				cases[chosen].Chan = reflect.Value{}
+			}
+		}
+	})
+	return outs
+}
+
+// RateLimit 限制输出速率：平均每 every 发送一个值，最多允许连续突发 burst 个。
+func RateLimit[T any](ctx context.Context, in <-chan T, every time.Duration, burst int) <-chan T {
+	if burst < 1 {
+		burst = 1
+	}
+	out := make(chan T)
+	start(ctx, func() error {
+		defer close(out)
+		tokens := float64(burst)
+		last := time.Now()
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			now := time.Now()
+			tokens += float64(now.Sub(last)) / float64(every)
+			if tokens > float64(burst) {
+				tokens = float64(burst)
+			}
+			last = now
+			if tokens < 1 {
+				wait := time.Duration((1 - tokens) * float64(every))
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return nil
+				}
+				tokens = 1
+				last = time.Now()
+			}
+			tokens--
+			if !send(ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Collect 接收 in 中的所有值，直到 in 关闭或 ctx 取消。
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	var all []T
+	for {
+		v, ok := recv(ctx, in)
+		if !ok {
+			return all
+		}
+		all = append(all, v)
+	}
+}
+
+// recv 从 in 接收一个值，in 关闭或 ctx 取消时返回 false
+func recv[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
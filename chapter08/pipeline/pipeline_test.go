@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// checkLeaks 在测试结束时确认 goroutine 数量回到测试开始前的水平
+func checkLeaks(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				buf := make([]byte, 1<<16)
+				t.Errorf("goroutine 泄漏: 开始 %d 个，结束 %d 个\n%s",
+					before, runtime.NumGoroutine(), buf[:runtime.Stack(buf, true)])
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+func TestStages(t *testing.T) {
+	checkLeaks(t)
+	ctx, g := WithGroup(context.Background())
+	nums := Generate(ctx, 1, 2, 3, 4, 5, 6, 7)
+	odd := Filter(ctx, nums, func(n int) bool { return n%2 == 1 })
+	strs := Map(ctx, odd, func(_ context.Context, n int) (string, error) {
+		return strconv.Itoa(n * n), nil
+	})
+	got := Collect(ctx, Batch(ctx, strs, 3, 0))
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1", "9", "25"}, {"49"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestFanOutFanIn(t *testing.T) {
+	checkLeaks(t)
+	ctx, g := WithGroup(context.Background())
+	var ins []int
+	for i := 0; i < 100; i++ {
+		ins = append(ins, i)
+	}
+	workers := FanOut(ctx, Generate(ctx, ins...), 4)
+	var doubled []<-chan int
+	for _, w := range workers {
+		doubled = append(doubled, Map(ctx, w, func(_ context.Context, n int) (int, error) {
+			return 2 * n, nil
+		}))
+	}
+	got := Collect(ctx, Merge(ctx, doubled...))
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(got)
+	for i, v := range got {
+		if v != 2*i {
+			t.Fatalf("got[%d] = %d; want %d", i, v, 2*i)
+		}
+	}
+	if len(got) != 100 {
+		t.Errorf("got %d values; want 100", len(got))
+	}
+}
+
+func TestTee(t *testing.T) {
+	checkLeaks(t)
+	ctx, g := WithGroup(context.Background())
+	outs := Tee(ctx, Generate(ctx, "a", "b", "c"), 3)
+	results := make([][]string, len(outs))
+	done := make(chan int)
+	for i, out := range outs {
+		go func(i int, out <-chan string) {
+			results[i] = Collect(ctx, out)
+			done <- i
+		}(i, out)
+	}
+	for range outs {
+		<-done
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if !reflect.DeepEqual(r, []string{"a", "b", "c"}) {
+			t.Errorf("output %d = %q; want [a b c]", i, r)
+		}
+	}
+}
+
+// TestFirstError 一个阶段出错后，其余阶段（包括无限的生成器）全部退出
+func TestFirstError(t *testing.T) {
+	checkLeaks(t)
+	ctx, g := WithGroup(context.Background())
+	boom := errors.New("boom")
+	naturals := GenerateFunc(ctx, func(yield func(int) bool) error {
+		for i := 0; yield(i); i++ {
+		}
+		return nil
+	})
+	outs := Tee(ctx, naturals, 2)
+	failing := Map(ctx, outs[0], func(_ context.Context, n int) (int, error) {
+		if n == 10 {
+			return 0, boom
+		}
+		return n, nil
+	})
+	slow := RateLimit(ctx, outs[1], time.Millisecond, 1)
+	merged := FanIn(ctx, failing, slow)
+	Collect(ctx, merged)
+	if err := g.Wait(); !errors.Is(err, boom) {
+		t.Errorf("Wait = %v; want boom", err)
+	}
+}
+
+// TestCancel 下游提前放弃时取消 ctx，所有阶段退出
+func TestCancel(t *testing.T) {
+	checkLeaks(t)
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, g := WithGroup(parent)
+	naturals := GenerateFunc(ctx, func(yield func(int) bool) error {
+		for i := 0; yield(i); i++ {
+		}
+		return nil
+	})
+	batches := Batch(ctx, naturals, 10, time.Millisecond)
+	<-batches
+	cancel()
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait = %v; want nil", err)
+	}
+}
+
+func TestBatchTimeout(t *testing.T) {
+	checkLeaks(t)
+	ctx, g := WithGroup(context.Background())
+	in := make(chan int)
+	batches := Batch(ctx, in, 10, 10*time.Millisecond)
+	in <- 1
+	in <- 2
+	if b := <-batches; !reflect.DeepEqual(b, []int{1, 2}) {
+		t.Errorf("batch = %v; want [1 2]", b)
+	}
+	close(in)
+	if b, ok := <-batches; ok {
+		t.Errorf("got extra batch %v", b)
+	}
+	g.Wait()
+}
+
+func TestRateLimit(t *testing.T) {
+	checkLeaks(t)
+	ctx, g := WithGroup(context.Background())
+	start := time.Now()
+	got := Collect(ctx, RateLimit(ctx, Generate(ctx, 1, 2, 3, 4, 5), 20*time.Millisecond, 2))
+	elapsed := time.Since(start)
+	g.Wait()
+	if len(got) != 5 {
+		t.Fatalf("got %v; want 5 values", got)
+	}
+	// 前 2 个是突发，其余 3 个各需要等待约 20ms
+	if elapsed < 50*time.Millisecond {
+		t.Errorf("5 values took %v; want at least 50ms", elapsed)
+	}
+}
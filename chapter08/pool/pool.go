@@ -0,0 +1,313 @@
+// Package pool 提供泛型的工作池 Pool[In, Out]。
+//
+// 固定数量的 worker 从有界队列中取任务执行，队列满时 Submit 阻塞，形成背压。
+// 每个任务的结果（包括错误）从 Results 通道送出，可以选择按提交顺序输出。
+// Close 停止接收新任务并等待队列中的任务执行完（排空），
+// Abort 或取消创建时的 ctx 则丢弃尚未开始的任务（中止）。
+// Wait 等待所有 worker 退出并返回汇总的任务错误，不需要用 time.Sleep 猜测何时完成。
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrClosed 表示工作池已经关闭，不再接收任务。
+	ErrClosed = errors.New("pool: 工作池已关闭")
+	// ErrQueueFull 表示 TrySubmit 时队列已满。
+	ErrQueueFull = errors.New("pool: 队列已满")
+	// ErrAborted 是 Abort 中止工作池时的原因。
+	ErrAborted = errors.New("pool: 工作池已中止")
+	// ErrPanic 表示任务函数发生了 panic。
+	ErrPanic = errors.New("pool: 任务 panic")
+)
+
+// Func 是处理单个任务的函数。ctx 在工作池中止时被取消。
+type Func[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Options 是工作池的配置。
+type Options struct {
+	Workers   int  // worker 数量，默认 1
+	QueueSize int  // 队列容量，默认等于 Workers
+	Ordered   bool // 为 true 时按提交顺序输出结果
+}
+
+// Result 是一个任务的执行结果。
+type Result[In, Out any] struct {
+	Index int // 任务的提交序号，从 0 开始
+	Input In
+	Value Out
+	Err   error
+}
+
+// JobError 记录失败任务的序号和错误。
+type JobError struct {
+	Index int
+	Err   error
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("pool: 任务 %d: %v", e.Index, e.Err)
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+type job[In any] struct {
+	index int
+	input In
+}
+
+// Pool 是固定 worker 数量、有界队列的工作池。
+type Pool[In, Out any] struct {
+	fn      Func[In, Out]
+	ordered bool
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+
+	jobs    chan job[In]
+	out     chan Result[In, Out] // worker -> collector
+	results chan Result[In, Out] // collector -> 调用方
+	done    chan struct{}
+
+	submitMu sync.Mutex // 串行化提交，保证序号连续
+	closed   bool
+	next     int
+
+	mu      sync.Mutex // 保护 errs 和 dropped
+	errs    []error
+	dropped int
+}
+
+// New 创建并启动工作池。取消 ctx 等同于调用 Abort。
+func New[In, Out any](ctx context.Context, fn Func[In, Out], opts Options) *Pool[In, Out] {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = opts.Workers
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	p := &Pool[In, Out]{
+		fn:      fn,
+		ordered: opts.Ordered,
+		ctx:     ctx,
+		cancel:  cancel,
+		jobs:    make(chan job[In], opts.QueueSize),
+		out:     make(chan Result[In, Out]),
+		results: make(chan Result[In, Out]),
+		done:    make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.out)
+	}()
+	go p.collect()
+	// 父 ctx 被取消时像 Abort 一样关闭队列，否则 worker 一直等待新任务，Wait 无法返回
+	go func() {
+		select {
+		case <-p.ctx.Done():
+			p.Close()
+		case <-p.done:
+		}
+	}()
+	return p
+}
+
+// Submit 提交一个任务，队列满时阻塞直到有空位、ctx 被取消或工作池中止。
+func (p *Pool[In, Out]) Submit(ctx context.Context, in In) error {
+	p.submitMu.Lock()
+	defer p.submitMu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.jobs <- job[In]{index: p.next, input: in}:
+		p.next++
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return fmt.Errorf("%w: %w", ErrClosed, context.Cause(p.ctx))
+	}
+}
+
+// TrySubmit 提交一个任务，队列满时立即返回 ErrQueueFull。
+func (p *Pool[In, Out]) TrySubmit(in In) error {
+	p.submitMu.Lock()
+	defer p.submitMu.Unlock()
+	if p.closed || p.ctx.Err() != nil {
+		return ErrClosed
+	}
+	select {
+	case p.jobs <- job[In]{index: p.next, input: in}:
+		p.next++
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Results 返回结果通道，所有任务结束后关闭。
+// 调用方必须持续接收结果，否则 worker 会阻塞（这也是一种背压）。
+func (p *Pool[In, Out]) Results() <-chan Result[In, Out] {
+	return p.results
+}
+
+// Close 停止接收新任务，已经提交的任务会继续执行完。可以多次调用。
+func (p *Pool[In, Out]) Close() {
+	p.submitMu.Lock()
+	defer p.submitMu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+}
+
+// Abort 中止工作池：正在执行的任务的 ctx 被取消，尚未开始的任务被丢弃，
+// 还没有被接收的结果也会被丢弃。
+func (p *Pool[In, Out]) Abort() {
+	p.cancel(ErrAborted)
+	p.Close()
+}
+
+// Wait 等待所有 worker 退出、结果通道关闭，返回所有任务错误的汇总（*JobError）。
+// 工作池被中止且有任务被丢弃时，错误中还包含中止的原因。
+func (p *Pool[In, Out]) Wait() error {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	errs := p.errs
+	if p.dropped > 0 {
+		errs = append(errs, fmt.Errorf("pool: 丢弃了 %d 个任务: %w", p.dropped, context.Cause(p.ctx)))
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown 关闭工作池并等待任务排空；ctx 先结束时中止剩下的任务。
+// 调用方仍需接收 Results，或者在另一个 goroutine 中接收。
+func (p *Pool[In, Out]) Shutdown(ctx context.Context) error {
+	p.Close()
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.cancel(ctx.Err())
+	}
+	return p.Wait()
+}
+
+func (p *Pool[In, Out]) work() {
+	for j := range p.jobs {
+		if p.ctx.Err() != nil {
+			p.drop(1)
+			continue
+		}
+		v, err := p.call(j.input)
+		r := Result[In, Out]{Index: j.index, Input: j.input, Value: v, Err: err}
+		if err != nil {
+			r.Err = &JobError{Index: j.index, Err: err}
+			p.mu.Lock()
+			p.errs = append(p.errs, r.Err)
+			p.mu.Unlock()
+		}
+		select {
+		case p.out <- r:
+		case <-p.ctx.Done():
+			p.drop(1)
+		}
+	}
+}
+
+// call 执行任务函数，把 panic 转换为错误，避免一个任务拖垮整个工作池
+func (p *Pool[In, Out]) call(in In) (v Out, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrPanic, r)
+		}
+	}()
+	return p.fn(p.ctx, in)
+}
+
+func (p *Pool[In, Out]) drop(n int) {
+	p.mu.Lock()
+	p.dropped += n
+	p.mu.Unlock()
+}
+
+// collect 把 worker 的结果转发给调用方，有序模式下先缓存乱序到达的结果
+func (p *Pool[In, Out]) collect() {
+	defer close(p.done)
+	defer close(p.results)
+
+	pending := make(map[int]Result[In, Out])
+	next := 0
+	for r := range p.out {
+		if !p.ordered {
+			p.emit(r)
+			continue
+		}
+		pending[r.Index] = r
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			p.emit(r)
+		}
+	}
+	// 中止时丢弃的任务会留下空缺，剩下的结果按序号输出
+	rest := make([]int, 0, len(pending))
+	for i := range pending {
+		rest = append(rest, i)
+	}
+	sort.Ints(rest)
+	for _, i := range rest {
+		p.emit(pending[i])
+	}
+}
+
+func (p *Pool[In, Out]) emit(r Result[In, Out]) {
+	select {
+	case p.results <- r:
+	case <-p.ctx.Done():
+		p.drop(1)
+	}
+}
+
+// Map 用工作池并发处理 inputs，按输入顺序返回结果。
+// 任何任务失败时仍然处理其余任务，返回的错误汇总了所有失败的任务。
+func Map[In, Out any](ctx context.Context, inputs []In, fn Func[In, Out], opts Options) ([]Out, error) {
+	p := New(ctx, fn, opts)
+	go func() {
+		defer p.Close()
+		for _, in := range inputs {
+			if p.Submit(ctx, in) != nil {
+				return
+			}
+		}
+	}()
+	out := make([]Out, len(inputs))
+	for r := range p.Results() {
+		out[r.Index] = r.Value
+	}
+	if err := p.Wait(); err != nil {
+		return out, err
+	}
+	return out, ctx.Err()
+}
@@ -0,0 +1,177 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func square(_ context.Context, n int) (int, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("负数 %d", n)
+	}
+	return n * n, nil
+}
+
+func TestOrdered(t *testing.T) {
+	// 让前面的任务更慢，结果自然会乱序完成
+	slow := func(ctx context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(10-n) * time.Millisecond)
+		return square(ctx, n)
+	}
+	p := New(context.Background(), slow, Options{Workers: 4, Ordered: true})
+	go func() {
+		defer p.Close()
+		for i := 0; i < 10; i++ {
+			if err := p.Submit(context.Background(), i); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	var got []int
+	for r := range p.Results() {
+		if r.Index != len(got) || r.Input != r.Index {
+			t.Errorf("result %d has index %d, input %d", len(got), r.Index, r.Input)
+		}
+		got = append(got, r.Value)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+	if err := p.Submit(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Close = %v; want ErrClosed", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	fn := func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			panic("三")
+		}
+		return square(ctx, n)
+	}
+	out, err := Map(context.Background(), []int{1, -2, 3, 4}, fn, Options{Workers: 2})
+	if !reflect.DeepEqual(out, []int{1, 0, 0, 16}) {
+		t.Errorf("out = %v; want [1 0 0 16]", out)
+	}
+	if !errors.Is(err, ErrPanic) {
+		t.Errorf("err = %v; want ErrPanic", err)
+	}
+	var jobErr *JobError
+	if !errors.As(err, &jobErr) || (jobErr.Index != 1 && jobErr.Index != 2) {
+		t.Errorf("err = %v; want a *JobError for job 1 or 2", err)
+	}
+}
+
+// TestBackpressure 队列满时 TrySubmit 失败，Submit 阻塞到 ctx 超时
+func TestBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	block := func(ctx context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}
+	p := New(context.Background(), block, Options{Workers: 1, QueueSize: 2})
+	var err error
+	// 一个任务在执行，两个在队列里，最多等 worker 取走第一个
+	for i := 0; i < 3; i++ {
+		for err = p.TrySubmit(i); errors.Is(err, ErrQueueFull); err = p.TrySubmit(i) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err := p.TrySubmit(3); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TrySubmit on full queue = %v; want ErrQueueFull", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit on full queue = %v; want DeadlineExceeded", err)
+	}
+
+	close(release)
+	go p.Close()
+	n := 0
+	for range p.Results() {
+		n++
+	}
+	if err := p.Wait(); err != nil || n != 3 {
+		t.Errorf("drained %d results, err %v; want 3, nil", n, err)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	var started atomic.Int32
+	fn := func(ctx context.Context, n int) (int, error) {
+		started.Add(1)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	p := New(context.Background(), fn, Options{Workers: 2, QueueSize: 10})
+	for i := 0; i < 10; i++ {
+		if err := p.Submit(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for started.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Abort()
+	for range p.Results() {
+	}
+	err := p.Wait()
+	if !errors.Is(err, ErrAborted) || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v; want ErrAborted and context.Canceled", err)
+	}
+	if n := started.Load(); n != 2 {
+		t.Errorf("%d jobs started; want 2", n)
+	}
+	if err := p.Submit(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Abort = %v; want ErrClosed", err)
+	}
+}
+
+// TestParentCancel 取消父 ctx 等同于 Abort：调用方没有 Close，Wait 也能返回
+func TestParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx, square, Options{Workers: 2})
+	if err := p.Submit(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range p.Results() {
+		}
+	}()
+	cancel()
+	done := make(chan error)
+	go func() { done <- p.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait still blocked 2s after the parent ctx was cancelled")
+	}
+	if err := p.Submit(context.Background(), 2); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after cancel = %v; want ErrClosed", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	fn := func(ctx context.Context, n int) (int, error) {
+		<-ctx.Done()
+		return n, nil
+	}
+	p := New(context.Background(), fn, Options{Workers: 1})
+	p.Submit(context.Background(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// 没有人接收结果：排空无法完成，超时后中止
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v; want DeadlineExceeded", err)
+	}
+}
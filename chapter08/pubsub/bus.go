@@ -0,0 +1,303 @@
+// Package pubsub 提供进程内基于通道的发布/订阅总线 Bus[T]。
+//
+// 主题由点号分隔，例如 "orders.created"。订阅时可以使用通配符：
+// "*" 匹配一段，"#" 放在最后匹配零段或多段，例如 "orders.*"、"orders.#"、"#"。
+// 每个订阅者有自己的缓冲通道和慢消费者策略：丢弃最旧的消息、丢弃最新的消息，
+// 或者阻塞发布者直到超时。Close 关闭总线并关闭所有订阅者的通道。
+package pubsub
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+var (
+	// ErrClosed 表示总线已经关闭。
+	ErrClosed = errors.New("pubsub: 总线已关闭")
+	// ErrInvalidTopic 表示主题或订阅模式的格式不正确。
+	ErrInvalidTopic = errors.New("pubsub: 无效的主题")
+)
+
+// Policy 决定订阅者的缓冲区满时如何处理新消息。
+type Policy int
+
+const (
+	// DropNewest 丢弃新消息，发布者从不等待。这是默认策略。
+	DropNewest Policy = iota
+	// DropOldest 丢弃缓冲区中最旧的消息，为新消息腾出位置。
+	DropOldest
+	// Block 阻塞发布者，直到订阅者接收、超时或者取消订阅。
+	Block
+)
+
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Block:
+		return "block"
+	}
+	return "unknown"
+}
+
+// Message 是投递给订阅者的消息。
+type Message[T any] struct {
+	Topic   string
+	Payload T
+	Time    time.Time
+}
+
+// Option 是 New 的可选配置。
+type Option func(*options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock 设置总线使用的时钟，测试中可以用虚拟时钟控制 Block 策略的超时。
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// SubOption 是 Subscribe 的可选配置。
+type SubOption func(*subscriber)
+
+// WithBuffer 设置订阅通道的缓冲大小，默认 16。
+func WithBuffer(n int) SubOption {
+	return func(s *subscriber) {
+		s.buffer = n
+	}
+}
+
+// WithPolicy 设置缓冲区满时的处理策略。
+// timeout 只对 Block 有效，为 0 时一直阻塞到订阅者接收或取消订阅。
+// 发布者阻塞期间持有总线的读锁，Subscribe 和其他订阅者的 Unsubscribe 也要等待，
+// 因此 Block 应该配合超时使用。
+func WithPolicy(p Policy, timeout time.Duration) SubOption {
+	return func(s *subscriber) {
+		s.policy = p
+		s.timeout = timeout
+	}
+}
+
+type subscriber struct {
+	pattern []string
+	buffer  int
+	policy  Policy
+	timeout time.Duration
+
+	done    chan struct{} // 取消订阅时关闭，唤醒阻塞在该订阅者上的发布者
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// Stats 是总线的统计信息。
+type Stats struct {
+	Published   uint64 // Publish 成功调用的次数
+	Delivered   uint64 // 投递到订阅通道的消息数
+	Dropped     uint64 // 因为缓冲区满、超时或取消订阅而丢弃的消息数
+	Subscribers int
+}
+
+// Bus 是按主题分发消息的总线，可以被多个 goroutine 并发使用。
+type Bus[T any] struct {
+	clock clock.Clock
+
+	mu     sync.RWMutex // Publish 持有读锁，订阅、取消订阅和关闭持有写锁
+	subs   map[<-chan Message[T]]*sub[T]
+	closed bool
+
+	// index 是 subs 的副本，在写锁下与 subs 一起更新。Unsubscribe 通过它找到订阅者并唤醒
+	// 阻塞在上面的发布者，不需要先拿读锁：否则有 Subscribe 在等写锁时，新的读锁会被推迟，
+	// 而持有读锁的发布者又在等待这次取消订阅，三者互相等待
+	index sync.Map
+
+	done      chan struct{} // 关闭总线时关闭，唤醒所有阻塞的发布者
+	closeOnce sync.Once
+
+	published, delivered, dropped atomic.Uint64
+}
+
+// sub 把与类型无关的订阅配置和有类型的通道放在一起
+type sub[T any] struct {
+	*subscriber
+	ch chan Message[T]
+}
+
+// New 创建总线。
+func New[T any](opts ...Option) *Bus[T] {
+	o := options{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Bus[T]{
+		clock: o.clock,
+		subs:  make(map[<-chan Message[T]]*sub[T]),
+		done:  make(chan struct{}),
+	}
+}
+
+// Subscribe 订阅匹配 pattern 的主题，返回接收消息的通道。
+// 通道在 Unsubscribe 或 Close 时关闭。
+func (b *Bus[T]) Subscribe(pattern string, opts ...SubOption) (<-chan Message[T], error) {
+	segs, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s := &subscriber{pattern: segs, buffer: 16, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.buffer < 0 {
+		s.buffer = 0
+	}
+	ch := make(chan Message[T], s.buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	sb := &sub[T]{subscriber: s, ch: ch}
+	b.subs[ch] = sb
+	b.index.Store((<-chan Message[T])(ch), sb)
+	return ch, nil
+}
+
+// Unsubscribe 取消订阅并关闭通道，通道中尚未接收的消息仍然可以读出。
+// 阻塞在该订阅者上的发布者会立即返回。ch 不是当前的订阅时什么也不做。
+func (b *Bus[T]) Unsubscribe(ch <-chan Message[T]) {
+	v, ok := b.index.Load(ch)
+	if !ok {
+		return
+	}
+	s := v.(*sub[T])
+	// 先唤醒阻塞的发布者，它们释放读锁后才能拿到写锁
+	s.once.Do(func() { close(s.done) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		b.index.Delete(ch)
+		close(s.ch)
+	}
+}
+
+// Publish 把消息发给所有匹配 topic 的订阅者，返回成功投递的订阅者数量。
+// 发布用的主题不能包含通配符。
+func (b *Bus[T]) Publish(topic string, payload T) (int, error) {
+	if err := validTopic(topic); err != nil {
+		return 0, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return 0, ErrClosed
+	}
+	b.published.Add(1)
+	msg := Message[T]{Topic: topic, Payload: payload, Time: b.clock.Now()}
+	n := 0
+	for _, s := range b.subs {
+		if !match(s.pattern, topic) {
+			continue
+		}
+		if b.deliver(s, msg) {
+			n++
+			b.delivered.Add(1)
+		} else {
+			s.dropped.Add(1)
+			b.dropped.Add(1)
+		}
+	}
+	return n, nil
+}
+
+// deliver 按订阅者的策略投递一条消息，调用方持有 b.mu 的读锁，通道不会被关闭
+func (b *Bus[T]) deliver(s *sub[T], msg Message[T]) bool {
+	select {
+	case s.ch <- msg:
+		return true
+	default:
+	}
+	switch s.policy {
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- msg:
+				return true
+			default:
+			}
+			// 缓冲区满：取走一条最旧的消息。无缓冲的通道没有可以丢弃的旧消息
+			if s.buffer == 0 {
+				return false
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+				b.dropped.Add(1)
+			default:
+			}
+		}
+	case Block:
+		var timeout <-chan time.Time
+		if s.timeout > 0 {
+			timeout = b.clock.After(s.timeout)
+		}
+		select {
+		case s.ch <- msg:
+			return true
+		case <-timeout:
+		case <-s.done:
+		case <-b.done:
+		}
+	}
+	return false
+}
+
+// Close 关闭总线：之后的 Publish 和 Subscribe 返回 ErrClosed，
+// 所有订阅者的通道被关闭。可以多次调用。
+func (b *Bus[T]) Close() {
+	// 先唤醒阻塞的发布者，它们释放读锁后才能拿到写锁
+	b.closeOnce.Do(func() { close(b.done) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch, s := range b.subs {
+		delete(b.subs, ch)
+		b.index.Delete(ch)
+		close(s.ch)
+	}
+}
+
+// Dropped 返回因为 ch 对应的订阅者处理不过来而丢弃的消息数。
+func (b *Bus[T]) Dropped(ch <-chan Message[T]) uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if s, ok := b.subs[ch]; ok {
+		return s.dropped.Load()
+	}
+	return 0
+}
+
+// Stats 返回总线的统计信息。
+func (b *Bus[T]) Stats() Stats {
+	b.mu.RLock()
+	n := len(b.subs)
+	b.mu.RUnlock()
+	return Stats{
+		Published:   b.published.Load(),
+		Delivered:   b.delivered.Load(),
+		Dropped:     b.dropped.Load(),
+		Subscribers: n,
+	}
+}
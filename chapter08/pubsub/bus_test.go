@@ -0,0 +1,215 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"orders.created", "orders.created", true},
+		{"orders.created", "orders.deleted", false},
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders", false},
+		{"orders.*", "orders.created.eu", false},
+		{"*.created", "users.created", true},
+		{"orders.#", "orders", true},
+		{"orders.#", "orders.created.eu", true},
+		{"#", "anything.at.all", true},
+		{"orders", "orders.created", false},
+	}
+	for _, test := range tests {
+		segs, err := parsePattern(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := match(segs, test.topic); got != test.want {
+			t.Errorf("match(%q, %q) = %t; want %t", test.pattern, test.topic, got, test.want)
+		}
+	}
+	for _, bad := range []string{"", "a..b", "#.a", "a.#.b"} {
+		if _, err := parsePattern(bad); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("parsePattern(%q) err = %v; want ErrInvalidTopic", bad, err)
+		}
+	}
+	if _, err := New[int]().Publish("orders.*", 1); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Publish with wildcard err = %v; want ErrInvalidTopic", err)
+	}
+}
+
+// payloads 读出通道中已有的消息
+func payloads(ch <-chan Message[int]) []int {
+	var got []int
+	for {
+		select {
+		case m, ok := <-ch:
+			if !ok {
+				return got
+			}
+			got = append(got, m.Payload)
+		default:
+			return got
+		}
+	}
+}
+
+func TestDropPolicies(t *testing.T) {
+	b := New[int]()
+	newest, _ := b.Subscribe("n", WithBuffer(2), WithPolicy(DropNewest, 0))
+	oldest, _ := b.Subscribe("n", WithBuffer(2), WithPolicy(DropOldest, 0))
+	for i := 1; i <= 5; i++ {
+		b.Publish("n", i)
+	}
+	if got := fmt.Sprint(payloads(newest)); got != "[1 2]" {
+		t.Errorf("drop-newest got %s; want [1 2]", got)
+	}
+	if got := fmt.Sprint(payloads(oldest)); got != "[4 5]" {
+		t.Errorf("drop-oldest got %s; want [4 5]", got)
+	}
+	if b.Dropped(newest) != 3 || b.Dropped(oldest) != 3 {
+		t.Errorf("Dropped = %d, %d; want 3, 3", b.Dropped(newest), b.Dropped(oldest))
+	}
+	if s := b.Stats(); s.Published != 5 || s.Subscribers != 2 {
+		t.Errorf("Stats() = %+v", s)
+	}
+}
+
+func TestBlockTimeout(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New[int](WithClock(clk))
+	ch, _ := b.Subscribe("t", WithBuffer(1), WithPolicy(Block, time.Second))
+	b.Publish("t", 1)
+
+	done := make(chan int)
+	go func() {
+		n, _ := b.Publish("t", 2) // 缓冲区已满，阻塞等待
+		done <- n
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Publish returned before the timeout")
+	default:
+	}
+	clk.Advance(time.Millisecond)
+	if n := <-done; n != 0 {
+		t.Errorf("Publish after timeout delivered to %d subscribers; want 0", n)
+	}
+
+	// 接收方腾出空间后，阻塞的发布者成功投递
+	go func() {
+		n, _ := b.Publish("t", 3)
+		done <- n
+	}()
+	clk.BlockUntil(1)
+	if m := <-ch; m.Payload != 1 {
+		t.Errorf("first message = %d; want 1", m.Payload)
+	}
+	if n := <-done; n != 1 {
+		t.Errorf("Publish delivered to %d subscribers; want 1", n)
+	}
+}
+
+// TestUnsubscribeWakesPublisher 没有超时的 Block 订阅者被取消订阅时，阻塞的发布者返回
+func TestUnsubscribeWakesPublisher(t *testing.T) {
+	b := New[int]()
+	ch, _ := b.Subscribe("t", WithBuffer(0), WithPolicy(Block, 0))
+	done := make(chan int)
+	go func() {
+		n, _ := b.Publish("t", 1)
+		done <- n
+	}()
+	time.Sleep(10 * time.Millisecond) // 让发布者先阻塞；即使没有阻塞，结果也一样
+	b.Unsubscribe(ch)
+	if n := <-done; n != 0 {
+		t.Errorf("Publish delivered to %d subscribers; want 0", n)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after Unsubscribe")
+	}
+	b.Unsubscribe(ch) // 重复取消订阅什么也不做
+}
+
+// TestUnsubscribeWithPendingSubscribe 发布者阻塞在 Block 订阅者上、另一个 Subscribe 在等写锁时，
+// 取消订阅仍然能唤醒发布者，三者都能返回
+func TestUnsubscribeWithPendingSubscribe(t *testing.T) {
+	b := New[int]()
+	ch, _ := b.Subscribe("t", WithBuffer(0), WithPolicy(Block, 0))
+	published := make(chan struct{})
+	go func() {
+		b.Publish("t", 1)
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond) // 让发布者先阻塞
+	subscribed := make(chan struct{})
+	go func() {
+		b.Subscribe("other")
+		close(subscribed)
+	}()
+	time.Sleep(10 * time.Millisecond) // 让 Subscribe 先等待写锁
+
+	unsubscribed := make(chan struct{})
+	go func() {
+		b.Unsubscribe(ch)
+		close(unsubscribed)
+	}()
+	for name, c := range map[string]chan struct{}{"Unsubscribe": unsubscribed, "Publish": published, "Subscribe": subscribed} {
+		select {
+		case <-c:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s still blocked after 2s", name)
+		}
+	}
+	if got := b.Stats().Subscribers; got != 1 {
+		t.Errorf("Subscribers = %d; want 1", got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	b := New[string]()
+	var chans []<-chan Message[string]
+	for _, p := range []string{"a.*", "a.b", "#"} {
+		ch, err := b.Subscribe(p, WithPolicy(Block, 0), WithBuffer(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		chans = append(chans, ch)
+	}
+
+	// 并发发布和关闭，在竞态检测下确认不会向已关闭的通道发送
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := b.Publish("a.b", "x"); errors.Is(err, ErrClosed) {
+					return
+				}
+			}
+		}()
+	}
+	<-chans[0] // 至少有一条消息发出后再关闭
+	b.Close()
+	wg.Wait()
+	for i, ch := range chans {
+		for range ch {
+		}
+		if _, ok := <-ch; ok {
+			t.Errorf("channel %d still open after Close", i)
+		}
+	}
+	if _, err := b.Subscribe("a"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close err = %v; want ErrClosed", err)
+	}
+	b.Close()
+}
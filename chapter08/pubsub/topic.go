@@ -0,0 +1,41 @@
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validTopic 检查发布用的主题：由点号分隔的非空段组成，不能包含通配符
+func validTopic(topic string) error {
+	for _, seg := range strings.Split(topic, ".") {
+		if seg == "" || seg == "*" || seg == "#" {
+			return fmt.Errorf("%w: %q", ErrInvalidTopic, topic)
+		}
+	}
+	return nil
+}
+
+// parsePattern 把订阅模式拆成段：* 匹配一段，# 只能出现在最后，匹配零段或多段
+func parsePattern(pattern string) ([]string, error) {
+	segs := strings.Split(pattern, ".")
+	for i, seg := range segs {
+		if seg == "" || seg == "#" && i != len(segs)-1 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTopic, pattern)
+		}
+	}
+	return segs, nil
+}
+
+// match 判断主题是否匹配模式
+func match(pattern []string, topic string) bool {
+	segs := strings.Split(topic, ".")
+	for i, p := range pattern {
+		if p == "#" {
+			return true
+		}
+		if i >= len(segs) || p != "*" && p != segs[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segs)
+}
@@ -0,0 +1,134 @@
+// Package ratelimit 提供并发安全的令牌桶限流器 Limiter。
+//
+// 令牌以固定速率放入容量为 burst 的桶中，每次请求消耗一个令牌：
+// Allow 在没有令牌时立即返回 false，Wait 则排队等待下一个令牌。
+// Wait 会预先占用令牌，令牌数可能暂时为负，多个等待者因此按到达顺序依次放行。
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+// ErrDeadline 表示 ctx 的截止时间早于能拿到令牌的时间，Wait 不会等待。
+var ErrDeadline = errors.New("ratelimit: 等待令牌会超过 ctx 的截止时间")
+
+// Option 是 New 的可选配置。
+type Option func(*Limiter)
+
+// WithClock 设置限流器使用的时钟，测试中使用虚拟时钟。
+func WithClock(c clock.Clock) Option {
+	return func(l *Limiter) {
+		l.clock = c
+	}
+}
+
+// Limiter 是令牌桶限流器。
+type Limiter struct {
+	clock clock.Clock
+	every time.Duration // 每产生一个令牌的间隔
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time // tokens 对应的时间
+}
+
+// New 创建每隔 every 产生一个令牌、最多积攒 burst 个令牌的限流器，桶一开始是满的。
+// every <= 0 表示不限流。
+func New(every time.Duration, burst int, opts ...Option) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &Limiter{clock: clock.Real(), every: every, burst: burst}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.tokens = float64(burst)
+	l.last = l.clock.Now()
+	return l
+}
+
+// PerSecond 把每秒请求数转换为 New 需要的间隔，n <= 0 时返回 0（不限流）。
+func PerSecond(n float64) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / n)
+}
+
+// advance 按经过的时间补充令牌，调用方必须持有 l.mu
+func (l *Limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += float64(elapsed) / float64(l.every)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+		l.last = now
+	}
+}
+
+// Allow 有令牌时消耗一个并返回 true，否则返回 false，不等待。
+func (l *Limiter) Allow() bool {
+	if l.every <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait 阻塞到拿到一个令牌或者 ctx 结束。如果 ctx 的截止时间早于能拿到令牌的时间，
+// 立即返回 ErrDeadline 而不是白白等待；ctx 被取消时归还预占的令牌。
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil || l.every <= 0 {
+		return err
+	}
+	l.mu.Lock()
+	now := l.clock.Now()
+	l.advance(now)
+	var delay time.Duration
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) * float64(l.every))
+	}
+	// ctx 的截止时间是真实时间，用剩余时长比较，不依赖 l.clock 的时间
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		l.mu.Unlock()
+		return ErrDeadline
+	}
+	l.tokens--
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-l.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.advance(l.clock.Now())
+		if l.tokens++; l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Tokens 返回当前可用的令牌数，可能为负（有等待者预占了令牌）。
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	return l.tokens
+}
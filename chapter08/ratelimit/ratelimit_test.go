@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter08/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestAllow(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	l := New(100*time.Millisecond, 3, WithClock(clk))
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow() #%d = false; want burst of 3", i)
+		}
+	}
+	if l.Allow() {
+		t.Error("Allow() = true with an empty bucket")
+	}
+	clk.Advance(250 * time.Millisecond)
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Error("want exactly 2 tokens after 250ms")
+	}
+	clk.Advance(time.Hour)
+	if got := l.Tokens(); got != 3 {
+		t.Errorf("Tokens() = %v after a long idle; want burst 3", got)
+	}
+}
+
+// TestWaitOrder 多个等待者按到达顺序、每 100ms 放行一个
+func TestWaitOrder(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	l := New(100*time.Millisecond, 1, WithClock(clk))
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan int, 3)
+	for i := 1; i <= 3; i++ {
+		go func(i int) {
+			l.Wait(context.Background())
+			done <- i
+		}(i)
+		clk.BlockUntil(i) // 确保等待者按 1、2、3 的顺序预占令牌
+	}
+	for want := 1; want <= 3; want++ {
+		clk.Advance(100 * time.Millisecond)
+		if got := <-done; got != want {
+			t.Errorf("waiter %d released; want %d", got, want)
+		}
+		if got := clk.Now().Sub(epoch); got != time.Duration(want)*100*time.Millisecond {
+			t.Errorf("waiter %d released at %v", want, got)
+		}
+	}
+}
+
+func TestWaitContext(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	l := New(time.Second, 1, WithClock(clk))
+	l.Allow()
+
+	// 截止时间早于下一个令牌：不等待，直接失败
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, ErrDeadline) {
+		t.Errorf("Wait with an early deadline = %v; want ErrDeadline", err)
+	}
+
+	// 取消等待时归还预占的令牌
+	ctx, cancel = context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- l.Wait(ctx) }()
+	clk.BlockUntil(1)
+	if got := l.Tokens(); got != -1 {
+		t.Errorf("Tokens() = %v while waiting; want -1", got)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait = %v; want Canceled", err)
+	}
+	if got := l.Tokens(); got != 0 {
+		t.Errorf("Tokens() = %v after cancel; want 0", got)
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	l := New(PerSecond(0), 1)
+	for i := 0; i < 1000; i++ {
+		if !l.Allow() {
+			t.Fatal("unlimited limiter refused a request")
+		}
+	}
+	if PerSecond(4) != 250*time.Millisecond {
+		t.Errorf("PerSecond(4) = %v; want 250ms", PerSecond(4))
+	}
+}
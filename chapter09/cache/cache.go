@@ -0,0 +1,347 @@
+// Package cache 提供一个并发安全的泛型缓存 Cache[K, V]。
+//
+// 它把第 9 章的几个例子组合成可以复用的组件：
+// 用互斥锁保护 map，用类似 sync.Once 的方式让并发的未命中共享同一次加载，
+// 再加上按键设置的过期时间（TTL）、LRU 容量限制、淘汰回调和命中率统计。
+//
+// 与示例中的读写锁不同，这里使用普通的互斥锁：LRU 在每次命中时都要移动链表节点，
+// 读操作也需要写锁，RWMutex 不会带来好处。
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLoaderPanic 表示加载函数发生了 panic，等待同一次加载的调用者都会收到这个错误。
+var ErrLoaderPanic = errors.New("cache: 加载函数 panic")
+
+// EvictReason 说明条目被移出缓存的原因。
+type EvictReason int
+
+const (
+	// Evicted 缓存已满，最久未使用的条目被淘汰
+	Evicted EvictReason = iota
+	// Expired 条目超过了过期时间
+	Expired
+	// Deleted 条目被 Delete 或 Clear 删除
+	Deleted
+	// Replaced 条目被 Set 覆盖
+	Replaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Evicted:
+		return "evicted"
+	case Expired:
+		return "expired"
+	case Deleted:
+		return "deleted"
+	case Replaced:
+		return "replaced"
+	}
+	return fmt.Sprintf("EvictReason(%d)", int(r))
+}
+
+// Options 是 New 的配置，零值表示容量不限、永不过期。
+type Options[K comparable, V any] struct {
+	// MaxEntries 最多保存的条目数，<= 0 表示不限
+	MaxEntries int
+	// TTL Set 和 GetOrLoad 使用的默认过期时间，<= 0 表示永不过期
+	TTL time.Duration
+	// OnEvict 条目被移出缓存时调用，调用时不持有缓存的锁，可以再次访问缓存
+	OnEvict func(key K, value V, reason EvictReason)
+	// Now 返回当前时间，默认为 time.Now，测试时可以替换
+	Now func() time.Time
+}
+
+// Stats 是缓存的统计数据。
+type Stats struct {
+	Hits       uint64
+	Misses     uint64
+	Loads      uint64 // 实际执行的加载次数，共享的加载只计一次
+	LoadErrors uint64
+	Evictions  uint64 // 因容量或过期被移出的条目数
+}
+
+// HitRate 返回命中率，还没有访问时返回 0。
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// entry 是 LRU 链表中的一个节点
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // 零值表示永不过期
+}
+
+// call 是一次进行中的加载，done 关闭后 value、err 和 panicked 可读
+type call[V any] struct {
+	done     chan struct{}
+	value    V
+	err      error
+	panicked interface{} // load 发生 panic 时的值，由第一个调用者重新抛出
+	// invalidated 加载期间 key 被 Set、Delete 或 Clear 修改过，加载结果已经过时，不写入缓存。
+	// 它只在 c.mu 下读写；只需要标记进行中的加载，不必为每个键保存版本号
+	invalidated bool
+}
+
+// eviction 是一条待通知的淘汰记录
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+// Cache 是并发安全的泛型缓存，请使用 New 创建。
+type Cache[K comparable, V any] struct {
+	opts Options[K, V]
+
+	mu    sync.Mutex
+	items map[K]*list.Element // 值为 *entry[K, V]
+	lru   *list.List          // 头部是最近使用的条目
+	calls map[K]*call[V]
+
+	hits, misses, loads, loadErrors, evictions atomic.Uint64
+}
+
+// New 创建一个缓存。
+func New[K comparable, V any](opts Options[K, V]) *Cache[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Cache[K, V]{
+		opts:  opts,
+		items: make(map[K]*list.Element),
+		lru:   list.New(),
+		calls: make(map[K]*call[V]),
+	}
+}
+
+// Get 返回 key 对应的值，并把它标记为最近使用。过期的条目视为不存在。
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	v, ok, evicted := c.get(key)
+	c.mu.Unlock()
+	c.notify(evicted)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return v, ok
+}
+
+// get 查找 key，顺便删除已经过期的条目，调用方必须持有 c.mu
+func (c *Cache[K, V]) get(key K) (V, bool, []eviction[K, V]) {
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false, nil
+	}
+	e := el.Value.(*entry[K, V])
+	if !e.expires.IsZero() && !c.opts.Now().Before(e.expires) {
+		return zero, false, []eviction[K, V]{c.remove(el, Expired)}
+	}
+	c.lru.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set 保存 key 和 value，使用默认的过期时间。
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.TTL)
+}
+
+// SetWithTTL 保存 key 和 value，ttl <= 0 表示永不过期。
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	c.invalidate(key)
+	evicted := c.set(key, value, ttl)
+	c.mu.Unlock()
+	c.notify(evicted)
+}
+
+// invalidate 让 key 上进行中的加载结果作废，调用方必须持有 c.mu
+func (c *Cache[K, V]) invalidate(key K) {
+	if cl, ok := c.calls[key]; ok {
+		cl.invalidated = true
+	}
+}
+
+// set 保存条目并按容量淘汰，调用方必须持有 c.mu
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) []eviction[K, V] {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.opts.Now().Add(ttl)
+	}
+	var evicted []eviction[K, V]
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		evicted = append(evicted, eviction[K, V]{key: key, value: e.value, reason: Replaced})
+		e.value, e.expires = value, expires
+		c.lru.MoveToFront(el)
+		return evicted
+	}
+	c.items[key] = c.lru.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		evicted = append(evicted, c.remove(c.lru.Back(), Evicted))
+	}
+	return evicted
+}
+
+// remove 从缓存中删除一个条目，调用方必须持有 c.mu
+func (c *Cache[K, V]) remove(el *list.Element, reason EvictReason) eviction[K, V] {
+	e := c.lru.Remove(el).(*entry[K, V])
+	delete(c.items, e.key)
+	if reason == Evicted || reason == Expired {
+		c.evictions.Add(1)
+	}
+	return eviction[K, V]{key: e.key, value: e.value, reason: reason}
+}
+
+// notify 在不持有锁的情况下调用淘汰回调
+func (c *Cache[K, V]) notify(evicted []eviction[K, V]) {
+	if c.opts.OnEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		c.opts.OnEvict(e.key, e.value, e.reason)
+	}
+}
+
+// GetOrLoad 返回 key 对应的值；未命中时调用 load 加载并保存结果。
+//
+// 同一个 key 的并发未命中只会执行一次 load，其他调用者等待并共享结果，
+// 就像 sync.Once 一样。每个调用者（包括触发加载的第一个调用者）都可以通过 ctx 放弃等待，
+// 但不会取消正在进行的加载：load 收到的 ctx 保留第一个调用者 ctx 中的值，但不会被取消，
+// 因此一个调用者放弃不会让其他等待者失败。
+// load 返回错误时结果不会被缓存，下一次调用会重新加载；
+// 加载期间 key 被 Set、Delete 或 Clear 修改时，加载结果同样不会写入缓存。
+// load 发生 panic 时等待者收到 ErrLoaderPanic，第一个调用者仍在等待时 panic 向它传播。
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(context.Context, K) (V, error)) (V, error) {
+	c.mu.Lock()
+	v, ok, evicted := c.get(key)
+	if ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return v, nil
+	}
+	c.misses.Add(1)
+	cl, ok := c.calls[key]
+	leader := !ok
+	if leader {
+		cl = &call[V]{done: make(chan struct{})}
+		c.calls[key] = cl
+		c.loads.Add(1)
+		go c.load(context.WithoutCancel(ctx), key, cl, load)
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+
+	select {
+	case <-cl.done:
+		if leader && cl.panicked != nil {
+			panic(cl.panicked)
+		}
+		return cl.value, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load 在单独的 goroutine 中执行加载并唤醒等待的调用者
+func (c *Cache[K, V]) load(ctx context.Context, key K, cl *call[V], load func(context.Context, K) (V, error)) {
+	var evicted []eviction[K, V]
+	defer func() {
+		if r := recover(); r != nil {
+			cl.err, cl.panicked = ErrLoaderPanic, r
+		}
+		c.mu.Lock()
+		delete(c.calls, key)
+		if cl.err != nil {
+			c.loadErrors.Add(1)
+		} else if !cl.invalidated {
+			evicted = c.set(key, cl.value, c.opts.TTL)
+		}
+		c.mu.Unlock()
+		close(cl.done)
+		c.notify(evicted)
+	}()
+	cl.value, cl.err = load(ctx, key)
+}
+
+// Delete 删除 key，返回它是否存在。
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	c.invalidate(key)
+	el, ok := c.items[key]
+	var evicted []eviction[K, V]
+	if ok {
+		evicted = append(evicted, c.remove(el, Deleted))
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+	return ok
+}
+
+// DeleteExpired 删除全部已经过期的条目，返回删除的数量。
+// 过期的条目在访问时也会被删除，只有需要及时释放内存或触发回调时才需要调用。
+func (c *Cache[K, V]) DeleteExpired() int {
+	c.mu.Lock()
+	now := c.opts.Now()
+	var evicted []eviction[K, V]
+	for el := c.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if e := el.Value.(*entry[K, V]); !e.expires.IsZero() && !now.Before(e.expires) {
+			evicted = append(evicted, c.remove(el, Expired))
+		}
+		el = prev
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+	return len(evicted)
+}
+
+// Clear 删除全部条目。
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	for key := range c.calls {
+		c.invalidate(key)
+	}
+	var evicted []eviction[K, V]
+	for el := c.lru.Back(); el != nil; el = c.lru.Back() {
+		evicted = append(evicted, c.remove(el, Deleted))
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+}
+
+// Len 返回缓存中的条目数，其中可能包括已经过期但还没有被删除的条目。
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Stats 返回统计数据的快照。
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Loads:      c.loads.Load(),
+		LoadErrors: c.loadErrors.Load(),
+		Evictions:  c.evictions.Load(),
+	}
+}
@@ -0,0 +1,330 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeNow 返回一个可以手动拨动的时钟
+func fakeNow() (now func() time.Time, advance func(time.Duration)) {
+	var mu sync.Mutex
+	t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return t
+	}
+	advance = func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		t = t.Add(d)
+	}
+	return now, advance
+}
+
+func TestLRU(t *testing.T) {
+	var evicted []string
+	c := New(Options[string, int]{
+		MaxEntries: 2,
+		OnEvict: func(key string, value int, reason EvictReason) {
+			evicted = append(evicted, fmt.Sprintf("%s=%d %s", key, value, reason))
+		},
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // a 变为最近使用，b 成为最久未使用
+	c.Set("c", 3)
+	c.Set("a", 10)
+	c.Delete("c")
+
+	want := []string{"b=2 evicted", "a=1 replaced", "c=3 deleted"}
+	if !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted = %q; want %q", evicted, want)
+	}
+	if v, ok := c.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a) = %d, %t; want 10, true", v, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get(b) hit; want miss after eviction")
+	}
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Errorf("Stats() = %+v; want 2 hits, 1 miss, 1 eviction", stats)
+	}
+	if rate := stats.HitRate(); rate < 0.66 || rate > 0.67 {
+		t.Errorf("HitRate() = %f; want 2/3", rate)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	now, advance := fakeNow()
+	var expired []string
+	c := New(Options[string, string]{
+		TTL: time.Minute,
+		Now: now,
+		OnEvict: func(key, _ string, reason EvictReason) {
+			if reason == Expired {
+				expired = append(expired, key)
+			}
+		},
+	})
+	c.Set("default", "x")
+	c.SetWithTTL("short", "y", time.Second)
+	c.SetWithTTL("forever", "z", 0)
+
+	advance(time.Second)
+	if _, ok := c.Get("short"); ok {
+		t.Error("short-lived entry still present after its TTL")
+	}
+	if _, ok := c.Get("default"); !ok {
+		t.Error("default entry expired early")
+	}
+	advance(time.Hour)
+	if n := c.DeleteExpired(); n != 1 {
+		t.Errorf("DeleteExpired() = %d; want 1", n)
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Error("entry without TTL expired")
+	}
+	if !reflect.DeepEqual(expired, []string{"short", "default"}) {
+		t.Errorf("expired = %q; want [short default]", expired)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d; want 1", c.Len())
+	}
+}
+
+// TestSingleFlight 并发未命中同一个键时只执行一次加载
+func TestSingleFlight(t *testing.T) {
+	c := New(Options[string, int]{})
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		<-release
+		return len(key), nil
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	results := make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "hello", load)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = v
+		}(i)
+	}
+	// 等所有调用者都已经未命中，再让加载完成
+	for c.Stats().Misses < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("load called %d times; want 1", calls.Load())
+	}
+	for _, v := range results {
+		if v != 5 {
+			t.Fatalf("results = %v; want all 5", results)
+		}
+	}
+	if v, err := c.GetOrLoad(context.Background(), "hello", load); err != nil || v != 5 {
+		t.Errorf("GetOrLoad after load = %d, %v; want cached 5", v, err)
+	}
+	if stats := c.Stats(); stats.Loads != 1 || stats.Hits != 1 {
+		t.Errorf("Stats() = %+v; want 1 load, 1 hit", stats)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	c := New(Options[int, string]{})
+	boom := errors.New("boom")
+	fail := func(context.Context, int) (string, error) { return "", boom }
+	if _, err := c.GetOrLoad(context.Background(), 1, fail); !errors.Is(err, boom) {
+		t.Errorf("err = %v; want boom", err)
+	}
+	if c.Len() != 0 {
+		t.Error("failed load was cached")
+	}
+
+	// 等待中的调用者可以通过 ctx 放弃
+	block := make(chan struct{})
+	go c.GetOrLoad(context.Background(), 2, func(context.Context, int) (string, error) {
+		<-block
+		return "late", nil
+	})
+	for c.Stats().Loads < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetOrLoad(ctx, 2, fail); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v; want DeadlineExceeded", err)
+	}
+	close(block)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "bad" {
+				t.Errorf("recovered %v; want the loader's panic", r)
+			}
+		}()
+		c.GetOrLoad(context.Background(), 3, func(context.Context, int) (string, error) { panic("bad") })
+	}()
+	if stats := c.Stats(); stats.LoadErrors != 2 {
+		t.Errorf("LoadErrors = %d; want 2", stats.LoadErrors)
+	}
+}
+
+// TestModifyDuringLoad 加载期间 Set 或 Delete 了同一个键时，过时的加载结果不会覆盖它们
+func TestModifyDuringLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Cache[string, string])
+		want   string
+		ok     bool
+	}{
+		{"set", func(c *Cache[string, string]) { c.Set("k", "new") }, "new", true},
+		{"delete", func(c *Cache[string, string]) { c.Delete("k") }, "", false},
+		{"clear", func(c *Cache[string, string]) { c.Clear() }, "", false},
+	}
+	for _, test := range tests {
+		c := New(Options[string, string]{})
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan string)
+		go func() {
+			v, _ := c.GetOrLoad(context.Background(), "k", func(context.Context, string) (string, error) {
+				close(started)
+				<-release
+				return "stale", nil
+			})
+			done <- v
+		}()
+		<-started
+		test.modify(c)
+		close(release)
+		if v := <-done; v != "stale" {
+			t.Errorf("%s: GetOrLoad returned %q; want the loaded value", test.name, v)
+		}
+		if v, ok := c.Get("k"); v != test.want || ok != test.ok {
+			t.Errorf("%s: Get after load = %q, %t; want %q, %t", test.name, v, ok, test.want, test.ok)
+		}
+	}
+}
+
+// TestLeaderCancel 触发加载的调用者放弃等待后，加载继续进行，其他等待者仍然得到结果
+func TestLeaderCancel(t *testing.T) {
+	c := New(Options[string, int]{})
+	release := make(chan struct{})
+	load := func(ctx context.Context, key string) (int, error) {
+		<-release
+		return 42, ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "k", load)
+		leader <- err
+	}()
+	for c.Stats().Loads < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	waiter := make(chan int)
+	go func() {
+		v, err := c.GetOrLoad(context.Background(), "k", load)
+		if err != nil {
+			t.Error(err)
+		}
+		waiter <- v
+	}()
+	for c.Stats().Misses < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader err = %v; want Canceled", err)
+	}
+	close(release)
+	if v := <-waiter; v != 42 {
+		t.Errorf("waiter got %d; want 42", v)
+	}
+	if v, ok := c.Get("k"); !ok || v != 42 {
+		t.Errorf("Get = %d, %t; want cached 42", v, ok)
+	}
+}
+
+// 基准测试：与读写锁保护的 map 比较并发读的开销
+
+const benchKeys = 1024
+
+func BenchmarkCacheGet(b *testing.B) {
+	c := New(Options[int, int]{MaxEntries: benchKeys})
+	for i := 0; i < benchKeys; i++ {
+		c.Set(i, i)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(i % benchKeys)
+			i++
+		}
+	})
+}
+
+func BenchmarkRWMutexMapGet(b *testing.B) {
+	var mu sync.RWMutex
+	m := make(map[int]int)
+	for i := 0; i < benchKeys; i++ {
+		m[i] = i
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			mu.RLock()
+			_ = m[i%benchKeys]
+			mu.RUnlock()
+			i++
+		}
+	})
+}
+
+func BenchmarkMutexMapGet(b *testing.B) {
+	var mu sync.Mutex
+	m := make(map[int]int)
+	for i := 0; i < benchKeys; i++ {
+		m[i] = i
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			mu.Lock()
+			_ = m[i%benchKeys]
+			mu.Unlock()
+			i++
+		}
+	})
+}
+
+func BenchmarkCacheSet(b *testing.B) {
+	c := New(Options[int, int]{MaxEntries: benchKeys})
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Set(i%(2*benchKeys), i)
+			i++
+		}
+	})
+}
@@ -1,11 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"go-programming-language/chapter06/money"
+	"go-programming-language/chapter09/cache"
+	"go-programming-language/chapter09/config"
+	"go-programming-language/chapter09/ledger"
+	"go-programming-language/chapter09/lockorder"
+	"go-programming-language/chapter09/shard"
+	"go-programming-language/chapter09/syncx"
 )
 
 // 示例1：竞态条件
@@ -62,7 +75,8 @@ func rwMutexExample() {
 	fmt.Println("\n=== 读写锁示例 ===")
 	
 	var data = make(map[string]int)
-	var rwMu sync.RWMutex
+	// OrderedRWMutex 的用法与 sync.RWMutex 相同，开启检查后会记录获取顺序
+	rwMu := &lockorder.OrderedRWMutex{Name: "data"}
 	var wg sync.WaitGroup
 	
 	// 初始化数据
@@ -100,22 +114,64 @@ func rwMutexExample() {
 	wg.Wait()
 }
 
+// 示例3.1：锁顺序检查
+// 两个 goroutine 以相反的顺序获取同一对锁，只要时机合适就会死锁。
+// 这里两次获取是先后发生的，程序不会挂起，但检查器仍然能发现顺序不一致
+func lockOrderExample() {
+	fmt.Println("\n=== 锁顺序检查示例 ===")
+	
+	if !lockorder.Enabled() {
+		fmt.Println("检查未开启，使用 -lockorder 参数或 -tags lockorder 构建后运行")
+		return
+	}
+	lockorder.SetHandler(func(v *lockorder.Violation) {
+		fmt.Printf("发现违例: 持有 %s 时获取 %s\n", v.Held, v.Acquiring)
+	})
+	defer lockorder.SetHandler(nil)
+	
+	a := &lockorder.OrderedMutex{Name: "a"}
+	b := &lockorder.OrderedMutex{Name: "b"}
+	
+	done := make(chan struct{})
+	go func() {
+		a.Lock()
+		b.Lock()
+		b.Unlock()
+		a.Unlock()
+		close(done)
+	}()
+	<-done
+	
+	b.Lock()
+	a.Lock() // 与上面的顺序相反
+	a.Unlock()
+	b.Unlock()
+}
+
 // 示例4：sync.Once - 确保某个操作只执行一次
 var once sync.Once
-var config map[string]string
+var configService *config.Service
 
+// loadConfig 只执行一次：从 config.toml 创建配置服务，之后的修改通过热加载生效
 func loadConfig() {
 	fmt.Println("正在加载配置...")
-	config = make(map[string]string)
-	config["host"] = "localhost"
-	config["port"] = "8080"
-	time.Sleep(500 * time.Millisecond) // 模拟配置加载时间
+	s, err := config.Load("config.toml")
+	if err != nil {
+		// 找不到配置文件时使用默认配置
+		fmt.Println("加载配置文件失败，使用默认配置:", err)
+	}
+	configService = s
 	fmt.Println("配置加载完成")
 }
 
-func getConfig() map[string]string {
+var defaultConfig = config.New(map[string]string{"host": "localhost", "port": "8080"})
+
+func getConfig() *config.Config {
 	once.Do(loadConfig)
-	return config
+	if configService == nil {
+		return defaultConfig
+	}
+	return configService.Current()
 }
 
 func syncOnceExample() {
@@ -129,13 +185,85 @@ func syncOnceExample() {
 		go func(id int) {
 			defer wg.Done()
 			cfg := getConfig()
-			fmt.Printf("Goroutine %d 获取配置: %v\n", id, cfg)
+			fmt.Printf("Goroutine %d 获取配置: 版本 %d %v\n", id, cfg.Version(), cfg.Map())
 		}(i)
 	}
 	
 	wg.Wait()
 }
 
+// 示例4.1：泛型缓存
+// cache.Cache 把 sync.Once 的思路推广到每个键：并发未命中同一个键时只加载一次，
+// 并支持过期时间和容量限制
+func cacheExample() {
+	fmt.Println("\n=== 泛型缓存示例 ===")
+	
+	c := cache.New(cache.Options[string, int]{MaxEntries: 100, TTL: time.Minute})
+	var loads int32
+	load := func(ctx context.Context, key string) (int, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(100 * time.Millisecond) // 模拟慢速加载
+		return len(key), nil
+	}
+	
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.GetOrLoad(context.Background(), "config", load)
+		}()
+	}
+	wg.Wait()
+	c.GetOrLoad(context.Background(), "config", load)
+	
+	stats := c.Stats()
+	fmt.Printf("6 次访问只加载了 %d 次，命中 %d 次，未命中 %d 次\n", atomic.LoadInt32(&loads), stats.Hits, stats.Misses)
+}
+
+// 示例4.2：配置热加载
+// 配置文件变化后整体替换配置并通知订阅者，校验失败的配置被拒绝
+func configReloadExample() {
+	fmt.Println("\n=== 配置热加载示例 ===")
+	
+	dir, err := os.MkdirTemp("", "config")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.toml")
+	os.WriteFile(path, []byte("[server]\nport = 8080\n"), 0o644)
+	
+	s, err := config.Load(path, config.WithValidator(func(c *config.Config) error {
+		port, err := c.Int("server.port")
+		if err == nil && (port < 1 || port > 65535) {
+			err = fmt.Errorf("端口超出范围: %d", port)
+		}
+		return err
+	}))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	updates, cancel := s.Subscribe()
+	defer cancel()
+	
+	os.WriteFile(path, []byte("[server]\nport = 9090\n"), 0o644)
+	if _, err := s.Reload(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg := <-updates
+	fmt.Printf("收到新配置: 版本 %d server.port=%s\n", cfg.Version(), cfg.String("server.port", ""))
+	
+	os.WriteFile(path, []byte("[server]\nport = 70000\n"), 0o644)
+	if _, err := s.Reload(); err != nil {
+		fmt.Println("重新加载被拒绝:", err)
+	}
+	fmt.Printf("当前配置: 版本 %d server.port=%s\n", s.Current().Version(), s.Current().String("server.port", ""))
+}
+
 // 示例5：原子操作
 // 原子操作提供了一种无锁的并发访问方式
 func atomicExample() {
@@ -159,62 +287,129 @@ func atomicExample() {
 	fmt.Printf("使用原子操作的计数器结果: %d (期望: 10000)\n", counter)
 }
 
-// 示例6：银行转账问题 - 演示死锁避免
-type Account struct {
-	mu      sync.Mutex
-	balance int
-}
-
-func (a *Account) Deposit(amount int) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	a.balance += amount
-}
-
-func (a *Account) Withdraw(amount int) bool {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	if a.balance < amount {
-		return false
+// 示例5.1：分片计数器和分片 map
+// 所有 goroutine 争用同一个计数器时，锁或原子操作都要在 CPU 之间来回传递同一缓存行；
+// shard 包把计数和 map 分散到多个独占缓存行的分片上
+func shardExample() {
+	fmt.Println("\n=== 分片计数器示例 ===")
+	
+	requests := shard.NewShardedCounter()
+	byPath := shard.NewStripedMap[string, int](0, shard.HashString)
+	paths := []string{"/", "/login", "/api"}
+	var wg sync.WaitGroup
+	
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				requests.Inc()
+				byPath.Update(paths[(id+j)%len(paths)], func(n int, _ bool) int { return n + 1 })
+			}
+		}(i)
+	}
+	
+	wg.Wait()
+	fmt.Printf("请求总数: %d (期望: 10000)\n", requests.Value())
+	for _, p := range paths {
+		n, _ := byPath.Load(p)
+		fmt.Printf("  %-7s %d\n", p, n)
 	}
-	a.balance -= amount
-	return true
-}
-
-func (a *Account) Balance() int {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	return a.balance
 }
 
-// 避免死锁的转账操作
-func Transfer(from, to *Account, amount int) {
-	// 通过账户地址排序来避免死锁
-	first, second := from, to
-	if from > to {
-		first, second = to, from
-	}
+// 示例5.2：信号量、屏障和任务组
+// syncx 包提供 sync 包之外常用的三种同步原语，不必每次都用 WaitGroup 和通道手写
+func syncxExample() {
+	fmt.Println("\n=== 信号量、屏障和任务组示例 ===")
 	
-	first.mu.Lock()
-	second.mu.Lock()
+	// 带权重的信号量：总共 10 个单位的内存，每个任务按大小占用
+	mem := syncx.NewSemaphore(10)
+	var mu sync.Mutex
+	var inUse, peak int64
+	var wg sync.WaitGroup
+	for _, size := range []int64{4, 6, 3, 7, 2, 5} {
+		wg.Add(1)
+		go func(size int64) {
+			defer wg.Done()
+			if err := mem.Acquire(context.Background(), size); err != nil {
+				fmt.Printf("获取内存失败: %v\n", err)
+				return
+			}
+			defer mem.Release(size)
+			mu.Lock()
+			inUse += size
+			if inUse > peak {
+				peak = inUse
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inUse -= size
+			mu.Unlock()
+		}(size)
+	}
+	wg.Wait()
+	fmt.Printf("同时占用的内存最多为 %d (上限: 10)\n", peak)
 	
-	defer first.mu.Unlock()
-	defer second.mu.Unlock()
+	// 循环屏障：3 个参与者分阶段计算，每个阶段结束时由最后到达者汇总
+	const parties = 3
+	var partial [parties]int
+	total := 0
+	barrier := syncx.NewBarrier(parties, func() {
+		for _, v := range partial {
+			total += v
+		}
+		fmt.Printf("阶段完成，累计: %d\n", total)
+	})
+	for p := 0; p < parties; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for phase := 1; phase <= 2; phase++ {
+				partial[p] = phase * (p + 1)
+				barrier.Wait(context.Background())
+			}
+		}(p)
+	}
+	wg.Wait()
 	
-	if from.balance >= amount {
-		from.balance -= amount
-		to.balance += amount
-		fmt.Printf("转账成功: %d 元\n", amount)
-	} else {
-		fmt.Printf("转账失败: 余额不足\n")
+	// 任务组：最多同时运行 2 个任务，第一个错误取消其余任务
+	ctx, g := syncx.WithGroup(context.Background(), 2)
+	for i := 1; i <= 5; i++ {
+		id := i
+		g.Go(func() error {
+			select {
+			case <-time.After(time.Duration(id) * 20 * time.Millisecond):
+			case <-ctx.Done():
+				fmt.Printf("任务 %d 被取消\n", id)
+				return ctx.Err()
+			}
+			if id == 2 {
+				return fmt.Errorf("任务 %d 失败", id)
+			}
+			fmt.Printf("任务 %d 完成\n", id)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		fmt.Printf("任务组返回第一个错误: %v\n", err)
 	}
 }
 
+// 示例6：银行转账 - 复式记账账本
+// ledger 包按账户 ID 排序加锁来避免死锁，并把每笔转账记入只增不改的日志
 func bankExample() {
 	fmt.Println("\n=== 银行转账示例 ===")
 	
-	account1 := &Account{balance: 1000}
-	account2 := &Account{balance: 500}
+	bank := ledger.New()
+	for _, id := range []string{"account1", "account2"} {
+		if err := bank.Open(id, money.CNY); err != nil {
+			fmt.Printf("开户失败: %v\n", err)
+			return
+		}
+	}
+	bank.Deposit("account1", money.MustParse("1000 CNY"))
+	bank.Deposit("account2", money.MustParse("500 CNY"))
 	
 	var wg sync.WaitGroup
 	
@@ -223,22 +418,51 @@ func bankExample() {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			amount := rand.Intn(100) + 1
-			if id%2 == 0 {
-				Transfer(account1, account2, amount)
-			} else {
-				Transfer(account2, account1, amount)
+			req := ledger.Request{
+				Key:    fmt.Sprintf("demo-%d", id),
+				From:   "account1",
+				To:     "account2",
+				Amount: money.New(int64(rand.Intn(10000)+1), money.CNY), // 0.01 到 100.00 元
+			}
+			if id%2 != 0 {
+				req.From, req.To = req.To, req.From
+			}
+			t, err := bank.Transfer(req)
+			if err != nil {
+				fmt.Printf("转账失败: %v\n", err)
+				return
 			}
+			fmt.Printf("转账成功: #%d %s -> %s %s\n", t.ID, t.From, t.To, t.Amount)
 		}(i)
 	}
 	
 	wg.Wait()
 	
-	fmt.Printf("账户1余额: %d\n", account1.Balance())
-	fmt.Printf("账户2余额: %d\n", account2.Balance())
+	balance1, _ := bank.Balance("account1")
+	balance2, _ := bank.Balance("account2")
+	fmt.Printf("账户1余额: %s\n", balance1)
+	fmt.Printf("账户2余额: %s\n", balance2)
+	
+	// 余额可以从日志重新计算出来
+	if err := bank.Verify(); err != nil {
+		fmt.Printf("账本校验失败: %v\n", err)
+	} else {
+		fmt.Printf("账本校验通过，日志共 %d 笔\n", len(bank.Journal()))
+	}
+	
+	// 错误是类型化的，可以用 errors.Is 判断
+	if _, err := bank.Transfer(ledger.Request{From: "account1", To: "account1", Amount: money.New(1, money.CNY)}); errors.Is(err, ledger.ErrSameAccount) {
+		fmt.Printf("拒绝转账: %v\n", err)
+	}
 }
 
 func main() {
+	checkLocks := flag.Bool("lockorder", false, "开启锁顺序检查")
+	flag.Parse()
+	if *checkLocks {
+		lockorder.SetEnabled(true)
+	}
+	
 	fmt.Println("《Go程序设计语言》第9章：共享变量的并发")
 	fmt.Println("============================================")
 	
@@ -246,8 +470,13 @@ func main() {
 	raceConditionExample()
 	mutexExample()
 	rwMutexExample()
+	lockOrderExample()
 	syncOnceExample()
+	cacheExample()
+	configReloadExample()
 	atomicExample()
+	shardExample()
+	syncxExample()
 	bankExample()
 	
 	fmt.Println("\n============================================")
@@ -0,0 +1,131 @@
+// Package config 提供可以热加载的配置服务。
+//
+// 配置从 JSON 文件或类 TOML 文件（key = value，[section] 分节）加载，
+// 嵌套的键用点号连接，例如 [server] 下的 port 对应键 "server.port"。
+// Service 定期检查文件的修改时间，文件变化后重新加载；
+// 新配置通过校验后才用 atomic.Value 整体替换旧配置并通知订阅者，
+// 校验失败时旧配置继续生效。Config 创建后不再修改，读者不会看到更新到一半的配置。
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrMissingKey 表示配置中没有请求的键。
+var ErrMissingKey = errors.New("config: 缺少配置项")
+
+// Config 是一份不可变的配置，可以被多个 goroutine 并发读取。
+type Config struct {
+	values   map[string]string
+	version  int
+	source   string
+	loadedAt time.Time
+}
+
+// New 用 values 的副本创建配置，主要用于测试和默认配置。
+func New(values map[string]string) *Config {
+	c := &Config{values: make(map[string]string, len(values)), loadedAt: time.Now()}
+	for k, v := range values {
+		c.values[k] = v
+	}
+	return c
+}
+
+// Version 返回配置的版本号，第一次加载为 1，每次成功重新加载加 1。
+func (c *Config) Version() int {
+	return c.version
+}
+
+// Source 返回配置文件的路径。
+func (c *Config) Source() string {
+	return c.source
+}
+
+// LoadedAt 返回配置的加载时间。
+func (c *Config) LoadedAt() time.Time {
+	return c.loadedAt
+}
+
+// Lookup 返回键对应的值以及键是否存在。
+func (c *Config) Lookup(key string) (string, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// String 返回键对应的值，键不存在时返回 def。
+func (c *Config) String(key, def string) string {
+	if v, ok := c.values[key]; ok {
+		return v
+	}
+	return def
+}
+
+// Int 把键对应的值解析为整数。
+func (c *Config) Int(key string) (int, error) {
+	v, err := c.require(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("config: %s 不是整数: %q", key, v)
+	}
+	return n, nil
+}
+
+// Bool 把键对应的值解析为布尔值。
+func (c *Config) Bool(key string) (bool, error) {
+	v, err := c.require(key)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("config: %s 不是布尔值: %q", key, v)
+	}
+	return b, nil
+}
+
+// Duration 把键对应的值解析为时间间隔，例如 "1m30s"。
+func (c *Config) Duration(key string) (time.Duration, error) {
+	v, err := c.require(key)
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("config: %s 不是时间间隔: %q", key, v)
+	}
+	return d, nil
+}
+
+func (c *Config) require(key string) (string, error) {
+	v, ok := c.values[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrMissingKey, key)
+	}
+	return v, nil
+}
+
+// Keys 返回全部键，按字典序排列。
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Map 返回全部配置项的副本。
+func (c *Config) Map() map[string]string {
+	m := make(map[string]string, len(c.values))
+	for k, v := range c.values {
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTOML(t *testing.T) {
+	data := `
+# 示例配置
+name = "demo \"app\""  # 行尾注释
+path = 'C:\temp'
+debug = true
+
+[server]
+host = localhost
+port = 8080 # 端口
+`
+	got, err := Parse("app.toml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"name":        `demo "app"`,
+		"path":        `C:\temp`,
+		"debug":       "true",
+		"server.host": "localhost",
+		"server.port": "8080",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %v; want %v", got, want)
+	}
+
+	for _, bad := range []string{"novalue", "a = 1\na = 2", "[section", `s = "open`, `s = "x" y`} {
+		if _, err := Parse("bad.conf", []byte(bad)); err == nil {
+			t.Errorf("Parse(%q) returned nil error", bad)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	data := `{"name": "demo", "server": {"port": 8080, "tls": false, "ratio": 0.5}, "empty": null}`
+	got, err := Parse("app.json", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"name":         "demo",
+		"server.port":  "8080",
+		"server.tls":   "false",
+		"server.ratio": "0.5",
+		"empty":        "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %v; want %v", got, want)
+	}
+	if _, err := Parse("app.json", []byte(`{"list": [1, 2]}`)); err == nil {
+		t.Error("Parse with an array returned nil error")
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	c := New(map[string]string{"port": "80", "debug": "yes", "timeout": "1m30s"})
+	if n, err := c.Int("port"); err != nil || n != 80 {
+		t.Errorf("Int(port) = %d, %v; want 80", n, err)
+	}
+	if _, err := c.Bool("debug"); err == nil {
+		t.Error("Bool(yes) returned nil error")
+	}
+	if d, err := c.Duration("timeout"); err != nil || d != 90*time.Second {
+		t.Errorf("Duration(timeout) = %v, %v; want 1m30s", d, err)
+	}
+	if _, err := c.Int("missing"); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Int(missing) err = %v; want ErrMissingKey", err)
+	}
+	if got := c.String("missing", "def"); got != "def" {
+		t.Errorf("String(missing) = %q; want def", got)
+	}
+}
+
+// writeConfig 写入配置文件，并把修改时间设为 mtime，避免依赖文件系统的时间精度
+func writeConfig(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// validPort 要求 port 是 1 到 65535 之间的整数
+func validPort(c *Config) error {
+	port, err := c.Int("port")
+	if err != nil {
+		return err
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("端口超出范围: %d", port)
+	}
+	return nil
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.conf")
+	mtime := time.Now().Add(-time.Hour)
+	writeConfig(t, path, "port = 8080\n", mtime)
+
+	s, err := Load(path, WithValidator(validPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, cancel := s.Subscribe()
+	defer cancel()
+
+	writeConfig(t, path, "port = 9090\n", mtime.Add(time.Second))
+	if err := s.check(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := <-updates
+	if port, _ := cfg.Int("port"); port != 9090 || cfg.Version() != 2 || s.Current() != cfg {
+		t.Errorf("after reload: port %d, version %d; want 9090, 2", port, cfg.Version())
+	}
+
+	// 校验失败的配置被拒绝，旧配置继续生效，订阅者不会收到通知
+	writeConfig(t, path, "port = 70000\n", mtime.Add(2*time.Second))
+	if err := s.check(); err == nil {
+		t.Error("check with an invalid port returned nil error")
+	}
+	if port, _ := s.Current().Int("port"); port != 9090 {
+		t.Errorf("port after rejected reload = %d; want 9090", port)
+	}
+	select {
+	case cfg := <-updates:
+		t.Errorf("subscriber notified of rejected config %v", cfg.Map())
+	default:
+	}
+	// 没有变化时不会重复加载
+	if err := s.check(); err != nil {
+		t.Errorf("check without changes returned %v", err)
+	}
+
+	// 第一次加载失败时没有旧配置，错误信息不应该提到旧配置
+	if _, err := Load(path, WithValidator(validPort)); err == nil {
+		t.Error("Load of an invalid config returned nil error")
+	} else if strings.Contains(err.Error(), "旧配置") {
+		t.Errorf("Load error = %q; want no mention of an old config", err)
+	}
+}
+
+// TestWatch 并发读取配置的同时不断重新加载，读者看到的 a 和 b 总是相等
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	mtime := time.Now().Add(-time.Hour)
+	writeConfig(t, path, `{"port": 1, "a": "0", "b": "0"}`, mtime)
+
+	var errs []error
+	var mu sync.Mutex
+	s, err := Load(path, WithInterval(time.Millisecond), WithValidator(validPort), WithErrorHandler(func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go s.Watch(ctx)
+	updates, cancel := s.Subscribe()
+	defer cancel()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				cfg := s.Current()
+				if a, b := cfg.String("a", ""), cfg.String("b", ""); a != b {
+					t.Errorf("partial config: a=%s b=%s", a, b)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 1; i <= 5; i++ {
+		writeConfig(t, path, fmt.Sprintf(`{"port": 1, "a": "%d", "b": "%d"}`, i, i), mtime.Add(time.Duration(i)*time.Second))
+		select {
+		case cfg := <-updates:
+			if got := cfg.String("a", ""); got != fmt.Sprint(i) {
+				t.Errorf("update %d: a = %s", i, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for update %d", i)
+		}
+	}
+	writeConfig(t, path, `{"port": 0}`, mtime.Add(time.Minute))
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		mu.Lock()
+		n := len(errs)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("rejected reload was not reported")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+	if got := s.Current().String("a", ""); got != "5" {
+		t.Errorf("a = %s after rejected reload; want 5", got)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Parse 按文件扩展名解析配置内容：.json 使用 JSON，其他扩展名使用类 TOML 格式。
+func Parse(name string, data []byte) (map[string]string, error) {
+	var (
+		values map[string]string
+		err    error
+	)
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		values, err = parseJSON(data)
+	} else {
+		values, err = parseTOML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", name, err)
+	}
+	return values, nil
+}
+
+// parseJSON 解析 JSON 对象，嵌套对象的键用点号连接，标量值转换为字符串
+func parseJSON(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root map[string]any
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	if err := flatten(values, "", root); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, obj map[string]any) error {
+	for k, v := range obj {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(values, key+".", v); err != nil {
+				return err
+			}
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		case nil:
+			values[key] = ""
+		default:
+			return fmt.Errorf("%s: 不支持的值类型 %T", key, v)
+		}
+	}
+	return nil
+}
+
+// parseTOML 解析类 TOML 格式：
+//
+//	# 注释
+//	name = "demo"
+//	[server]
+//	port = 8080   # 行尾注释
+//
+// 带引号的字符串支持 Go 的转义序列，单引号字符串按原样保留
+func parseTOML(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	prefix := ""
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		if text[0] == '[' {
+			if !strings.HasSuffix(text, "]") || len(text) < 3 {
+				return nil, fmt.Errorf("第 %d 行: 无效的分节 %q", line, text)
+			}
+			prefix = strings.TrimSpace(text[1:len(text)-1]) + "."
+			continue
+		}
+		key, raw, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("第 %d 行: 应为 key = value: %q", line, text)
+		}
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %v", line, err)
+		}
+		key = prefix + key
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("第 %d 行: 重复的键 %s", line, key)
+		}
+		values[key] = value
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseValue 解析等号右边的值
+func parseValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := closingQuote(raw)
+		if end < 0 {
+			return "", fmt.Errorf("字符串缺少结束引号: %s", raw)
+		}
+		if err := trailing(raw[end+1:]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(raw[:end+1])
+	case strings.HasPrefix(raw, "'"):
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("字符串缺少结束引号: %s", raw)
+		}
+		if err := trailing(raw[end+2:]); err != nil {
+			return "", err
+		}
+		return raw[1 : end+1], nil
+	}
+	if i := strings.Index(raw, "#"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// closingQuote 返回双引号字符串结束引号的位置，跳过转义的引号
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// trailing 检查字符串值之后只有空白或注释
+func trailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && rest[0] != '#' {
+		return fmt.Errorf("值后面有多余的内容: %q", rest)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Validator 校验新加载的配置，返回错误时新配置被拒绝。
+type Validator func(*Config) error
+
+// Option 是 Load 的可选配置。
+type Option func(*Service)
+
+// WithValidator 设置配置的校验函数，初次加载和每次重新加载都会调用。
+func WithValidator(v Validator) Option {
+	return func(s *Service) {
+		s.validate = v
+	}
+}
+
+// WithInterval 设置 Watch 检查文件修改时间的间隔，默认 1 秒。
+func WithInterval(d time.Duration) Option {
+	return func(s *Service) {
+		s.interval = d
+	}
+}
+
+// WithErrorHandler 设置 Watch 中重新加载失败时的处理函数，默认忽略错误。
+func WithErrorHandler(fn func(error)) Option {
+	return func(s *Service) {
+		s.onError = fn
+	}
+}
+
+// Service 持有当前生效的配置，并在配置文件变化时热加载。
+type Service struct {
+	path     string
+	validate Validator
+	interval time.Duration
+	onError  func(error)
+
+	current atomic.Value // *Config
+
+	mu          sync.Mutex // 保护下面的字段，并串行化重新加载
+	modTime     time.Time
+	size        int64
+	subscribers map[chan *Config]struct{}
+}
+
+// Load 从 path 加载配置，初次加载失败时返回错误。
+func Load(path string, opts ...Option) (*Service, error) {
+	s := &Service{
+		path:        path,
+		interval:    time.Second,
+		subscribers: make(map[chan *Config]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Current 返回当前生效的配置。返回的 Config 不会再改变，
+// 同一个请求内应该只调用一次 Current，避免前后读到不同版本的配置。
+func (s *Service) Current() *Config {
+	return s.current.Load().(*Config)
+}
+
+// Reload 立即重新加载配置文件，返回加载后生效的配置。
+// 解析或校验失败时返回错误，旧配置保持不变。
+func (s *Service) Reload() (*Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return s.reload(info)
+}
+
+// reload 读取、解析、校验并替换配置，调用方必须持有 s.mu
+func (s *Service) reload(info os.FileInfo) (*Config, error) {
+	// 无论成功与否都记下这次看到的文件状态，同一个错误的文件不会被反复加载
+	s.modTime, s.size = info.ModTime(), info.Size()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := Parse(s.path, data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{values: values, source: s.path, loadedAt: time.Now(), version: 1}
+	old, loaded := s.current.Load().(*Config)
+	if loaded {
+		cfg.version = old.version + 1
+	}
+	if s.validate != nil {
+		if err := s.validate(cfg); err != nil {
+			// 第一次加载时还没有旧配置可用
+			if !loaded {
+				return nil, fmt.Errorf("config: %s 校验失败: %w", s.path, err)
+			}
+			return nil, fmt.Errorf("config: %s 校验失败，继续使用旧配置: %w", s.path, err)
+		}
+	}
+
+	s.current.Store(cfg)
+	for ch := range s.subscribers {
+		publish(ch, cfg)
+	}
+	return cfg, nil
+}
+
+// publish 把最新的配置发给订阅者而不阻塞：订阅者来不及接收时，旧的通知被新的替换
+func publish(ch chan *Config, cfg *Config) {
+	for {
+		select {
+		case ch <- cfg:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// Subscribe 返回一个通道，每次成功重新加载后收到新配置，以及取消订阅的函数。
+// 通道只缓存最新的一份配置，处理较慢的订阅者会跳过中间版本。
+func (s *Service) Subscribe() (<-chan *Config, func()) {
+	ch := make(chan *Config, 1)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, ch)
+			s.mu.Unlock()
+		})
+	}
+}
+
+// Watch 每隔一段时间检查配置文件的修改时间和大小，发生变化时重新加载，
+// 直到 ctx 被取消。重新加载的错误交给 WithErrorHandler 设置的函数处理。
+//
+// 写配置文件时最好先写临时文件再重命名，否则可能读到写了一半的文件；
+// 这种文件通常无法通过解析或校验，会被拒绝，等写完后下一次检查再加载。
+func (s *Service) Watch(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := s.check(); err != nil && s.onError != nil {
+			s.onError(err)
+		}
+	}
+}
+
+// check 文件变化时重新加载
+func (s *Service) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+	_, err = s.reload(info)
+	return err
+}
@@ -0,0 +1,587 @@
+// Package ledger 实现一个并发安全的复式记账账本。
+//
+// 每一笔转账都会生成两条金额相反的分录（借方和贷方），并追加到只增不改的日志中，
+// 因此任意时刻账户余额都可以从日志重新计算出来，全部分录的总和恒为零。
+// 存款和取款被视为与外部账户 External 之间的转账；
+// 跨币种转账经由 Exchange 账户兑换，汇率和舍入记录在转账的 Conversion 中。
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-programming-language/chapter06/money"
+	"go-programming-language/chapter09/lockorder"
+)
+
+// 账本操作返回的错误，可以通过 errors.Is 匹配。
+// 货币不一致时返回的错误包装 money.ErrCurrencyMismatch。
+var (
+	ErrInsufficientFunds   = errors.New("ledger: 余额不足")
+	ErrSameAccount         = errors.New("ledger: 转出和转入账户相同")
+	ErrUnknownAccount      = errors.New("ledger: 账户不存在")
+	ErrAccountExists       = errors.New("ledger: 账户已存在")
+	ErrInvalidAmount       = errors.New("ledger: 金额必须大于0")
+	ErrIdempotencyConflict = errors.New("ledger: 幂等键已用于不同的转账")
+	ErrSystemAccount       = errors.New("ledger: 系统账户不能作为转账的一方")
+)
+
+// 系统账户不能通过 Open 开设，它们在每种货币上各有一个余额，可以为负数。
+const (
+	// External 是代表账本外部世界的账户，作为存款和取款的对手方，
+	// 余额的绝对值等于流入账本的该货币资金总额。
+	External = "@external"
+	// Exchange 是货币兑换的中转账户：跨币种转账在源货币上记入它，在目标货币上从它记出，
+	// 因此每种货币的分录仍然各自平衡，它的余额就是账本的外汇敞口。
+	Exchange = "@exchange"
+)
+
+// isSystem 报告 id 是否是系统账户
+func isSystem(id string) bool {
+	return id == External || id == Exchange
+}
+
+// Posting 是一条记账分录，Amount 为正表示记入，为负表示记出。
+type Posting struct {
+	Account string      `json:"account"`
+	Amount  money.Money `json:"amount"`
+}
+
+// Transfer 是日志中的一笔转账记录。
+type Transfer struct {
+	ID       uint64      `json:"id"`
+	Key      string      `json:"key,omitempty"`
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Amount   money.Money `json:"amount"`
+	Time     time.Time   `json:"time"`
+	Postings []Posting   `json:"postings"`
+	// Conversion 跨币种转账的兑换记录，同币种转账为 nil
+	Conversion *Conversion `json:"conversion,omitempty"`
+}
+
+// Request 描述一笔转账请求。
+//
+// Amount 是从 From 扣除的金额，必须以 From 的货币计价；
+// To 以另一种货币计价时，按汇率兑换后记入 To。
+type Request struct {
+	// Key 可选的幂等键：使用相同的键重复提交同一请求只会执行一次
+	Key    string
+	From   string
+	To     string
+	Amount money.Money
+}
+
+// Position 标识某个账户在某种货币上的余额。
+// 普通账户只持有开户时指定的货币，系统账户在每种货币上各有一个 Position。
+type Position struct {
+	Account  string
+	Currency money.Currency
+}
+
+// account 是账本中的一个账户，mu 保护 balance；id 和 currency 创建后不再改变
+type account struct {
+	id       string
+	currency money.Currency
+	mu       lockorder.OrderedMutex
+	balance  money.Money
+}
+
+// newAccount 创建余额为零的账户
+func newAccount(id string, currency money.Currency) *account {
+	a := &account{id: id, currency: currency, balance: money.New(0, currency)}
+	a.mu.Name = "account:" + id + "/" + string(currency)
+	return a
+}
+
+// position 返回账户对应的 Position
+func (a *account) position() Position {
+	return Position{Account: a.id, Currency: a.currency}
+}
+
+// before 定义账户锁的全局顺序：先比较 ID，再比较货币
+func (a *account) before(b *account) bool {
+	if a.id != b.id {
+		return a.id < b.id
+	}
+	return a.currency < b.currency
+}
+
+// pending 记录一个幂等键对应的请求和执行结果，done 关闭后结果可读
+type pending struct {
+	req      Request
+	done     chan struct{}
+	transfer Transfer
+	err      error
+}
+
+// Ledger 是复式记账账本，零值不可用，请使用 New 创建。
+//
+// 锁的顺序：先按 (账户 ID, 货币) 的顺序获取账户锁，再获取 mu；
+// 持有 mu 时从不获取账户锁。锁使用 lockorder 包装，开启检查后违反顺序会被报告。
+type Ledger struct {
+	mu       lockorder.OrderedMutex // 保护 accounts、system、rates、journal、keys 和 nextID
+	accounts map[string]*account
+	system   map[Position]*account // 系统账户在每种货币上的账户，按需创建
+	rates    ExchangeRateProvider
+	journal  []Transfer
+	keys     map[string]*pending
+	nextID   uint64
+	now      func() time.Time
+	// base 日志开始之前的期初余额，从快照恢复时非空
+	base map[Position]int64
+}
+
+// New 创建一个空账本。
+func New() *Ledger {
+	l := &Ledger{
+		accounts: make(map[string]*account),
+		system:   make(map[Position]*account),
+		keys:     make(map[string]*pending),
+		nextID:   1,
+		now:      time.Now,
+	}
+	l.mu.Name = "ledger"
+	return l
+}
+
+// Open 开设一个以 currency 计价、余额为零的账户。
+func (l *Ledger) Open(id string, currency money.Currency) error {
+	return l.open(id, currency, nil)
+}
+
+// open 检查参数后调用 commit（例如写预写日志），commit 成功后才开设账户
+func (l *Ledger) open(id string, currency money.Currency, commit func() error) error {
+	if !currency.Valid() {
+		return fmt.Errorf("%w: %s", money.ErrUnknownCurrency, currency)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.accounts[id]; ok || isSystem(id) {
+		return fmt.Errorf("%w: %s", ErrAccountExists, id)
+	}
+	if commit != nil {
+		if err := commit(); err != nil {
+			return err
+		}
+	}
+	l.accounts[id] = newAccount(id, currency)
+	return nil
+}
+
+// Accounts 返回全部账户 ID（不含系统账户），按字典序排列。
+func (l *Ledger) Accounts() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ids := make([]string, 0, len(l.accounts))
+	for id := range l.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetExchangeRates 设置跨币种转账使用的汇率来源，nil 表示禁止跨币种转账。
+func (l *Ledger) SetExchangeRates(rates ExchangeRateProvider) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rates = rates
+}
+
+// Balance 返回账户的当前余额。系统账户没有单一的余额，返回 ErrUnknownAccount。
+func (l *Ledger) Balance(id string) (money.Money, error) {
+	l.mu.Lock()
+	a, ok := l.accounts[id]
+	l.mu.Unlock()
+	if !ok {
+		return money.Money{}, fmt.Errorf("%w: %s", ErrUnknownAccount, id)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.balance, nil
+}
+
+// Deposit 从外部账户向 id 存入 amount。
+func (l *Ledger) Deposit(id string, amount money.Money) (Transfer, error) {
+	return l.submit(Request{From: External, To: id, Amount: amount}, nil)
+}
+
+// Withdraw 从 id 向外部账户取出 amount。
+func (l *Ledger) Withdraw(id string, amount money.Money) (Transfer, error) {
+	return l.submit(Request{From: id, To: External, Amount: amount}, nil)
+}
+
+// checkRequest 拒绝以系统账户为一方的转账请求：External 只能通过 Deposit 和 Withdraw 使用，
+// Exchange 只由跨币种转账内部使用，否则可以凭空创造资金
+func checkRequest(req Request) error {
+	for _, id := range []string{req.From, req.To} {
+		if isSystem(id) {
+			return fmt.Errorf("%w: %s", ErrSystemAccount, id)
+		}
+	}
+	return nil
+}
+
+// Transfer 原子地执行一笔转账，并把它追加到日志中。
+// 转入账户与金额的货币不同时，使用 SetExchangeRates 设置的汇率兑换，
+// 没有设置汇率时返回包装 money.ErrCurrencyMismatch 的错误。
+//
+// 如果 req.Key 非空，使用相同键的重复请求不会再次执行，而是返回第一次的结果；
+// 键相同但请求内容不同时返回 ErrIdempotencyConflict。
+// 执行失败的请求不会占用幂等键，修正问题后可以用同一个键重试。
+// From 或 To 是系统账户时返回 ErrSystemAccount。
+func (l *Ledger) Transfer(req Request) (Transfer, error) {
+	if err := checkRequest(req); err != nil {
+		return Transfer{}, err
+	}
+	return l.submit(req, nil)
+}
+
+// submit 执行转账请求并处理幂等键，不检查系统账户。
+// 幂等重试直接返回第一次的结果，不会再次调用 commit
+func (l *Ledger) submit(req Request, commit func(Transfer) error) (Transfer, error) {
+	if req.Key == "" {
+		return l.transfer(req, commit)
+	}
+
+	l.mu.Lock()
+	if p, ok := l.keys[req.Key]; ok {
+		l.mu.Unlock()
+		if p.req != req {
+			return Transfer{}, fmt.Errorf("%w: %q", ErrIdempotencyConflict, req.Key)
+		}
+		<-p.done
+		return p.transfer, p.err
+	}
+	p := &pending{req: req, done: make(chan struct{})}
+	l.keys[req.Key] = p
+	l.mu.Unlock()
+
+	p.transfer, p.err = l.transfer(req, commit)
+	if p.err != nil {
+		l.mu.Lock()
+		delete(l.keys, req.Key)
+		l.mu.Unlock()
+	}
+	close(p.done)
+	return p.transfer, p.err
+}
+
+// transfer 执行一笔转账。检查全部通过后调用 commit（为 nil 时跳过），
+// commit 成功之后才修改余额和日志，失败时账本保持不变
+func (l *Ledger) transfer(req Request, commit func(Transfer) error) (Transfer, error) {
+	if req.Amount.Sign() <= 0 {
+		return Transfer{}, ErrInvalidAmount
+	}
+	if req.From == req.To {
+		return Transfer{}, fmt.Errorf("%w: %s", ErrSameAccount, req.From)
+	}
+	source := req.Amount.Currency()
+	target, err := l.currency(req.To, source)
+	if err != nil {
+		return Transfer{}, err
+	}
+	credit := req.Amount
+	var conv *Conversion
+	if target != source {
+		l.mu.Lock()
+		rates := l.rates
+		l.mu.Unlock()
+		if rates == nil {
+			return Transfer{}, fmt.Errorf("%w: 账户 %s 以 %s 计价，金额为 %s，且没有设置汇率",
+				money.ErrCurrencyMismatch, req.To, target, source)
+		}
+		// 查询汇率可能涉及 I/O，不持有任何锁
+		if conv, err = convert(rates, req.Amount, target); err != nil {
+			return Transfer{}, err
+		}
+		credit = conv.Target
+	}
+
+	postings := []Posting{{Account: req.From, Amount: money.New(-req.Amount.Amount(), source)}}
+	if conv != nil {
+		postings = append(postings,
+			Posting{Account: Exchange, Amount: req.Amount},
+			Posting{Account: Exchange, Amount: money.New(-credit.Amount(), target)})
+	}
+	postings = append(postings, Posting{Account: req.To, Amount: credit})
+
+	accounts := make([]*account, len(postings))
+	for i, p := range postings {
+		if accounts[i], err = l.lookup(p.Account, p.Amount.Currency()); err != nil {
+			return Transfer{}, err
+		}
+	}
+
+	// 按全局顺序加锁，避免两个方向相反的转账互相等待。
+	// 多条分录可能记在同一个账户上，每个账户只加锁一次
+	var locked []*account
+	balances := make(map[*account]money.Money, len(accounts))
+	for _, a := range accounts {
+		if _, ok := balances[a]; !ok {
+			balances[a] = money.Money{}
+			locked = append(locked, a)
+		}
+	}
+	sort.Slice(locked, func(i, j int) bool { return locked[i].before(locked[j]) })
+	for _, a := range locked {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		balances[a] = a.balance
+	}
+
+	// 先计算全部新余额，任何一步失败都不修改账户
+	for i, p := range postings {
+		if balances[accounts[i]], err = balances[accounts[i]].Add(p.Amount); err != nil {
+			return Transfer{}, err
+		}
+	}
+	if from := accounts[0]; !isSystem(from.id) && balances[from].Sign() < 0 {
+		return Transfer{}, fmt.Errorf("%w: 账户 %s 余额 %s，需要 %s",
+			ErrInsufficientFunds, from.id, from.balance, req.Amount)
+	}
+
+	// 仍然持有账户锁时写日志，保证同一账户的分录在日志中的顺序与实际执行顺序一致
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := Transfer{
+		ID:         l.nextID,
+		Key:        req.Key,
+		From:       req.From,
+		To:         req.To,
+		Amount:     req.Amount,
+		Time:       l.now(),
+		Postings:   postings,
+		Conversion: conv,
+	}
+	// 调用 commit 时仍然持有账户锁和 l.mu，其他调用者看不到尚未提交的转账
+	if commit != nil {
+		if err := commit(t); err != nil {
+			return Transfer{}, err
+		}
+	}
+	for a, balance := range balances {
+		a.balance = balance
+	}
+	l.nextID++
+	l.journal = append(l.journal, t)
+	return t, nil
+}
+
+// currency 返回记入账户 id 时使用的货币：普通账户是开户时的货币，
+// 系统账户在任何货币上都有余额，使用 fallback
+func (l *Ledger) currency(id string, fallback money.Currency) (money.Currency, error) {
+	if isSystem(id) {
+		return fallback, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownAccount, id)
+	}
+	return a.currency, nil
+}
+
+// lookup 查找账户，并检查它是否以 currency 计价。
+// 系统账户在该货币上的账户不存在时会被创建。
+func (l *Ledger) lookup(id string, currency money.Currency) (*account, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if isSystem(id) {
+		if !currency.Valid() {
+			return nil, fmt.Errorf("%w: %s", money.ErrUnknownCurrency, currency)
+		}
+		pos := Position{Account: id, Currency: currency}
+		a, ok := l.system[pos]
+		if !ok {
+			a = newAccount(id, currency)
+			l.system[pos] = a
+		}
+		return a, nil
+	}
+	a, ok := l.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, id)
+	}
+	if a.currency != currency {
+		return nil, fmt.Errorf("%w: 账户 %s 以 %s 计价，金额为 %s", money.ErrCurrencyMismatch, id, a.currency, currency)
+	}
+	return a, nil
+}
+
+// all 返回全部账户（包括系统账户在各货币上的账户），调用方必须持有 l.mu
+func (l *Ledger) all() []*account {
+	accounts := make([]*account, 0, len(l.accounts)+len(l.system))
+	for _, a := range l.accounts {
+		accounts = append(accounts, a)
+	}
+	for _, a := range l.system {
+		accounts = append(accounts, a)
+	}
+	return accounts
+}
+
+// Journal 返回日志的副本，按转账 ID 递增排列。
+// 从快照恢复的账本只包含快照之后的转账。
+func (l *Ledger) Journal() []Transfer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Transfer(nil), l.journal...)
+}
+
+// lastID 返回最近一笔转账的 ID，还没有转账时返回 0
+func (l *Ledger) lastID() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.nextID - 1
+}
+
+// Replay 根据日志中的分录重新计算每个 Position 的余额（以最小货币单位计），
+// 包括系统账户在各货币上的余额。
+func Replay(journal []Transfer) map[Position]int64 {
+	balances := make(map[Position]int64)
+	for _, t := range journal {
+		for _, p := range t.Postings {
+			balances[Position{Account: p.Account, Currency: p.Amount.Currency()}] += p.Amount.Amount()
+		}
+	}
+	return balances
+}
+
+// Verify 检查账本是否自洽：每笔转账在每种货币上的分录总和为零，
+// 并且期初余额加上从日志重新计算出的余额与账户的当前余额一致。
+//
+// Verify 需要在没有并发转账时调用，否则可能观察到中间状态。
+func (l *Ledger) Verify() error {
+	journal := l.Journal()
+	for _, t := range journal {
+		sums := make(map[money.Currency]int64)
+		for _, p := range t.Postings {
+			sums[p.Amount.Currency()] += p.Amount.Amount()
+		}
+		for currency, sum := range sums {
+			if sum != 0 {
+				return fmt.Errorf("ledger: 转账 %d 的 %s 分录不平衡: %s", t.ID, currency, money.New(sum, currency))
+			}
+		}
+	}
+
+	replayed := Replay(journal)
+	l.mu.Lock()
+	accounts := l.all()
+	l.mu.Unlock()
+	for _, a := range accounts {
+		a.mu.Lock()
+		balance := a.balance
+		a.mu.Unlock()
+		pos := a.position()
+		if expected := l.base[pos] + replayed[pos]; balance.Amount() != expected {
+			return fmt.Errorf("ledger: 账户 %s 余额 %s 与日志重放结果 %s 不一致",
+				a.id, balance, money.New(expected, a.currency))
+		}
+	}
+	return nil
+}
+
+// snapshot 是账本在某一时刻的完整状态
+type snapshot struct {
+	NextID uint64 `json:"next_id"`
+	// Balances 全部账户的余额，包括系统账户在各货币上的余额
+	Balances []Posting           `json:"balances"`
+	Keys     map[string]Transfer `json:"keys,omitempty"`
+}
+
+// snapshot 返回账本的当前状态，调用方需要保证期间没有并发转账。
+func (l *Ledger) snapshot() snapshot {
+	l.mu.Lock()
+	s := snapshot{
+		NextID: l.nextID,
+		Keys:   make(map[string]Transfer),
+	}
+	accounts := l.all()
+	for key, p := range l.keys {
+		select {
+		case <-p.done:
+			if p.err == nil {
+				s.Keys[key] = p.transfer
+			}
+		default:
+		}
+	}
+	l.mu.Unlock()
+
+	// 遵守锁的顺序：释放 mu 之后再获取账户锁
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].before(accounts[j]) })
+	for _, a := range accounts {
+		a.mu.Lock()
+		s.Balances = append(s.Balances, Posting{Account: a.id, Amount: a.balance})
+		a.mu.Unlock()
+	}
+	return s
+}
+
+// restore 根据快照创建账本，快照中的余额成为期初余额。
+func restore(s snapshot) *Ledger {
+	l := New()
+	l.nextID = s.NextID
+	l.base = make(map[Position]int64, len(s.Balances))
+	for _, b := range s.Balances {
+		a := newAccount(b.Account, b.Amount.Currency())
+		a.balance = b.Amount
+		if isSystem(a.id) {
+			l.system[a.position()] = a
+		} else {
+			l.accounts[a.id] = a
+		}
+		l.base[a.position()] = b.Amount.Amount()
+	}
+	for key, t := range s.Keys {
+		l.keys[key] = completed(t)
+	}
+	return l
+}
+
+// apply 重放一笔已经持久化的转账：不做余额检查，直接记入分录。
+// 只在恢复期间、账本尚未被并发使用时调用。
+func (l *Ledger) apply(t Transfer) error {
+	accounts := make([]*account, len(t.Postings))
+	for i, p := range t.Postings {
+		a, err := l.lookup(p.Account, p.Amount.Currency())
+		if err != nil {
+			return err
+		}
+		accounts[i] = a
+	}
+	for i, p := range t.Postings {
+		balance, err := accounts[i].balance.Add(p.Amount)
+		if err != nil {
+			return err
+		}
+		accounts[i].balance = balance
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.journal = append(l.journal, t)
+	if t.ID >= l.nextID {
+		l.nextID = t.ID + 1
+	}
+	if t.Key != "" {
+		l.keys[t.Key] = completed(t)
+	}
+	return nil
+}
+
+// completed 为已经成功执行的转账构造幂等记录
+func completed(t Transfer) *pending {
+	p := &pending{
+		req:      Request{Key: t.Key, From: t.From, To: t.To, Amount: t.Amount},
+		done:     make(chan struct{}),
+		transfer: t,
+	}
+	close(p.done)
+	return p
+}
@@ -0,0 +1,347 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter06/money"
+	"go-programming-language/chapter09/lockorder"
+)
+
+// usd 返回以美分计的金额
+func usd(cents int64) money.Money {
+	return money.New(cents, money.USD)
+}
+
+func newTestLedger(t testing.TB, balances map[string]int64) *Ledger {
+	t.Helper()
+	l := New()
+	for id, balance := range balances {
+		if err := l.Open(id, money.USD); err != nil {
+			t.Fatalf("Open(%s) returned error: %v", id, err)
+		}
+		if balance == 0 {
+			continue
+		}
+		if _, err := l.Deposit(id, usd(balance)); err != nil {
+			t.Fatalf("Deposit(%s) returned error: %v", id, err)
+		}
+	}
+	return l
+}
+
+func TestTransfer(t *testing.T) {
+	l := newTestLedger(t, map[string]int64{"alice": 100, "bob": 50})
+
+	tr, err := l.Transfer(Request{From: "alice", To: "bob", Amount: usd(30)})
+	if err != nil {
+		t.Fatalf("Transfer returned error: %v", err)
+	}
+	if len(tr.Postings) != 2 || tr.Postings[0].Amount != usd(-30) || tr.Postings[1].Amount != usd(30) {
+		t.Errorf("Postings = %v; want -30/+30", tr.Postings)
+	}
+	if tr.Time.IsZero() || tr.ID == 0 {
+		t.Errorf("Transfer = %+v; want ID and Time set", tr)
+	}
+
+	tests := []struct {
+		name string
+		req  Request
+		err  error
+	}{
+		{"insufficient funds", Request{From: "bob", To: "alice", Amount: usd(81)}, ErrInsufficientFunds},
+		{"same account", Request{From: "bob", To: "bob", Amount: usd(1)}, ErrSameAccount},
+		{"unknown account", Request{From: "bob", To: "carol", Amount: usd(1)}, ErrUnknownAccount},
+		{"zero amount", Request{From: "bob", To: "alice", Amount: usd(0)}, ErrInvalidAmount},
+		{"currency mismatch", Request{From: "bob", To: "alice", Amount: money.New(1, money.EUR)}, money.ErrCurrencyMismatch},
+	}
+	for _, test := range tests {
+		if _, err := l.Transfer(test.req); !errors.Is(err, test.err) {
+			t.Errorf("%s: err = %v; want %v", test.name, err, test.err)
+		}
+	}
+
+	if balance, _ := l.Balance("alice"); balance != usd(70) {
+		t.Errorf("alice balance = %v; want 70", balance)
+	}
+	if balance, _ := l.Balance("bob"); balance != usd(80) {
+		t.Errorf("bob balance = %v; want 80", balance)
+	}
+	if _, err := l.Withdraw("bob", usd(81)); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Withdraw err = %v; want ErrInsufficientFunds", err)
+	}
+	if err := l.Open("bob", money.USD); !errors.Is(err, ErrAccountExists) {
+		t.Errorf("Open(bob) err = %v; want ErrAccountExists", err)
+	}
+	if err := l.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCurrencies(t *testing.T) {
+	l := newTestLedger(t, map[string]int64{"alice": 100})
+	if err := l.Open("hans", money.EUR); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Open("x", "XXX"); !errors.Is(err, money.ErrUnknownCurrency) {
+		t.Errorf("Open(XXX) err = %v; want ErrUnknownCurrency", err)
+	}
+	if _, err := l.Deposit("hans", money.MustParse("12.50 EUR")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Deposit("hans", usd(1)); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Deposit(USD) err = %v; want ErrCurrencyMismatch", err)
+	}
+	if balance, _ := l.Balance("hans"); balance != money.MustParse("12.50 EUR") {
+		t.Errorf("hans balance = %v; want 12.50 EUR", balance)
+	}
+
+	// External 在每种货币上分别记账
+	replayed := Replay(l.Journal())
+	if got := replayed[Position{External, money.EUR}]; got != -1250 {
+		t.Errorf("external EUR = %d; want -1250", got)
+	}
+	if got := replayed[Position{External, money.USD}]; got != -100 {
+		t.Errorf("external USD = %d; want -100", got)
+	}
+	if err := l.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	l := newTestLedger(t, map[string]int64{"alice": 100, "bob": 0})
+	req := Request{Key: "order-1", From: "alice", To: "bob", Amount: usd(10)}
+
+	first, err := l.Transfer(req)
+	if err != nil {
+		t.Fatalf("Transfer returned error: %v", err)
+	}
+	second, err := l.Transfer(req)
+	if err != nil || second.ID != first.ID {
+		t.Errorf("retry = %d, %v; want %d, nil", second.ID, err, first.ID)
+	}
+	if balance, _ := l.Balance("bob"); balance != usd(10) {
+		t.Errorf("bob balance = %v; want 10", balance)
+	}
+
+	conflict := req
+	conflict.Amount = usd(20)
+	if _, err := l.Transfer(conflict); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("conflict err = %v; want ErrIdempotencyConflict", err)
+	}
+
+	// 失败的请求不占用幂等键
+	large := Request{Key: "order-2", From: "bob", To: "alice", Amount: usd(50)}
+	if _, err := l.Transfer(large); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("err = %v; want ErrInsufficientFunds", err)
+	}
+	if _, err := l.Deposit("bob", usd(40)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Transfer(large); err != nil {
+		t.Errorf("retry after deposit returned error: %v", err)
+	}
+}
+
+func TestConcurrentIdempotency(t *testing.T) {
+	l := newTestLedger(t, map[string]int64{"alice": 100, "bob": 0})
+	req := Request{Key: "once", From: "alice", To: "bob", Amount: usd(10)}
+
+	var wg sync.WaitGroup
+	ids := make([]uint64, 20)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tr, err := l.Transfer(req)
+			if err != nil {
+				t.Error(err)
+			}
+			ids[i] = tr.ID
+		}(i)
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("ids = %v; want all equal", ids)
+		}
+	}
+	if balance, _ := l.Balance("bob"); balance != usd(10) {
+		t.Errorf("bob balance = %v; want 10", balance)
+	}
+}
+
+// TestConservation 大量并发转账后资金总量守恒，且余额可以从日志重建
+func TestConservation(t *testing.T) {
+	const (
+		accounts  = 20
+		workers   = 16
+		transfers = 500
+		initial   = 1000
+	)
+	balances := make(map[string]int64)
+	for i := 0; i < accounts; i++ {
+		balances[fmt.Sprintf("acct-%02d", i)] = initial
+	}
+	l := newTestLedger(t, balances)
+	ids := l.Accounts()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < transfers; i++ {
+				req := Request{
+					From:   ids[rng.Intn(len(ids))],
+					To:     ids[rng.Intn(len(ids))],
+					Amount: usd(rng.Int63n(200) + 1),
+				}
+				_, err := l.Transfer(req)
+				if err != nil && !errors.Is(err, ErrInsufficientFunds) && !errors.Is(err, ErrSameAccount) {
+					t.Error(err)
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	var total int64
+	for _, id := range ids {
+		balance, _ := l.Balance(id)
+		if balance.Sign() < 0 {
+			t.Errorf("%s balance = %v; want >= 0", id, balance)
+		}
+		total += balance.Amount()
+	}
+	if total != accounts*initial {
+		t.Errorf("total = %d; want %d", total, accounts*initial)
+	}
+	if err := l.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	journal := l.Journal()
+	for i, tr := range journal {
+		if tr.ID != uint64(i+1) {
+			t.Fatalf("journal[%d].ID = %d; want %d", i, tr.ID, i+1)
+		}
+	}
+	external := Position{Account: External, Currency: money.USD}
+	if replayed := Replay(journal); replayed[external] != -accounts*initial {
+		t.Errorf("external balance = %d; want %d", replayed[external], -accounts*initial)
+	}
+}
+
+// TestLockOrder 开启锁顺序检查，并发执行同币种和跨币种转账，不应该出现违例
+func TestLockOrder(t *testing.T) {
+	was := lockorder.Enabled()
+	lockorder.SetEnabled(true)
+	lockorder.SetHandler(func(v *lockorder.Violation) { t.Error(v) })
+	defer func() {
+		lockorder.SetHandler(nil)
+		lockorder.Reset()
+		lockorder.SetEnabled(was)
+	}()
+
+	l := newTestLedger(t, map[string]int64{"a": 10000, "b": 10000, "c": 10000})
+	l.Open("eur", money.EUR)
+	rates := NewStaticRates()
+	rates.Set(money.USD, money.EUR, "0.9")
+	l.SetExchangeRates(rates)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			ids := []string{"a", "b", "c", "eur", External}
+			for i := 0; i < 200; i++ {
+				from, to := ids[rng.Intn(3)], ids[rng.Intn(len(ids))]
+				amount := usd(rng.Int63n(100) + 1)
+				if to == External {
+					l.Withdraw(from, amount)
+				} else {
+					l.Transfer(Request{From: from, To: to, Amount: amount})
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	if err := l.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+// withTimeout 在 2 秒内运行 fn，超时说明出现了死锁
+func withTimeout(t *testing.T, name string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s still blocked after 2s", name)
+	}
+}
+
+// TestSystemAccounts 系统账户不能直接作为转账的一方，否则可以凭空创造资金
+func TestSystemAccounts(t *testing.T) {
+	l := newTestLedger(t, map[string]int64{"a": 1000})
+	l.Open("eur", money.EUR)
+	rates := NewStaticRates()
+	rates.Set(money.USD, money.EUR, "0.8")
+	l.SetExchangeRates(rates)
+
+	for _, req := range []Request{
+		{From: External, To: Exchange, Amount: usd(100)},
+		{From: External, To: "a", Amount: usd(100)},
+		{From: "a", To: External, Amount: usd(100)},
+		{From: Exchange, To: "eur", Amount: usd(100)},
+		{From: "a", To: Exchange, Amount: usd(100)},
+	} {
+		var err error
+		withTimeout(t, fmt.Sprintf("Transfer(%s -> %s)", req.From, req.To), func() {
+			_, err = l.Transfer(req)
+		})
+		if !errors.Is(err, ErrSystemAccount) {
+			t.Errorf("Transfer(%s -> %s) = %v; want ErrSystemAccount", req.From, req.To, err)
+		}
+	}
+	if err := l.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestDuplicatePostingAccounts 多条分录记在同一个账户上时只加锁一次，余额按全部分录累加
+func TestDuplicatePostingAccounts(t *testing.T) {
+	l := New()
+	l.Open("eur", money.EUR)
+	rates := NewStaticRates()
+	rates.Set(money.USD, money.EUR, "0.8")
+	l.SetExchangeRates(rates)
+
+	// Exchange 的 USD 账户先记出再记入，两条分录指向同一个 *account
+	withTimeout(t, "transfer from Exchange", func() {
+		if _, err := l.transfer(Request{From: Exchange, To: "eur", Amount: usd(1000)}, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	if balance, _ := l.Balance("eur"); balance != money.MustParse("8 EUR") {
+		t.Errorf("eur balance = %s; want 8.00 EUR", balance)
+	}
+	if err := l.Verify(); err != nil {
+		t.Error(err)
+	}
+}
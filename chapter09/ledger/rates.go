@@ -0,0 +1,259 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-programming-language/chapter06/money"
+)
+
+// ErrNoRate 表示汇率来源没有两种货币之间的报价。
+var ErrNoRate = errors.New("ledger: 没有可用的汇率")
+
+// RoundHalfEven 是兑换金额使用的舍入方式：舍入到目标货币的最小单位，
+// 恰好一半时取偶数（银行家舍入），记录在 Conversion.Rounding 中。
+const RoundHalfEven = "half-even"
+
+// ExchangeRateProvider 提供货币之间的汇率，实现必须可以被多个 goroutine 并发调用。
+type ExchangeRateProvider interface {
+	// Rate 返回 1 单位 base 货币可以兑换的 quote 货币数量
+	Rate(base, quote money.Currency) (Rate, error)
+}
+
+// Rate 是一条汇率报价：1 单位 Base 货币兑换 Value 单位 Quote 货币。
+type Rate struct {
+	Base   money.Currency
+	Quote  money.Currency
+	Value  *big.Rat
+	Source string // 报价来源，写入兑换记录以便审计
+}
+
+// Conversion 是一次货币兑换的审计记录。
+type Conversion struct {
+	// Source 从转出账户扣除的金额
+	Source money.Money `json:"source"`
+	// Target 舍入后记入转入账户的金额
+	Target money.Money `json:"target"`
+	// Rate 使用的汇率：1 单位源货币兑换的目标货币数量
+	Rate       string `json:"rate"`
+	RateSource string `json:"rate_source,omitempty"`
+	// Exact 舍入前的精确结果（目标货币的主单位），无限小数时为分数形式
+	Exact string `json:"exact"`
+	// Rounding 从 Exact 得到 Target 使用的舍入方式
+	Rounding string `json:"rounding"`
+}
+
+// convert 把 amount 按 rates 提供的汇率兑换成 target 货币
+func convert(rates ExchangeRateProvider, amount money.Money, target money.Currency) (*Conversion, error) {
+	source := amount.Currency()
+	rate, err := rates.Rate(source, target)
+	if err != nil {
+		return nil, err
+	}
+	if rate.Value == nil || rate.Value.Sign() <= 0 {
+		return nil, fmt.Errorf("ledger: %s/%s 的汇率无效: %v", source, target, rate.Value)
+	}
+
+	// 以目标货币最小单位计的精确结果：amount × rate × 10^(目标小数位 - 源小数位)
+	exact := new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Amount()), rate.Value)
+	exact.Mul(exact, new(big.Rat).SetFrac(pow10(target.Digits()), pow10(source.Digits())))
+	rounded := roundHalfEven(exact)
+	if !rounded.IsInt64() {
+		return nil, fmt.Errorf("%w: %s 兑换为 %s", money.ErrOverflow, amount, target)
+	}
+	if rounded.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s 兑换为 %s 后不足一个最小单位", ErrInvalidAmount, amount, target)
+	}
+
+	major := new(big.Rat).Quo(exact, new(big.Rat).SetInt(pow10(target.Digits())))
+	return &Conversion{
+		Source:     amount,
+		Target:     money.New(rounded.Int64(), target),
+		Rate:       formatRat(rate.Value),
+		RateSource: rate.Source,
+		Exact:      formatRat(major),
+		Rounding:   RoundHalfEven,
+	}, nil
+}
+
+// pow10 返回 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundHalfEven 把非负有理数舍入为整数，恰好一半时取偶数
+func roundHalfEven(r *big.Rat) *big.Int {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	switch m.Lsh(m, 1).Cmp(r.Denom()) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// formatRat 把有理数格式化为有限小数，无法精确表示时返回 "a/b" 形式
+func formatRat(r *big.Rat) string {
+	ten := big.NewInt(10)
+	scale := big.NewInt(1)
+	for n := 0; n <= 64; n++ {
+		if new(big.Int).Mod(scale, r.Denom()).Sign() == 0 {
+			return r.FloatString(n)
+		}
+		scale.Mul(scale, ten)
+	}
+	return r.RatString()
+}
+
+// StaticRates 是保存在内存中的汇率表，可以并发读写。
+// 只设置了 A/B 的报价时，B/A 使用其倒数。
+type StaticRates struct {
+	mu     sync.RWMutex
+	rates  map[[2]money.Currency]*big.Rat
+	source string
+}
+
+// NewStaticRates 创建一个空的汇率表。
+func NewStaticRates() *StaticRates {
+	return &StaticRates{rates: make(map[[2]money.Currency]*big.Rat), source: "static"}
+}
+
+// Set 设置 1 单位 base 货币兑换的 quote 货币数量，value 是十进制字符串，例如 "7.1234"。
+func (s *StaticRates) Set(base, quote money.Currency, value string) error {
+	for _, c := range []money.Currency{base, quote} {
+		if !c.Valid() {
+			return fmt.Errorf("%w: %s", money.ErrUnknownCurrency, c)
+		}
+	}
+	if base == quote {
+		return fmt.Errorf("ledger: 不能设置 %s 对自身的汇率", base)
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || r.Sign() <= 0 {
+		return fmt.Errorf("ledger: 汇率必须是正数: %q", value)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[[2]money.Currency{base, quote}] = r
+	return nil
+}
+
+// Rate 实现 ExchangeRateProvider。
+func (s *StaticRates) Rate(base, quote money.Currency) (Rate, error) {
+	rate := Rate{Base: base, Quote: quote, Source: s.source}
+	if base == quote {
+		rate.Value = big.NewRat(1, 1)
+		return rate, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r, ok := s.rates[[2]money.Currency{base, quote}]; ok {
+		rate.Value = new(big.Rat).Set(r)
+		return rate, nil
+	}
+	if r, ok := s.rates[[2]money.Currency{quote, base}]; ok {
+		rate.Value = new(big.Rat).Inv(r)
+		return rate, nil
+	}
+	return Rate{}, fmt.Errorf("%w: %s/%s", ErrNoRate, base, quote)
+}
+
+// CSVRates 从 CSV 文件读取汇率，文件被修改后自动重新加载。
+//
+// 文件每行包含 base,quote,rate 三列，例如：
+//
+//	base,quote,rate
+//	USD,CNY,7.1234
+//	EUR,USD,1.0850
+//
+// 第一行是表头时会被跳过，以 # 开头的行是注释。
+type CSVRates struct {
+	path    string
+	mu      sync.Mutex
+	rates   *StaticRates
+	modTime time.Time
+	size    int64
+}
+
+// OpenCSVRates 加载 path 处的汇率文件。
+func OpenCSVRates(path string) (*CSVRates, error) {
+	c := &CSVRates{path: path}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Rate 实现 ExchangeRateProvider。文件在上次加载后发生变化时先重新加载，
+// 新文件有错误时返回错误，而不是继续使用可能过期的汇率。
+func (c *CSVRates) Rate(base, quote money.Currency) (Rate, error) {
+	if err := c.reload(); err != nil {
+		return Rate{}, err
+	}
+	c.mu.Lock()
+	rates := c.rates
+	c.mu.Unlock()
+	return rates.Rate(base, quote)
+}
+
+// reload 在文件的修改时间或大小变化时重新读取文件
+func (c *CSVRates) reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return err
+	}
+	if c.rates != nil && info.ModTime().Equal(c.modTime) && info.Size() == c.size {
+		return nil
+	}
+	f, err := os.Open(c.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rates, err := parseRates(f)
+	if err != nil {
+		return fmt.Errorf("ledger: 汇率文件 %s: %w", c.path, err)
+	}
+	rates.source = "csv:" + c.path
+	c.rates, c.modTime, c.size = rates, info.ModTime(), info.Size()
+	return nil
+}
+
+// parseRates 解析 CSV 格式的汇率表
+func parseRates(r io.Reader) (*StaticRates, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	rates := NewStaticRates()
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && strings.EqualFold(record[0], "base") {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		base := money.Currency(strings.ToUpper(record[0]))
+		quote := money.Currency(strings.ToUpper(record[1]))
+		if err := rates.Set(base, quote, record[2]); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ledger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-programming-language/chapter06/money"
+)
+
+func TestConvert(t *testing.T) {
+	rates := NewStaticRates()
+	if err := rates.Set(money.USD, money.EUR, "0.125"); err != nil {
+		t.Fatal(err)
+	}
+	rates.Set(money.USD, money.JPY, "151.235")
+
+	tests := []struct {
+		amount string
+		target money.Currency
+		want   string
+		exact  string
+	}{
+		{"0.10 USD", money.EUR, "0.01 EUR", "0.0125"},
+		{"0.20 USD", money.EUR, "0.02 EUR", "0.025"}, // 恰好一半，舍入到偶数
+		{"0.60 USD", money.EUR, "0.08 EUR", "0.075"},
+		{"1 EUR", money.USD, "8.00 USD", "8"}, // 反向汇率
+		{"1 USD", money.JPY, "151 JPY", "151.235"},
+		{"100 JPY", money.USD, "0.66 USD", "20000/30247"},
+	}
+	for _, test := range tests {
+		conv, err := convert(rates, money.MustParse(test.amount), test.target)
+		if err != nil {
+			t.Errorf("convert(%s, %s) returned error: %v", test.amount, test.target, err)
+			continue
+		}
+		if conv.Target != money.MustParse(test.want) || conv.Exact != test.exact {
+			t.Errorf("convert(%s, %s) = %s (exact %s); want %s (exact %s)",
+				test.amount, test.target, conv.Target, conv.Exact, test.want, test.exact)
+		}
+		if conv.Rounding != RoundHalfEven || conv.RateSource != "static" {
+			t.Errorf("conversion = %+v; want half-even from static", conv)
+		}
+	}
+
+	if _, err := convert(rates, money.MustParse("0.01 USD"), money.EUR); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("converting to less than a cent err = %v; want ErrInvalidAmount", err)
+	}
+	if _, err := convert(rates, money.MustParse("1 GBP"), money.EUR); !errors.Is(err, ErrNoRate) {
+		t.Errorf("missing rate err = %v; want ErrNoRate", err)
+	}
+}
+
+func TestCrossCurrencyTransfer(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	s.Open("alice", money.USD)
+	s.Open("hans", money.EUR)
+	s.Deposit("alice", usd(10000))
+
+	req := Request{From: "alice", To: "hans", Amount: usd(1000)}
+	if _, err := s.Transfer(req); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("transfer without rates err = %v; want ErrCurrencyMismatch", err)
+	}
+
+	rates := NewStaticRates()
+	rates.Set(money.EUR, money.USD, "1.25")
+	s.SetExchangeRates(rates)
+	tr, err := s.Transfer(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Conversion == nil || tr.Conversion.Rate != "0.8" || tr.Conversion.Target != money.MustParse("8 EUR") {
+		t.Fatalf("Conversion = %+v; want 10.00 USD -> 8.00 EUR at 0.8", tr.Conversion)
+	}
+	if len(tr.Postings) != 4 {
+		t.Errorf("Postings = %v; want 4 postings through %s", tr.Postings, Exchange)
+	}
+	s.Close()
+
+	// 恢复时重放记录下来的分录，不需要汇率
+	s = openTestStore(t, dir)
+	defer s.Close()
+	if balance, _ := s.Balance("hans"); balance != money.MustParse("8 EUR") {
+		t.Errorf("hans balance = %v; want 8.00 EUR", balance)
+	}
+	if balance, _ := s.Balance("alice"); balance != usd(9000) {
+		t.Errorf("alice balance = %v; want 90.00 USD", balance)
+	}
+	if err := s.Verify(); err != nil {
+		t.Error(err)
+	}
+	replayed := Replay(s.Journal())
+	if replayed[Position{Exchange, money.USD}] != 1000 || replayed[Position{Exchange, money.EUR}] != -800 {
+		t.Errorf("exchange positions = %v", replayed)
+	}
+	journal := s.Journal()
+	if got := journal[len(journal)-1].Conversion; got == nil || *got != *tr.Conversion {
+		t.Errorf("recovered Conversion = %+v; want %+v", got, tr.Conversion)
+	}
+}
+
+func TestCSVRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+	}
+	start := time.Now().Add(-time.Hour)
+	write("base,quote,rate\n# 每日中间价\nUSD,CNY,7.1234\n", start)
+
+	rates, err := OpenCSVRates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate, err := rates.Rate(money.USD, money.CNY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatRat(rate.Value) != "7.1234" || rate.Source != "csv:"+path {
+		t.Errorf("rate = %s from %s; want 7.1234 from csv:%s", formatRat(rate.Value), rate.Source, path)
+	}
+
+	// 文件变化后重新加载
+	write("USD,CNY,7.2\n", start.Add(time.Minute))
+	if rate, _ := rates.Rate(money.USD, money.CNY); formatRat(rate.Value) != "7.2" {
+		t.Errorf("rate after reload = %s; want 7.2", formatRat(rate.Value))
+	}
+
+	write("USD,CNY,7.2\nUSD,EUR,-1\n", start.Add(2*time.Minute))
+	if _, err := rates.Rate(money.USD, money.CNY); err == nil {
+		t.Error("Rate with a malformed file returned nil error")
+	}
+}
@@ -0,0 +1,271 @@
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go-programming-language/chapter06/money"
+	"go-programming-language/chapter09/wal"
+)
+
+// 存储目录中的文件名
+const (
+	snapshotFile = "snapshot.json"
+	logFile      = "ledger.wal"
+)
+
+// ErrStoreFailed 表示之前的日志写入失败。失败的操作没有修改内存状态，
+// 但日志末尾可能留下了不完整的记录，需要关闭后重新打开存储。
+var ErrStoreFailed = errors.New("ledger: 存储已失效")
+
+// record 是写入预写日志的一条记录
+type record struct {
+	Op       string         `json:"op"` // "open" 或 "transfer"
+	Account  string         `json:"account,omitempty"`
+	Currency money.Currency `json:"currency,omitempty"`
+	Transfer *Transfer      `json:"transfer,omitempty"`
+}
+
+// StoreOption 是 OpenStore 的函数式选项。
+type StoreOption func(*Store)
+
+// WithSnapshotEvery 设置每写入 n 条日志记录自动生成一次快照，n <= 0 表示不自动生成。
+func WithSnapshotEvery(n int) StoreOption {
+	return func(s *Store) {
+		s.snapshotEvery = n
+	}
+}
+
+// Store 是持久化的账本：每个开户、存款、取款和转账操作在返回之前
+// 都会追加到带校验和的预写日志并 fsync。
+//
+// 重新打开时先加载最近的快照，再重放快照之后的日志；
+// 写到一半时崩溃留下的不完整记录会被截断，对应的操作从未被确认过。
+type Store struct {
+	mu            sync.Mutex // 串行化写操作，保证日志顺序与执行顺序一致
+	dir           string
+	ledger        *Ledger
+	log           *wal.Log
+	snapshotEvery int
+	sinceSnapshot int
+	err           error // 第一次日志写入失败的原因
+}
+
+// OpenStore 打开 dir 中的账本，目录不存在时会被创建。
+func OpenStore(dir string, opts ...StoreOption) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	snap, err := readSnapshot(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	s.ledger = New()
+	if snap != nil {
+		s.ledger = restore(*snap)
+	}
+
+	s.log, err = wal.Open(filepath.Join(dir, logFile))
+	if err != nil {
+		return nil, err
+	}
+	err = s.log.Replay(func(data []byte) error {
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		s.sinceSnapshot++
+		return s.recover(rec, snap)
+	})
+	if err != nil {
+		s.log.Close()
+		return nil, fmt.Errorf("ledger: 重放日志失败: %w", err)
+	}
+	return s, nil
+}
+
+// recover 把一条日志记录应用到账本上
+func (s *Store) recover(rec record, snap *snapshot) error {
+	switch rec.Op {
+	case "open":
+		// 快照写入后、日志清空前崩溃时，开户记录可能已经包含在快照中
+		if err := s.ledger.Open(rec.Account, rec.Currency); err != nil && !errors.Is(err, ErrAccountExists) {
+			return err
+		}
+		return nil
+	case "transfer":
+		if rec.Transfer == nil {
+			return errors.New("转账记录为空")
+		}
+		if snap != nil && rec.Transfer.ID < snap.NextID {
+			return nil
+		}
+		return s.ledger.apply(*rec.Transfer)
+	default:
+		return fmt.Errorf("未知的日志记录 %q", rec.Op)
+	}
+}
+
+// Open 开设一个以 currency 计价、余额为零的账户。
+func (s *Store) Open(id string, currency money.Currency) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return fmt.Errorf("%w: %v", ErrStoreFailed, s.err)
+	}
+	err := s.ledger.open(id, currency, func() error {
+		return s.write(record{Op: "open", Account: id, Currency: currency})
+	})
+	if err != nil {
+		return err
+	}
+	s.maybeSnapshot()
+	return nil
+}
+
+// Deposit 从外部账户向 id 存入 amount。
+func (s *Store) Deposit(id string, amount money.Money) (Transfer, error) {
+	return s.submit(Request{From: External, To: id, Amount: amount})
+}
+
+// Withdraw 从 id 向外部账户取出 amount。
+func (s *Store) Withdraw(id string, amount money.Money) (Transfer, error) {
+	return s.submit(Request{From: id, To: External, Amount: amount})
+}
+
+// Transfer 执行一笔转账，返回时转账记录已经落盘。
+// From 或 To 是系统账户时返回 ErrSystemAccount。
+func (s *Store) Transfer(req Request) (Transfer, error) {
+	if err := checkRequest(req); err != nil {
+		return Transfer{}, err
+	}
+	return s.submit(req)
+}
+
+// submit 执行转账请求，不检查系统账户。
+// 转账记录先写入日志并落盘，之后才修改内存中的余额；
+// 幂等重试返回的是已经持久化的转账，不会再写日志。
+func (s *Store) submit(req Request) (Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return Transfer{}, fmt.Errorf("%w: %v", ErrStoreFailed, s.err)
+	}
+	t, err := s.ledger.submit(req, func(t Transfer) error {
+		return s.write(record{Op: "transfer", Transfer: &t})
+	})
+	if err != nil {
+		return Transfer{}, err
+	}
+	s.maybeSnapshot()
+	return t, nil
+}
+
+// write 写入一条日志记录并落盘，调用方必须持有 s.mu。
+// 它在账本的锁内被调用，不能再访问账本
+func (s *Store) write(rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := s.log.Append(data); err != nil {
+		s.err = err
+		return fmt.Errorf("%w: %v", ErrStoreFailed, err)
+	}
+	s.sinceSnapshot++
+	return nil
+}
+
+// maybeSnapshot 在写入的日志记录达到 snapshotEvery 条时生成快照，调用方必须持有 s.mu
+func (s *Store) maybeSnapshot() {
+	if s.snapshotEvery > 0 && s.sinceSnapshot >= s.snapshotEvery {
+		// 日志已经落盘，快照失败不影响本次操作的持久性
+		s.snapshot()
+	}
+}
+
+// Snapshot 立即把当前状态写入快照并清空日志。
+func (s *Store) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return fmt.Errorf("%w: %v", ErrStoreFailed, s.err)
+	}
+	return s.snapshot()
+}
+
+func (s *Store) snapshot() error {
+	data, err := json.Marshal(s.ledger.snapshot())
+	if err != nil {
+		return err
+	}
+	if err := wal.WriteFileAtomic(filepath.Join(s.dir, snapshotFile), data); err != nil {
+		return err
+	}
+	// 快照已经持久化，清空日志失败只会导致下次打开时多重放几条记录
+	if err := s.log.Reset(); err != nil {
+		return err
+	}
+	s.sinceSnapshot = 0
+	return nil
+}
+
+// SetExchangeRates 设置跨币种转账使用的汇率来源。
+// 恢复时直接重放日志中记录的分录，不需要汇率。
+func (s *Store) SetExchangeRates(rates ExchangeRateProvider) {
+	s.ledger.SetExchangeRates(rates)
+}
+
+// Balance 返回账户的当前余额。
+func (s *Store) Balance(id string) (money.Money, error) {
+	return s.ledger.Balance(id)
+}
+
+// Accounts 返回全部账户 ID（不含系统账户），按字典序排列。
+func (s *Store) Accounts() []string {
+	return s.ledger.Accounts()
+}
+
+// Journal 返回最近一次快照之后的转账记录。
+func (s *Store) Journal() []Transfer {
+	return s.ledger.Journal()
+}
+
+// Verify 检查账本是否自洽，参见 Ledger.Verify。
+func (s *Store) Verify() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ledger.Verify()
+}
+
+// Close 关闭日志文件。
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+// readSnapshot 读取快照文件，文件不存在时返回 nil
+func readSnapshot(path string) (*snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("ledger: 快照损坏: %w", err)
+	}
+	return &snap, nil
+}
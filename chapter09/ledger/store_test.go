@@ -0,0 +1,194 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-programming-language/chapter06/money"
+)
+
+func openTestStore(t *testing.T, dir string, opts ...StoreOption) *Store {
+	t.Helper()
+	s, err := OpenStore(dir, opts...)
+	if err != nil {
+		t.Fatalf("OpenStore returned error: %v", err)
+	}
+	return s
+}
+
+func balances(t *testing.T, s *Store) map[string]int64 {
+	t.Helper()
+	m := make(map[string]int64)
+	for _, id := range s.Accounts() {
+		balance, err := s.Balance(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m[id] = balance.Amount()
+	}
+	return m
+}
+
+// TestStoreCrash 在任意位置截断日志，模拟写到一半时崩溃：
+// 重新打开后的余额必须等于某个已确认操作前缀执行后的余额
+func TestStoreCrash(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, filepath.Join(dir, "full"))
+	var (
+		states []map[string]int64 // states[i] 是前 i 条记录落盘后的余额
+		ends   []int64            // ends[i] 是第 i+1 条记录结束时的日志大小
+	)
+	states = append(states, map[string]int64{})
+	record := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, balances(t, s))
+		ends = append(ends, s.log.Size())
+	}
+	record(s.Open("alice", money.USD))
+	record(s.Open("bob", money.USD))
+	_, err := s.Deposit("alice", usd(100))
+	record(err)
+	_, err = s.Transfer(Request{Key: "k1", From: "alice", To: "bob", Amount: usd(30)})
+	record(err)
+	_, err = s.Withdraw("bob", usd(10))
+	record(err)
+	_, err = s.Transfer(Request{From: "bob", To: "alice", Amount: usd(5)})
+	record(err)
+	s.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "full", logFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cut := 0; cut <= len(data); cut++ {
+		torn := filepath.Join(dir, fmt.Sprintf("torn-%d", cut))
+		os.Mkdir(torn, 0o755)
+		if err := os.WriteFile(filepath.Join(torn, logFile), data[:cut], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s := openTestStore(t, torn)
+		complete := 0
+		for complete < len(ends) && ends[complete] <= int64(cut) {
+			complete++
+		}
+		if got := balances(t, s); !reflect.DeepEqual(got, states[complete]) {
+			t.Fatalf("cut %d: balances = %v; want %v", cut, got, states[complete])
+		}
+		if err := s.Verify(); err != nil {
+			t.Fatalf("cut %d: %v", cut, err)
+		}
+		s.Close()
+	}
+}
+
+func TestStoreSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir, WithSnapshotEvery(3))
+	for _, id := range []string{"alice", "bob", "carol"} {
+		if err := s.Open(id, money.USD); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Deposit("alice", usd(1000)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		to := "bob"
+		if i%2 == 0 {
+			to = "carol"
+		}
+		if _, err := s.Transfer(Request{From: "alice", To: to, Amount: usd(int64(i + 1))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := balances(t, s)
+	last := s.ledger.lastID()
+	s.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+	s = openTestStore(t, dir)
+	defer s.Close()
+	if got := balances(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("balances = %v; want %v", got, want)
+	}
+	if err := s.Verify(); err != nil {
+		t.Error(err)
+	}
+	tr, err := s.Deposit("bob", usd(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.ID != last+1 {
+		t.Errorf("next ID = %d; want %d", tr.ID, last+1)
+	}
+}
+
+func TestStoreIdempotencyAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	s.Open("alice", money.USD)
+	s.Open("bob", money.USD)
+	s.Deposit("alice", usd(100))
+	req := Request{Key: "order-1", From: "alice", To: "bob", Amount: usd(40)}
+	first, err := s.Transfer(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 快照之后幂等键仍然有效
+	if err := s.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = openTestStore(t, dir)
+	defer s.Close()
+	again, err := s.Transfer(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("retry ID = %d; want %d", again.ID, first.ID)
+	}
+	if balance, _ := s.Balance("bob"); balance != usd(40) {
+		t.Errorf("bob balance = %v; want 40", balance)
+	}
+	if size := s.log.Size(); size != 0 {
+		t.Errorf("retry wrote %d bytes to the log; want 0", size)
+	}
+}
+
+// TestStoreWriteFailure 检查日志写入失败时内存中的账本保持不变
+func TestStoreWriteFailure(t *testing.T) {
+	s := openTestStore(t, t.TempDir())
+	s.Open("alice", money.USD)
+	s.Open("bob", money.USD)
+	s.Deposit("alice", usd(100))
+	want := balances(t, s)
+	journal := len(s.Journal())
+
+	s.log.Close() // 之后的 Append 都会失败
+	if _, err := s.Transfer(Request{From: "alice", To: "bob", Amount: usd(40)}); !errors.Is(err, ErrStoreFailed) {
+		t.Fatalf("Transfer error = %v; want ErrStoreFailed", err)
+	}
+	if got := balances(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("balances after failed write = %v; want %v", got, want)
+	}
+	if n := len(s.Journal()); n != journal {
+		t.Errorf("journal has %d transfers after failed write; want %d", n, journal)
+	}
+	if err := s.Open("carol", money.USD); !errors.Is(err, ErrStoreFailed) {
+		t.Errorf("Open error = %v; want ErrStoreFailed", err)
+	}
+	if _, err := s.Balance("carol"); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("Balance(carol) error = %v; want ErrUnknownAccount", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build lockorder
+
+package lockorder
+
+// 使用 -tags lockorder 构建时默认开启检查
+func init() {
+	enabled.Store(true)
+}
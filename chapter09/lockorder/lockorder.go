@@ -0,0 +1,261 @@
+// Package lockorder 提供用于调试的互斥锁包装 OrderedMutex 和 OrderedRWMutex，
+// 在运行时检查锁的获取顺序。
+//
+// 每当一个 goroutine 在持有锁 A 的情况下获取锁 B，检查器就在全局的锁顺序图中记录一条边 A → B。
+// 如果新加入的边使图中出现环（例如另一个 goroutine 曾经在持有 B 时获取 A），
+// 说明这些锁可能以相反的顺序被获取，存在死锁的风险；检查器会在真正阻塞之前报告，
+// 报告中包含本次获取和之前那次反向获取时的调用栈。
+//
+// 检查默认关闭，此时包装锁只比 sync.Mutex 多一次原子读。
+// 使用 -tags lockorder 构建或调用 SetEnabled(true) 可以开启检查。
+package lockorder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// enabled 报告是否开启检查
+var enabled atomic.Bool
+
+// SetEnabled 开启或关闭检查。关闭检查时已经持有的锁不再被跟踪，
+// 应该在没有锁被持有时切换。
+func SetEnabled(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled 报告检查是否开启。
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Edge 是锁顺序图中的一条边：在持有 From 的情况下获取了 To。
+type Edge struct {
+	From  string
+	To    string
+	Stack string // 第一次以这个顺序获取时的调用栈
+}
+
+// Violation 描述一次锁顺序违例。
+type Violation struct {
+	// Acquiring 正在获取的锁，Held 当前 goroutine 已经持有的锁
+	Acquiring string
+	Held      string
+	// Stack 本次获取时的调用栈
+	Stack string
+	// Cycle 之前记录的、从 Acquiring 到 Held 的路径；
+	// 加上本次的 Held → Acquiring 构成一个环。重复加锁时为空
+	Cycle []Edge
+}
+
+// Error 实现 error 接口，返回包含两处调用栈的报告。
+func (v *Violation) Error() string {
+	var b strings.Builder
+	if len(v.Cycle) == 0 {
+		fmt.Fprintf(&b, "lockorder: 重复获取已经持有的锁 %s\n", v.Acquiring)
+	} else {
+		fmt.Fprintf(&b, "lockorder: 可能的死锁：持有 %s 时获取 %s，但之前的获取顺序是", v.Held, v.Acquiring)
+		for _, e := range v.Cycle {
+			fmt.Fprintf(&b, " %s →", e.From)
+		}
+		fmt.Fprintf(&b, " %s\n", v.Held)
+	}
+	fmt.Fprintf(&b, "\n本次获取:\n%s", v.Stack)
+	for _, e := range v.Cycle {
+		fmt.Fprintf(&b, "\n之前持有 %s 时获取 %s:\n%s", e.From, e.To, e.Stack)
+	}
+	return b.String()
+}
+
+// handler 处理违例，默认打印报告后 panic
+var handler atomic.Value // func(*Violation)
+
+// SetHandler 设置发现违例时的处理函数，nil 恢复默认行为（打印到标准错误后 panic）。
+// 处理函数返回后加锁照常进行。
+func SetHandler(fn func(*Violation)) {
+	if fn == nil {
+		fn = defaultHandler
+	}
+	handler.Store(fn)
+}
+
+func defaultHandler(v *Violation) {
+	fmt.Fprintln(os.Stderr, v.Error())
+	panic(v)
+}
+
+func init() {
+	handler.Store(defaultHandler)
+}
+
+// lock 是被跟踪的锁，由 *OrderedMutex 和 *OrderedRWMutex 实现
+type lock interface {
+	String() string
+}
+
+// detector 保存锁顺序图和每个 goroutine 持有的锁
+type detector struct {
+	mu    sync.Mutex
+	held  map[int64][]lock
+	graph map[lock]map[lock]string // from → to → 第一次获取时的调用栈
+}
+
+var global = &detector{
+	held:  make(map[int64][]lock),
+	graph: make(map[lock]map[lock]string),
+}
+
+// Reset 清空锁顺序图，例如在两个互不相关的测试之间调用。
+func Reset() {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.held = make(map[int64][]lock)
+	global.graph = make(map[lock]map[lock]string)
+}
+
+// before 在当前 goroutine 获取 l 之前调用：检查并记录新的边
+func (d *detector) before(l lock) {
+	gid := goroutineID()
+	d.mu.Lock()
+	var violations []*Violation
+	var stack string
+	for _, h := range d.held[gid] {
+		if h == l {
+			if stack == "" {
+				stack = callers()
+			}
+			violations = append(violations, &Violation{Acquiring: l.String(), Held: h.String(), Stack: stack})
+			continue
+		}
+		if _, ok := d.graph[h][l]; ok {
+			continue
+		}
+		if stack == "" {
+			stack = callers()
+		}
+		if path := d.path(l, h); path != nil {
+			violations = append(violations, &Violation{
+				Acquiring: l.String(),
+				Held:      h.String(),
+				Stack:     stack,
+				Cycle:     path,
+			})
+			continue
+		}
+		if d.graph[h] == nil {
+			d.graph[h] = make(map[lock]string)
+		}
+		d.graph[h][l] = stack
+	}
+	d.mu.Unlock()
+
+	// 在检查器的锁之外调用处理函数，处理函数可以安全地使用被跟踪的锁
+	for _, v := range violations {
+		handler.Load().(func(*Violation))(v)
+	}
+}
+
+// acquired 在当前 goroutine 获取 l 之后调用
+func (d *detector) acquired(l lock) {
+	gid := goroutineID()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.held[gid] = append(d.held[gid], l)
+}
+
+// released 在释放 l 时调用。sync.Mutex 允许在另一个 goroutine 中解锁，
+// 因此当前 goroutine 没有持有 l 时在其他 goroutine 中查找
+func (d *detector) released(l lock) {
+	gid := goroutineID()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.remove(gid, l) {
+		return
+	}
+	for g := range d.held {
+		if d.remove(g, l) {
+			return
+		}
+	}
+}
+
+// remove 从 goroutine g 持有的锁中删除最近获取的 l
+func (d *detector) remove(g int64, l lock) bool {
+	held := d.held[g]
+	for i := len(held) - 1; i >= 0; i-- {
+		if held[i] == l {
+			held = append(held[:i], held[i+1:]...)
+			if len(held) == 0 {
+				delete(d.held, g)
+			} else {
+				d.held[g] = held
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// path 用广度优先搜索查找从 from 到 to 的路径，不存在时返回 nil
+func (d *detector) path(from, to lock) []Edge {
+	prev := map[lock]lock{from: nil}
+	queue := []lock{from}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == to {
+			var path []Edge
+			for n != from {
+				p := prev[n]
+				path = append([]Edge{{From: p.String(), To: n.String(), Stack: d.graph[p][n]}}, path...)
+				n = p
+			}
+			return path
+		}
+		for next := range d.graph[n] {
+			if _, seen := prev[next]; !seen {
+				prev[next] = n
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// goroutineID 从调用栈的第一行 "goroutine 123 [running]:" 中解析当前 goroutine 的 ID。
+// 这种做法很慢，只适合调试
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+// callers 返回当前 goroutine 的调用栈，去掉本包内部（测试以外）的帧
+func callers() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		f, more := frames.Next()
+		internal := strings.Contains(f.Function, "chapter09/lockorder.") && !strings.HasSuffix(f.File, "_test.go")
+		if !internal {
+			fmt.Fprintf(&b, "\t%s\n\t\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package lockorder
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// record 开启检查并收集违例，测试结束后恢复默认设置
+func record(t *testing.T) *[]*Violation {
+	t.Helper()
+	var (
+		mu         sync.Mutex
+		violations []*Violation
+	)
+	was := Enabled()
+	SetEnabled(true)
+	Reset()
+	SetHandler(func(v *Violation) {
+		mu.Lock()
+		violations = append(violations, v)
+		mu.Unlock()
+	})
+	t.Cleanup(func() {
+		SetHandler(nil)
+		Reset()
+		SetEnabled(was)
+	})
+	return &violations
+}
+
+func TestConsistentOrder(t *testing.T) {
+	violations := record(t)
+	a := &OrderedMutex{Name: "a"}
+	b := &OrderedMutex{Name: "b"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.Lock()
+				b.Lock()
+				b.Unlock()
+				a.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(*violations) != 0 {
+		t.Errorf("violations = %v; want none", *violations)
+	}
+}
+
+// TestInversion 两个 goroutine 先后以相反的顺序获取锁：
+// 实际上没有发生死锁，但检查器在第二次获取时就报告了
+func TestInversion(t *testing.T) {
+	violations := record(t)
+	a := &OrderedMutex{Name: "a"}
+	b := &OrderedRWMutex{Name: "b"}
+
+	done := make(chan struct{})
+	go func() {
+		a.Lock()
+		b.RLock()
+		b.RUnlock()
+		a.Unlock()
+		close(done)
+	}()
+	<-done
+
+	b.Lock()
+	a.Lock()
+	a.Unlock()
+	b.Unlock()
+
+	if len(*violations) != 1 {
+		t.Fatalf("got %d violations; want 1", len(*violations))
+	}
+	v := (*violations)[0]
+	if v.Acquiring != "a" || v.Held != "b" || len(v.Cycle) != 1 {
+		t.Errorf("violation = %+v; want acquiring a while holding b", v)
+	}
+	report := v.Error()
+	for _, want := range []string{"可能的死锁", "TestInversion.func1", "TestInversion\n"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not mention %q:\n%s", want, report)
+		}
+	}
+}
+
+// TestLongCycle a → b、b → c 之后出现 c → a
+func TestLongCycle(t *testing.T) {
+	violations := record(t)
+	a := &OrderedMutex{Name: "a"}
+	b := &OrderedMutex{Name: "b"}
+	c := &OrderedMutex{Name: "c"}
+	lockPair := func(x, y *OrderedMutex) {
+		x.Lock()
+		y.Lock()
+		y.Unlock()
+		x.Unlock()
+	}
+	lockPair(a, b)
+	lockPair(b, c)
+	if len(*violations) != 0 {
+		t.Fatalf("violations = %v; want none", *violations)
+	}
+	lockPair(c, a)
+	if len(*violations) != 1 || len((*violations)[0].Cycle) != 2 {
+		t.Fatalf("violations = %+v; want one cycle of length 3", *violations)
+	}
+}
+
+func TestRecursiveLock(t *testing.T) {
+	violations := record(t)
+	m := &OrderedRWMutex{Name: "m"}
+	m.RLock()
+	m.RLock()
+	m.RUnlock()
+	m.RUnlock()
+	if len(*violations) != 1 || (*violations)[0].Cycle != nil {
+		t.Errorf("violations = %+v; want one recursive lock", *violations)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	violations := record(t)
+	SetEnabled(false)
+	a := &OrderedMutex{}
+	b := &OrderedMutex{}
+	a.Lock()
+	b.Lock()
+	b.Unlock()
+	a.Unlock()
+	b.Lock()
+	a.Lock()
+	a.Unlock()
+	b.Unlock()
+	if len(*violations) != 0 {
+		t.Errorf("violations = %v; want none while disabled", *violations)
+	}
+}
@@ -0,0 +1,96 @@
+package lockorder
+
+import (
+	"fmt"
+	"sync"
+)
+
+// OrderedMutex 是检查获取顺序的 sync.Mutex，零值可用。
+type OrderedMutex struct {
+	// Name 出现在违例报告中，为空时使用锁的地址
+	Name string
+	mu   sync.Mutex
+}
+
+// Lock 获取锁，开启检查时先检查获取顺序。
+func (m *OrderedMutex) Lock() {
+	if !enabled.Load() {
+		m.mu.Lock()
+		return
+	}
+	global.before(m)
+	m.mu.Lock()
+	global.acquired(m)
+}
+
+// Unlock 释放锁。
+func (m *OrderedMutex) Unlock() {
+	if enabled.Load() {
+		global.released(m)
+	}
+	m.mu.Unlock()
+}
+
+// String 返回锁的名字。
+func (m *OrderedMutex) String() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return fmt.Sprintf("Mutex(%p)", m)
+}
+
+// OrderedRWMutex 是检查获取顺序的 sync.RWMutex，零值可用。
+//
+// 读锁和写锁使用同一个节点：读锁也可能与等待中的写锁形成死锁，
+// 同一个 goroutine 重复获取读锁同样会被报告。
+type OrderedRWMutex struct {
+	// Name 出现在违例报告中，为空时使用锁的地址
+	Name string
+	mu   sync.RWMutex
+}
+
+// Lock 获取写锁。
+func (m *OrderedRWMutex) Lock() {
+	if !enabled.Load() {
+		m.mu.Lock()
+		return
+	}
+	global.before(m)
+	m.mu.Lock()
+	global.acquired(m)
+}
+
+// Unlock 释放写锁。
+func (m *OrderedRWMutex) Unlock() {
+	if enabled.Load() {
+		global.released(m)
+	}
+	m.mu.Unlock()
+}
+
+// RLock 获取读锁。
+func (m *OrderedRWMutex) RLock() {
+	if !enabled.Load() {
+		m.mu.RLock()
+		return
+	}
+	global.before(m)
+	m.mu.RLock()
+	global.acquired(m)
+}
+
+// RUnlock 释放读锁。
+func (m *OrderedRWMutex) RUnlock() {
+	if enabled.Load() {
+		global.released(m)
+	}
+	m.mu.RUnlock()
+}
+
+// String 返回锁的名字。
+func (m *OrderedRWMutex) String() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return fmt.Sprintf("RWMutex(%p)", m)
+}
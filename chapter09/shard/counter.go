@@ -0,0 +1,57 @@
+package shard
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+// slot 独占一个缓存行
+type slot struct {
+	n atomic.Int64
+	_ [cacheLine - 8]byte
+}
+
+// ShardedCounter 是分片的计数器，多个 goroutine 并发 Add 时几乎没有争用。
+type ShardedCounter struct {
+	slots []slot
+	mask  uint32
+}
+
+// NewShardedCounter 创建计数器，槽的数量是不小于 GOMAXPROCS 的 2 的幂。
+func NewShardedCounter() *ShardedCounter {
+	n := ceilPow2(runtime.GOMAXPROCS(0))
+	return &ShardedCounter{slots: make([]slot, n), mask: uint32(n - 1)}
+}
+
+// Add 把计数加 delta。
+//
+// Go 没有提供获取当前 CPU 的方法，这里随机选择槽：
+// math/rand 的全局函数在 Go 1.20 之后不加锁，同时写同一个槽的概率约为 1/槽数。
+func (c *ShardedCounter) Add(delta int64) {
+	c.slots[rand.Uint32()&c.mask].n.Add(delta)
+}
+
+// Inc 把计数加 1。
+func (c *ShardedCounter) Inc() {
+	c.Add(1)
+}
+
+// Value 返回所有槽的和。与 Add 并发调用时结果介于调用前后的值之间，
+// 不是某一时刻的精确快照。
+func (c *ShardedCounter) Value() int64 {
+	var sum int64
+	for i := range c.slots {
+		sum += c.slots[i].n.Load()
+	}
+	return sum
+}
+
+// Reset 把计数清零并返回清零前的值，每个槽被原子地取走，不会丢失并发的 Add。
+func (c *ShardedCounter) Reset() int64 {
+	var sum int64
+	for i := range c.slots {
+		sum += c.slots[i].n.Swap(0)
+	}
+	return sum
+}
@@ -0,0 +1,43 @@
+// Package shard 提供分片的并发数据结构，把对同一内存位置的争用分散到多个分片上。
+//
+// ShardedCounter 为每个 P（GOMAXPROCS）准备一个独占缓存行的计数槽，
+// 写入时随机选择一个槽，读取时把所有槽相加；适合写多读少的统计计数。
+// StripedMap 把键按哈希分到 N 个各自带读写锁的 map 中，
+// 不同分片上的读写互不阻塞。
+//
+// 两者都用一致性换取吞吐量：ShardedCounter.Value 不是某一时刻的快照，
+// StripedMap.Len 和 Range 也不会同时锁住所有分片。
+package shard
+
+import "hash/maphash"
+
+// cacheLine 是假定的缓存行大小。相邻的槽如果落在同一缓存行，
+// 不同 CPU 的写入会互相使对方的缓存失效（伪共享），分片就失去了意义。
+const cacheLine = 64
+
+// ceilPow2 返回不小于 n 的最小的 2 的幂，分片数是 2 的幂时可以用位与代替取模
+func ceilPow2(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+var seed = maphash.MakeSeed()
+
+// HashString 是字符串键的哈希函数。
+func HashString(s string) uint64 {
+	return maphash.String(seed, s)
+}
+
+// HashInt 是整数键的哈希函数（splitmix64 的混合步骤），让相邻的整数分散到不同分片。
+func HashInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr](n T) uint64 {
+	x := uint64(n)
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,206 @@
+package shard
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestPadding(t *testing.T) {
+	if n := unsafe.Sizeof(slot{}); n != cacheLine {
+		t.Errorf("slot size = %d; want %d", n, cacheLine)
+	}
+	if n := unsafe.Sizeof(stripe[string, int]{}); n != cacheLine {
+		t.Errorf("stripe size = %d; want %d", n, cacheLine)
+	}
+}
+
+func TestShardedCounter(t *testing.T) {
+	c := NewShardedCounter()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc()
+			}
+			c.Add(-500)
+		}()
+	}
+	wg.Wait()
+	if v := c.Value(); v != 16*500 {
+		t.Errorf("Value() = %d; want %d", v, 16*500)
+	}
+	if v := c.Reset(); v != 16*500 || c.Value() != 0 {
+		t.Errorf("Reset() = %d, then Value() = %d; want %d, 0", v, c.Value(), 16*500)
+	}
+}
+
+func TestStripedMap(t *testing.T) {
+	m := NewStripedMap[string, int](8, HashString)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Update(fmt.Sprint("k", j%10), func(n int, _ bool) int { return n + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+	if m.Len() != 10 {
+		t.Errorf("Len() = %d; want 10", m.Len())
+	}
+	total := 0
+	m.Range(func(_ string, n int) bool {
+		total += n
+		return true
+	})
+	if total != 800 {
+		t.Errorf("sum of counts = %d; want 800", total)
+	}
+
+	if v, loaded := m.LoadOrStore("k0", -1); !loaded || v != 80 {
+		t.Errorf("LoadOrStore(k0) = %d, %t; want 80, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("new", 7); loaded || v != 7 {
+		t.Errorf("LoadOrStore(new) = %d, %t; want 7, false", v, loaded)
+	}
+	m.Delete("new")
+	if _, ok := m.Load("new"); ok {
+		t.Error("Load(new) found a deleted key")
+	}
+}
+
+func TestHashIntSpread(t *testing.T) {
+	m := NewStripedMap[int, int](16, HashInt[int])
+	for i := 0; i < 1600; i++ {
+		m.Store(i, i)
+	}
+	for i := range m.stripes {
+		if n := len(m.stripes[i].m); n < 50 || n > 150 {
+			t.Errorf("stripe %d has %d keys; want about 100", i, n)
+		}
+	}
+}
+
+// 基准测试：在不同的 goroutine 数量下比较各种计数器和 map。
+// 运行 go test -bench . -benchmem ./shard，结果名称中的 g=N 表示并发的 goroutine 数。
+
+var goroutineCounts = []int{1, 4, 16, 64}
+
+// runParallel 把 b.N 次操作平均分给 g 个 goroutine
+func runParallel(b *testing.B, g int, op func(i int)) {
+	var wg sync.WaitGroup
+	per := b.N / g
+	b.ResetTimer()
+	for w := 0; w < g; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < per; i++ {
+				op(w*per + i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func BenchmarkCounter(b *testing.B) {
+	for _, g := range goroutineCounts {
+		b.Run(fmt.Sprintf("Mutex/g=%d", g), func(b *testing.B) {
+			var mu sync.Mutex
+			var n int64
+			runParallel(b, g, func(int) {
+				mu.Lock()
+				n++
+				mu.Unlock()
+			})
+		})
+		b.Run(fmt.Sprintf("RWMutex/g=%d", g), func(b *testing.B) {
+			var mu sync.RWMutex
+			var n int64
+			runParallel(b, g, func(int) {
+				mu.Lock()
+				n++
+				mu.Unlock()
+			})
+		})
+		b.Run(fmt.Sprintf("Atomic/g=%d", g), func(b *testing.B) {
+			var n int64
+			runParallel(b, g, func(int) {
+				atomic.AddInt64(&n, 1)
+			})
+		})
+		b.Run(fmt.Sprintf("Sharded/g=%d", g), func(b *testing.B) {
+			c := NewShardedCounter()
+			runParallel(b, g, func(int) {
+				c.Inc()
+			})
+		})
+	}
+}
+
+// 读多写少：每 10 次操作中 1 次写、9 次读
+const benchMapKeys = 1024
+
+func BenchmarkMap(b *testing.B) {
+	for _, g := range goroutineCounts {
+		b.Run(fmt.Sprintf("Mutex/g=%d", g), func(b *testing.B) {
+			var mu sync.Mutex
+			m := make(map[int]int)
+			runParallel(b, g, func(i int) {
+				k := i % benchMapKeys
+				mu.Lock()
+				if i%10 == 0 {
+					m[k] = i
+				} else {
+					_ = m[k]
+				}
+				mu.Unlock()
+			})
+		})
+		b.Run(fmt.Sprintf("RWMutex/g=%d", g), func(b *testing.B) {
+			var mu sync.RWMutex
+			m := make(map[int]int)
+			runParallel(b, g, func(i int) {
+				k := i % benchMapKeys
+				if i%10 == 0 {
+					mu.Lock()
+					m[k] = i
+					mu.Unlock()
+				} else {
+					mu.RLock()
+					_ = m[k]
+					mu.RUnlock()
+				}
+			})
+		})
+		b.Run(fmt.Sprintf("SyncMap/g=%d", g), func(b *testing.B) {
+			var m sync.Map
+			runParallel(b, g, func(i int) {
+				k := i % benchMapKeys
+				if i%10 == 0 {
+					m.Store(k, i)
+				} else {
+					m.Load(k)
+				}
+			})
+		})
+		b.Run(fmt.Sprintf("Striped/g=%d", g), func(b *testing.B) {
+			m := NewStripedMap[int, int](0, HashInt[int])
+			runParallel(b, g, func(i int) {
+				k := i % benchMapKeys
+				if i%10 == 0 {
+					m.Store(k, i)
+				} else {
+					m.Load(k)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package shard
+
+import (
+	"runtime"
+	"sync"
+)
+
+// stripe 是 StripedMap 的一个分片，填充到缓存行边界，避免相邻分片的锁伪共享
+type stripe[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+	_  [cacheLine - 32]byte
+}
+
+// StripedMap 是按键哈希分片的并发 map，每个分片由自己的读写锁保护。
+type StripedMap[K comparable, V any] struct {
+	stripes []stripe[K, V]
+	mask    uint64
+	hash    func(K) uint64
+}
+
+// NewStripedMap 创建有 n 个分片（向上取整到 2 的幂）的 map，hash 用来把键分到分片，
+// 可以使用 HashString、HashInt 或自定义函数。n <= 0 时使用 4 倍的 GOMAXPROCS。
+func NewStripedMap[K comparable, V any](n int, hash func(K) uint64) *StripedMap[K, V] {
+	if n <= 0 {
+		n = 4 * runtime.GOMAXPROCS(0)
+	}
+	n = ceilPow2(n)
+	m := &StripedMap[K, V]{stripes: make([]stripe[K, V], n), mask: uint64(n - 1), hash: hash}
+	for i := range m.stripes {
+		m.stripes[i].m = make(map[K]V)
+	}
+	return m
+}
+
+func (m *StripedMap[K, V]) stripe(key K) *stripe[K, V] {
+	return &m.stripes[m.hash(key)&m.mask]
+}
+
+// Load 返回键对应的值以及键是否存在。
+func (m *StripedMap[K, V]) Load(key K) (V, bool) {
+	s := m.stripe(key)
+	s.mu.RLock()
+	v, ok := s.m[key]
+	s.mu.RUnlock()
+	return v, ok
+}
+
+// Store 设置键对应的值。
+func (m *StripedMap[K, V]) Store(key K, value V) {
+	s := m.stripe(key)
+	s.mu.Lock()
+	s.m[key] = value
+	s.mu.Unlock()
+}
+
+// LoadOrStore 键存在时返回已有的值和 true，否则存入 value 并返回它和 false。
+func (m *StripedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	s := m.stripe(key)
+	s.mu.RLock()
+	v, ok := s.m[key]
+	s.mu.RUnlock()
+	if ok {
+		return v, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok {
+		return v, true
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// Update 在分片的写锁内用 fn 计算键的新值，fn 收到旧值以及键是否存在。
+// 用于读-改-写，例如计数：m.Update(k, func(n int, _ bool) int { return n + 1 })。
+func (m *StripedMap[K, V]) Update(key K, fn func(old V, ok bool) V) V {
+	s := m.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.m[key]
+	v := fn(old, ok)
+	s.m[key] = v
+	return v
+}
+
+// Delete 删除键。
+func (m *StripedMap[K, V]) Delete(key K) {
+	s := m.stripe(key)
+	s.mu.Lock()
+	delete(s.m, key)
+	s.mu.Unlock()
+}
+
+// Len 返回元素个数，依次锁住每个分片计数。
+func (m *StripedMap[K, V]) Len() int {
+	n := 0
+	for i := range m.stripes {
+		s := &m.stripes[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Range 依次遍历每个分片，fn 返回 false 时停止。
+// 遍历某个分片时持有它的读锁，fn 不能修改同一个 map。
+func (m *StripedMap[K, V]) Range(fn func(key K, value V) bool) {
+	for i := range m.stripes {
+		s := &m.stripes[i]
+		s.mu.RLock()
+		for k, v := range s.m {
+			if !fn(k, v) {
+				s.mu.RUnlock()
+				return
+			}
+		}
+		s.mu.RUnlock()
+	}
+}
@@ -0,0 +1,108 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBrokenBarrier 表示屏障在等待过程中被打破：某个参与者的 context 结束了，或者调用了 Reset。
+var ErrBrokenBarrier = errors.New("syncx: 屏障已被打破")
+
+// Barrier 是可重复使用的循环屏障：parties 个参与者都调用 Wait 之后，
+// 所有人一起返回，屏障自动进入下一代，可以用于分阶段的并行计算。
+type Barrier struct {
+	parties int
+	action  func()
+
+	mu    sync.Mutex
+	count int
+	gen   *generation
+}
+
+// generation 是屏障的一代，done 在这一代结束（全部到达或被打破）时关闭
+type generation struct {
+	done   chan struct{}
+	broken bool
+}
+
+// NewBarrier 创建需要 parties 个参与者的屏障。action 不为 nil 时，
+// 由最后一个到达的参与者在放行其他参与者之前执行，可以用来合并本阶段的结果；
+// action 中不能调用同一屏障的方法。
+func NewBarrier(parties int, action func()) *Barrier {
+	if parties <= 0 {
+		panic("syncx: 屏障的参与者数量必须为正")
+	}
+	return &Barrier{parties: parties, action: action, gen: newGeneration()}
+}
+
+func newGeneration() *generation {
+	return &generation{done: make(chan struct{})}
+}
+
+// Wait 等待所有参与者到达，返回到达序号：第一个到达的是 parties-1，最后一个是 0。
+// ctx 在等待期间结束时，这一代屏障被打破：调用方得到 ctx.Err()，
+// 其他正在等待的参与者得到 ErrBrokenBarrier，之后的 Wait 从新的一代开始。
+func (b *Barrier) Wait(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	g := b.gen
+	if err := ctx.Err(); err != nil {
+		b.mu.Unlock()
+		return 0, err
+	}
+	b.count++
+	index := b.parties - b.count
+	if index == 0 {
+		if b.action != nil {
+			b.action()
+		}
+		b.next(false)
+		b.mu.Unlock()
+		return 0, nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-g.done:
+		if g.broken {
+			return index, ErrBrokenBarrier
+		}
+		return index, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.gen != g {
+			// 取消的同时这一代已经结束
+			if g.broken {
+				return index, ErrBrokenBarrier
+			}
+			return index, nil
+		}
+		b.next(true)
+		return index, ctx.Err()
+	}
+}
+
+// Reset 打破当前这一代（正在等待的参与者得到 ErrBrokenBarrier），让屏障回到初始状态。
+func (b *Barrier) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.count > 0 {
+		b.next(true)
+	}
+}
+
+// Waiting 返回当前这一代中正在等待的参与者数量。
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// next 结束当前这一代并开始新的一代，调用方必须持有 b.mu
+func (b *Barrier) next(broken bool) {
+	b.gen.broken = broken
+	close(b.gen.done)
+	b.gen = newGeneration()
+	b.count = 0
+}
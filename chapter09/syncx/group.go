@@ -0,0 +1,84 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+)
+
+// Group 并发执行一组任务：最多同时运行 limit 个，
+// 第一个返回错误的任务取消共享的 context，Wait 等待所有任务结束并返回这个错误。
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	sem    chan struct{} // 为 nil 时不限制并发数量
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// WithGroup 创建一个任务组，返回的 ctx 在第一个任务失败、parent 结束或 Wait 返回后被取消。
+// limit <= 0 表示不限制并发数量。
+func WithGroup(parent context.Context, limit int) (context.Context, *Group) {
+	ctx, cancel := context.WithCancelCause(parent)
+	g := &Group{ctx: ctx, cancel: cancel}
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+	return ctx, g
+}
+
+// Go 启动一个任务。正在运行的任务达到上限时 Go 阻塞，直到有任务结束；
+// 等待期间 ctx 被取消时不再启动 f，ctx 的错误会由 Wait 返回（如果还没有更早的错误）。
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.fail(context.Cause(g.ctx))
+			return
+		}
+	}
+	g.start(f)
+}
+
+// TryGo 在没有达到并发上限时启动 f 并返回 true，否则返回 false，不等待。
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+func (g *Group) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		if err := f(); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// fail 记录第一个错误并取消 ctx
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
+
+// Wait 等待所有已经启动的任务结束，返回第一个错误。
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
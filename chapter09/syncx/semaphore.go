@@ -0,0 +1,122 @@
+// Package syncx 提供标准库 sync 包之外常用的同步原语：
+//
+//   - Semaphore：带权重的信号量，Acquire 可以被 context 取消；
+//   - Barrier：可重复使用的循环屏障，所有参与者到达后一起继续；
+//   - Group：限制并发数量的任务组，第一个错误取消共享的 context，Wait 返回该错误。
+package syncx
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTooLarge 表示请求的权重超过了信号量的容量，永远无法满足。
+var ErrTooLarge = errors.New("syncx: 请求超过信号量容量")
+
+// Semaphore 是带权重的信号量，容量在创建时确定。
+// 等待者按先来先到的顺序获得资源：队首的大请求不会被后来的小请求饿死。
+type Semaphore struct {
+	size int64
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // 获得资源后关闭
+}
+
+// NewSemaphore 创建容量为 n 的信号量。
+func NewSemaphore(n int64) *Semaphore {
+	if n < 0 {
+		panic("syncx: 信号量容量不能为负")
+	}
+	return &Semaphore{size: n}
+}
+
+// Acquire 获取权重为 n 的资源，资源不足时阻塞，直到获得资源或者 ctx 结束。
+// 成功时返回 nil；失败时返回 ctx.Err() 或 ErrTooLarge，不占用任何资源。
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return fmt.Errorf("%w: %d > %d", ErrTooLarge, n, s.size)
+	}
+	s.mu.Lock()
+	if s.cur+n <= s.size && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// 取消的同时获得了资源，按成功处理，调用方会 Release
+			s.mu.Unlock()
+			return nil
+		default:
+		}
+		isFront := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		// 队首的等待者离开后，后面较小的请求可能已经可以满足
+		if isFront {
+			s.notifyWaiters()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 不等待地获取权重为 n 的资源，成功时返回 true。
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cur+n <= s.size && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release 释放权重为 n 的资源。释放的比持有的多时 panic。
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("syncx: 释放的资源多于持有的资源")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters 按顺序唤醒能够满足的等待者，调用方必须持有 s.mu
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if s.cur+w.n > s.size {
+			return // 队首不能满足时停下，保证先来先到
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,224 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tracker 记录同时进行的操作数量的最大值
+type tracker struct {
+	cur, max atomic.Int64
+}
+
+func (t *tracker) enter(n int64) {
+	cur := t.cur.Add(n)
+	for {
+		m := t.max.Load()
+		if cur <= m || t.max.CompareAndSwap(m, cur) {
+			return
+		}
+	}
+}
+
+func (t *tracker) leave(n int64) { t.cur.Add(-n) }
+
+func TestSemaphoreLimit(t *testing.T) {
+	const size = 5
+	s := NewSemaphore(size)
+	var tr tracker
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(n int64) {
+			defer wg.Done()
+			if err := s.Acquire(context.Background(), n); err != nil {
+				t.Error(err)
+				return
+			}
+			tr.enter(n)
+			time.Sleep(time.Millisecond)
+			tr.leave(n)
+			s.Release(n)
+		}(int64(i%3 + 1))
+	}
+	wg.Wait()
+	if m := tr.max.Load(); m > size || m == 0 {
+		t.Errorf("max weight held = %d; want 1..%d", m, size)
+	}
+	if !s.TryAcquire(size) {
+		t.Error("TryAcquire(size) failed after everything was released")
+	}
+	if err := s.Acquire(context.Background(), size+1); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Acquire(size+1) = %v; want ErrTooLarge", err)
+	}
+}
+
+// TestSemaphoreFIFO 队首等待大请求时，后来的小请求不能插队；队首取消后小请求立即获得资源
+func TestSemaphoreFIFO(t *testing.T) {
+	s := NewSemaphore(3)
+	s.Acquire(context.Background(), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bigErr := make(chan error)
+	go func() { bigErr <- s.Acquire(ctx, 3) }()
+	waitFor(t, func() bool { return waiters(s) == 1 })
+
+	if s.TryAcquire(1) {
+		t.Fatal("TryAcquire(1) jumped ahead of a queued waiter")
+	}
+	small := make(chan error)
+	go func() { small <- s.Acquire(context.Background(), 1) }()
+	waitFor(t, func() bool { return waiters(s) == 2 })
+
+	cancel()
+	if err := <-bigErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Acquire = %v; want Canceled", err)
+	}
+	if err := <-small; err != nil {
+		t.Errorf("small Acquire = %v after the front waiter left", err)
+	}
+	s.Release(3)
+	if !s.TryAcquire(3) {
+		t.Error("semaphore leaked capacity")
+	}
+}
+
+func waiters(s *Semaphore) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestBarrierPhases 每一阶段所有参与者写完各自的格子后，action 检查整行，然后一起进入下一阶段
+func TestBarrierPhases(t *testing.T) {
+	const parties, phases = 8, 50
+	var grid [phases][parties]int
+	phase := 0
+	b := NewBarrier(parties, func() {
+		for p, v := range grid[phase] {
+			if v != phase+1 {
+				t.Errorf("phase %d: party %d wrote %d before the barrier", phase, p, v)
+			}
+		}
+		phase++
+	})
+	var wg sync.WaitGroup
+	var last atomic.Int32
+	for p := 0; p < parties; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < phases; i++ {
+				grid[i][p] = i + 1
+				index, err := b.Wait(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if index == 0 {
+					last.Add(1)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	if phase != phases || last.Load() != phases {
+		t.Errorf("completed %d phases with %d last arrivals; want %d", phase, last.Load(), phases)
+	}
+}
+
+func TestBarrierBroken(t *testing.T) {
+	b := NewBarrier(3, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		_, err := b.Wait(context.Background())
+		errs <- err
+	}()
+	go func() {
+		_, err := b.Wait(ctx)
+		errs <- err
+	}()
+	waitFor(t, func() bool { return b.Waiting() == 2 })
+	cancel()
+	got := []error{<-errs, <-errs}
+	if !(errors.Is(got[0], ErrBrokenBarrier) && errors.Is(got[1], context.Canceled) ||
+		errors.Is(got[1], ErrBrokenBarrier) && errors.Is(got[0], context.Canceled)) {
+		t.Errorf("errors = %v; want one ErrBrokenBarrier and one Canceled", got)
+	}
+
+	// 打破之后屏障从新的一代开始，可以继续使用
+	if b.Waiting() != 0 {
+		t.Fatalf("Waiting() = %d after break; want 0", b.Waiting())
+	}
+	go func() {
+		_, err := b.Wait(context.Background())
+		errs <- err
+	}()
+	waitFor(t, func() bool { return b.Waiting() == 1 })
+	b.Reset()
+	if err := <-errs; !errors.Is(err, ErrBrokenBarrier) {
+		t.Errorf("Wait during Reset = %v; want ErrBrokenBarrier", err)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	ctx, g := WithGroup(context.Background(), 3)
+	var tr tracker
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			tr.enter(1)
+			defer tr.leave(1)
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if m := tr.max.Load(); m > 3 {
+		t.Errorf("max concurrent tasks = %d; want <= 3", m)
+	}
+	if ctx.Err() == nil {
+		t.Error("ctx not cancelled after Wait")
+	}
+}
+
+func TestGroupFirstError(t *testing.T) {
+	ctx, g := WithGroup(context.Background(), 2)
+	boom := errors.New("boom")
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-ctx.Done() // 等待另一个任务失败
+		return ctx.Err()
+	})
+	g.Go(func() error {
+		<-release
+		return boom
+	})
+	if g.TryGo(func() error { return nil }) {
+		t.Error("TryGo succeeded at the concurrency limit")
+	}
+	close(release)
+	if err := g.Wait(); !errors.Is(err, boom) {
+		t.Errorf("Wait() = %v; want boom", err)
+	}
+	if !errors.Is(context.Cause(ctx), boom) {
+		t.Errorf("Cause(ctx) = %v; want boom", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,39 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic 先写临时文件并 fsync，再重命名覆盖目标文件，
+// 因此崩溃后目标文件要么是旧内容，要么是完整的新内容。
+// 与日志配合保存快照：快照写好后才能清空日志。
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// fsync 目录，确保重命名本身也已持久化
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
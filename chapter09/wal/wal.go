@@ -0,0 +1,178 @@
+// Package wal 实现一个基于文件的预写日志（write-ahead log）。
+//
+// 每条记录的格式为：
+//
+//	+-----------+-----------+---------+
+//	| 长度 (4B) | CRC (4B)  | 数据    |
+//	+-----------+-----------+---------+
+//
+// 长度和校验和均为小端序，校验和使用 CRC-32C 计算数据部分。
+// Append 在返回前调用 fsync，因此返回 nil 的记录在崩溃后一定能读回。
+// 打开日志时会从头校验全部记录，并截断末尾不完整或校验失败的部分（写到一半时崩溃留下的“撕裂尾部”）。
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// headerSize 每条记录头部的字节数
+const headerSize = 8
+
+// MaxRecordSize 单条记录允许的最大字节数，也用于识别损坏的长度字段
+const MaxRecordSize = 16 << 20
+
+// ErrRecordTooLarge 表示记录超过 MaxRecordSize
+var ErrRecordTooLarge = errors.New("wal: 记录过大")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Log 是一个只追加的日志文件，可以被多个 goroutine 并发使用。
+type Log struct {
+	mu        sync.Mutex
+	f         *os.File
+	size      int64 // 有效数据的长度，也是下一条记录的写入位置
+	truncated int64 // 打开时截掉的字节数
+}
+
+// Open 打开或创建 path 处的日志，校验已有记录并截断撕裂的尾部。
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	valid, err := scan(f, func([]byte) error { return nil })
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if valid < info.Size() {
+		if err := f.Truncate(valid); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return &Log{f: f, size: valid, truncated: info.Size() - valid}, nil
+}
+
+// Append 追加一条记录，并在数据落盘后返回。
+func (l *Log) Append(record []byte) error {
+	if len(record) > MaxRecordSize {
+		return fmt.Errorf("%w: %d 字节", ErrRecordTooLarge, len(record))
+	}
+	frame := make([]byte, headerSize+len(record))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(record)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.Checksum(record, castagnoli))
+	copy(frame[headerSize:], record)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.WriteAt(frame, l.size); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.size += int64(len(frame))
+	return nil
+}
+
+// Replay 按写入顺序对每条记录调用 fn，fn 返回错误时停止并返回该错误。
+// fn 收到的切片只在本次调用期间有效。
+func (l *Log) Replay(fn func(record []byte) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := scan(io.NewSectionReader(l.f, 0, l.size), fn)
+	return err
+}
+
+// Reset 清空日志，通常在写入快照之后调用。
+func (l *Log) Reset() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.size = 0
+	return nil
+}
+
+// Size 返回日志中有效数据的字节数。
+func (l *Log) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Truncated 返回打开日志时截掉的撕裂尾部的字节数。
+func (l *Log) Truncated() int64 {
+	return l.truncated
+}
+
+// Close 关闭日志文件。
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// scan 从 r 的开头依次读取记录并调用 fn，返回最后一条完整有效记录的结束位置。
+// 遇到不完整或校验失败的记录时停止，这不被视为错误。
+func scan(r io.Reader, fn func([]byte) error) (int64, error) {
+	br := bufio.NewReader(r)
+	var (
+		offset int64
+		header [headerSize]byte
+		buf    []byte
+	)
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return offset, ignoreEOF(err)
+		}
+		n := binary.LittleEndian.Uint32(header[0:4])
+		if n > MaxRecordSize {
+			return offset, nil
+		}
+		if cap(buf) < int(n) {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return offset, ignoreEOF(err)
+		}
+		if crc32.Checksum(buf, castagnoli) != binary.LittleEndian.Uint32(header[4:8]) {
+			return offset, nil
+		}
+		if err := fn(buf); err != nil {
+			return offset, err
+		}
+		offset += headerSize + int64(n)
+	}
+}
+
+// ignoreEOF 把读到文件末尾（包括读到一半）视为正常结束
+func ignoreEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	return err
+}
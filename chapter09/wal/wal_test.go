@@ -0,0 +1,157 @@
+package wal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readAll(t *testing.T, l *Log) []string {
+	t.Helper()
+	var records []string
+	if err := l.Replay(func(rec []byte) error {
+		records = append(records, string(rec))
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	return records
+}
+
+func TestAppendReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.wal")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first", "", "third record"}
+	for _, rec := range want {
+		if err := l.Append([]byte(rec)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := readAll(t, l); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q; want %q", got, want)
+	}
+	if l.Truncated() != 0 {
+		t.Errorf("Truncated() = %d; want 0", l.Truncated())
+	}
+
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, l); len(got) != 0 {
+		t.Errorf("records after Reset = %q; want none", got)
+	}
+}
+
+// TestTornTail 在任意位置截断日志文件，重新打开后只保留完整的记录，
+// 并且可以继续追加
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "full.wal")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []string
+	var ends []int64 // 每条记录结束时的文件大小
+	for i := 0; i < 5; i++ {
+		rec := fmt.Sprintf("record-%d", i)
+		records = append(records, rec)
+		if err := l.Append([]byte(rec)); err != nil {
+			t.Fatal(err)
+		}
+		ends = append(ends, l.Size())
+	}
+	l.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cut := 0; cut <= len(data); cut++ {
+		torn := filepath.Join(dir, fmt.Sprintf("torn-%d.wal", cut))
+		if err := os.WriteFile(torn, data[:cut], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		l, err := Open(torn)
+		if err != nil {
+			t.Fatalf("cut %d: Open returned error: %v", cut, err)
+		}
+
+		complete := 0
+		for complete < len(ends) && ends[complete] <= int64(cut) {
+			complete++
+		}
+		got := readAll(t, l)
+		if len(got) != complete || complete > 0 && !reflect.DeepEqual(got, records[:complete]) {
+			t.Fatalf("cut %d: records = %q; want %q", cut, got, records[:complete])
+		}
+
+		if err := l.Append([]byte("after")); err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, l); got[len(got)-1] != "after" {
+			t.Fatalf("cut %d: last record = %q; want after", cut, got[len(got)-1])
+		}
+		l.Close()
+	}
+}
+
+func TestCorruptChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corrupt.wal")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append([]byte("good"))
+	l.Append([]byte("bad"))
+	l.Close()
+
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := readAll(t, l); !reflect.DeepEqual(got, []string{"good"}) {
+		t.Errorf("records = %q; want [good]", got)
+	}
+	if l.Truncated() != headerSize+3 {
+		t.Errorf("Truncated() = %d; want %d", l.Truncated(), headerSize+3)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshot.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Fatalf("ReadFile = %q, %v; want %q", data, err, content)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files left in dir; want only the target file", len(entries))
+	}
+}
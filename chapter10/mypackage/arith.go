@@ -0,0 +1,192 @@
+package mypackage
+
+import (
+	"math"
+	"math/bits"
+)
+
+// arith 描述一组整数运算的取值范围和溢出处理方式。
+//
+// 所有运算都先在原生 int 范围内精确计算（借助 math/bits 检测溢出），
+// 再检查结果是否落在 [min, max] 之内，因此任意边界都共用同一套逻辑。
+type arith struct {
+	min, max int
+	saturate bool
+}
+
+// defaultArith 包级别函数使用的默认范围，与 MinInt/MaxInt 保持一致
+var defaultArith = arith{min: MinInt, max: MaxInt}
+
+// nativeArith 覆盖原生 int 的全部范围
+var nativeArith = arith{min: math.MinInt, max: math.MaxInt}
+
+func (r arith) add(a, b int) (int, error) {
+	if err := r.checkArgs(a, b); err != nil {
+		return 0, err
+	}
+	sum := a + b
+	// 同号相加且结果符号改变说明发生了回绕
+	if (a >= 0) == (b >= 0) && (sum >= 0) != (a >= 0) {
+		return r.overflow(a >= 0)
+	}
+	return r.result(sum)
+}
+
+func (r arith) subtract(a, b int) (int, error) {
+	if err := r.checkArgs(a, b); err != nil {
+		return 0, err
+	}
+	diff := a - b
+	// 异号相减且结果符号与被减数不同说明发生了回绕
+	if (a >= 0) != (b >= 0) && (diff >= 0) != (a >= 0) {
+		return r.overflow(a >= 0)
+	}
+	return r.result(diff)
+}
+
+func (r arith) multiply(a, b int) (int, error) {
+	if err := r.checkArgs(a, b); err != nil {
+		return 0, err
+	}
+	product, ok := mulNative(a, b)
+	if !ok {
+		return r.overflow((a < 0) == (b < 0))
+	}
+	return r.result(product)
+}
+
+func (r arith) divide(a, b int) (int, error) {
+	if err := r.checkArgs(a, b); err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	// math.MinInt / -1 是唯一会回绕的情况
+	if a == math.MinInt && b == -1 {
+		return r.overflow(true)
+	}
+	return r.result(a / b)
+}
+
+func (r arith) mod(a, b int) (int, error) {
+	if err := r.checkArgs(a, b); err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	return r.result(a % b)
+}
+
+func (r arith) pow(a, b int) (int, error) {
+	if err := r.checkArgs(a, b); err != nil {
+		return 0, err
+	}
+	if b < 0 {
+		return 0, ErrOutOfRange{Arg: "b", Value: b}
+	}
+	positive := a >= 0 || b%2 == 0
+
+	// 快速幂。中间结果只检查原生溢出：边界不对称时 base*base 可能越界而最终结果不越界，
+	// 例如 [-1000, 50] 中的 (-10)^3。a 不为 0 时中间结果的绝对值不会超过最终结果，
+	// 所以中间结果溢出原生 int 时最终结果也一定溢出，范围只需要在最后检查一次
+	result, base := 1, a
+	for exp := b; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			next, ok := mulNative(result, base)
+			if !ok {
+				return r.overflow(positive)
+			}
+			result = next
+		}
+		if exp > 1 {
+			next, ok := mulNative(base, base)
+			if !ok {
+				return r.overflow(positive)
+			}
+			base = next
+		}
+	}
+	return r.result(result)
+}
+
+// inRange 判断 v 是否在 [min, max] 之内。
+func (r arith) inRange(v int) bool {
+	return v >= r.min && v <= r.max
+}
+
+// checkArgs 校验两个操作数，返回第一个越界参数对应的错误。
+func (r arith) checkArgs(a, b int) error {
+	if !r.inRange(a) {
+		return ErrOutOfRange{Arg: "a", Value: a}
+	}
+	if !r.inRange(b) {
+		return ErrOutOfRange{Arg: "b", Value: b}
+	}
+	return nil
+}
+
+// result 检查计算结果是否越界，并记录一次成功的操作。
+func (r arith) result(v int) (int, error) {
+	if v > r.max {
+		return r.overflow(true)
+	}
+	if v < r.min {
+		return r.overflow(false)
+	}
+	operations++
+	return v, nil
+}
+
+// overflow 根据溢出方式返回饱和值或 ErrOverflow，positive 表示真实结果的符号。
+func (r arith) overflow(positive bool) (int, error) {
+	if !r.saturate {
+		return 0, ErrOverflow
+	}
+	operations++
+	if positive {
+		return r.max, nil
+	}
+	return r.min, nil
+}
+
+// mulNative 计算 a * b，结果超出原生 int 范围时 ok 为 false。
+func mulNative(a, b int) (int, bool) {
+	negative := (a < 0) != (b < 0)
+	hi, lo := bits.Mul(magnitude(a), magnitude(b))
+	if hi != 0 {
+		return 0, false
+	}
+	if negative {
+		// 负数比正数多一个可表示的值：-(MaxInt+1) == MinInt
+		if lo > uint(math.MaxInt)+1 {
+			return 0, false
+		}
+		return -int(lo), true
+	}
+	if lo > uint(math.MaxInt) {
+		return 0, false
+	}
+	return int(lo), true
+}
+
+// magnitude 返回 v 的绝对值，math.MinInt 也能正确表示。
+func magnitude(v int) uint {
+	if v < 0 {
+		return -uint(v)
+	}
+	return uint(v)
+}
+
+// table 返回一张绑定到当前范围的内置操作表。
+func (r arith) table() map[string]Operation {
+	return map[string]Operation{
+		"add":      r.add,
+		"subtract": r.subtract,
+		"multiply": r.multiply,
+		"divide":   r.divide,
+		"mod":      r.mod,
+		"pow":      r.pow,
+	}
+}
@@ -0,0 +1,231 @@
+package mypackage
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode 表示十进制运算结果的舍入方式。
+type RoundingMode int
+
+const (
+	// RoundHalfEven 四舍六入五成双（银行家舍入），计算器的默认模式
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp 四舍五入，恰好一半时远离零舍入
+	RoundHalfUp
+	// RoundTruncate 直接截断多余的位数（向零舍入）
+	RoundTruncate
+)
+
+// String 返回舍入模式的名称。
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfEven:
+		return "half-even"
+	case RoundHalfUp:
+		return "half-up"
+	case RoundTruncate:
+		return "truncate"
+	default:
+		return fmt.Sprintf("RoundingMode(%d)", int(m))
+	}
+}
+
+// Decimal 是一个不可变的十进制定点数，值为 unscaled × 10^(-scale)。
+//
+// Decimal 基于 math/big 实现，不存在二进制浮点数的表示误差，
+// 适合需要精确到分的金额计算。零值表示 0。
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal 创建一个值为 unscaled × 10^(-scale) 的十进制数。
+//
+// 参数：
+//   unscaled - 去掉小数点后的整数值
+//   scale - 小数位数，必须大于等于 0
+//
+// 示例：
+//    d := mypackage.NewDecimal(1234, 2) // 12.34
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < 0 {
+		panic("mypackage: Decimal 的小数位数不能为负数")
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal 解析形如 "-12.345" 的十进制字符串。
+//
+// 参数：
+//   s - 可选符号、整数部分和可选小数部分组成的字符串
+//
+// 返回值：
+//   Decimal - 解析结果，小数位数与输入一致
+//   error - 格式不正确时返回错误
+func ParseDecimal(s string) (Decimal, error) {
+	text := s
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+	intPart, fracPart, hasPoint := strings.Cut(text, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) || hasPoint && fracPart == "" {
+		return Decimal{}, fmt.Errorf("无效的十进制数: %q", s)
+	}
+	unscaled, ok := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("无效的十进制数: %q", s)
+	}
+	return Decimal{unscaled: unscaled, scale: len(fracPart)}, nil
+}
+
+// Scale 返回小数位数。
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Rat 以有理数的形式返回 d 的精确值。
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.int())
+	return r.Quo(r, new(big.Rat).SetInt(pow10(d.scale)))
+}
+
+// Cmp 比较 d 和 other 的数值大小，返回 -1、0 或 +1。
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+// Round 将 d 按指定的舍入模式舍入到 scale 位小数。scale 为负数时 panic。
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	if scale < 0 {
+		panic("mypackage: Decimal 的小数位数不能为负数")
+	}
+	return roundRat(d.Rat(), scale, mode)
+}
+
+// String 返回 d 的十进制表示，保留全部小数位，例如 "-0.50"。
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	if d.scale > 0 {
+		point := len(digits) - d.scale
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.int().Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// int 返回未缩放的整数值，零值 Decimal 返回 0。
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// decimalOperations 十进制模式下支持的操作，结果为精确的有理数
+var decimalOperations = map[string]func(a, b *big.Rat) (*big.Rat, error){
+	"add": func(a, b *big.Rat) (*big.Rat, error) {
+		return new(big.Rat).Add(a, b), nil
+	},
+	"subtract": func(a, b *big.Rat) (*big.Rat, error) {
+		return new(big.Rat).Sub(a, b), nil
+	},
+	"multiply": func(a, b *big.Rat) (*big.Rat, error) {
+		return new(big.Rat).Mul(a, b), nil
+	},
+	"divide": func(a, b *big.Rat) (*big.Rat, error) {
+		if b.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return new(big.Rat).Quo(a, b), nil
+	},
+}
+
+// SetRoundingMode 设置十进制模式下的舍入方式。
+//
+// 参数：
+//   mode - 新的舍入模式
+func (c *Calculator) SetRoundingMode(mode RoundingMode) {
+	c.rounding = mode
+}
+
+// GetRoundingMode 获取十进制模式下的舍入方式。
+//
+// 返回值：
+//   RoundingMode - 当前舍入模式
+func (c *Calculator) GetRoundingMode() RoundingMode {
+	return c.rounding
+}
+
+// CalculateDecimal 以十进制模式执行计算操作。
+//
+// 运算先得到精确结果，再按计算器的精度（小数位数）和舍入模式舍入一次，
+// 因此相同的输入总能得到相同的结果。
+//
+// 参数：
+//   a - 第一个操作数
+//   b - 第二个操作数
+//   operation - 操作类型 ("add"、"subtract"、"multiply" 或 "divide")
+//
+// 返回值：
+//   Decimal - 小数位数等于计算器精度的计算结果
+//   error - 精度为负数时返回 ErrOutOfRange，操作不支持时返回包装了
+//           ErrUnsupportedOp 的错误，除数为零时返回 ErrDivisionByZero
+//
+// 示例：
+//    calc := mypackage.NewCalculator("billing")
+//    a, _ := mypackage.ParseDecimal("10")
+//    b, _ := mypackage.ParseDecimal("3")
+//    result, _ := calc.CalculateDecimal(a, b, "divide")
+//    fmt.Println(result) // 输出: 3.33
+func (c *Calculator) CalculateDecimal(a, b Decimal, operation string) (Decimal, error) {
+	if c.precision < 0 {
+		return Decimal{}, ErrOutOfRange{Arg: "precision", Value: c.precision}
+	}
+	op, ok := decimalOperations[operation]
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrUnsupportedOp, operation)
+	}
+	exact, err := op(a.Rat(), b.Rat())
+	if err != nil {
+		return Decimal{}, err
+	}
+	return roundRat(exact, c.precision, c.rounding), nil
+}
+
+// roundRat 将有理数 r 舍入到 scale 位小数。
+func roundRat(r *big.Rat, scale int, mode RoundingMode) Decimal {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if rem.Sign() != 0 && mode != RoundTruncate {
+		// 比较 2*|rem| 与分母，判断舍去部分是否超过一半
+		twice := new(big.Int).Lsh(new(big.Int).Abs(rem), 1)
+		cmp := twice.Cmp(scaled.Denom())
+		if cmp > 0 || cmp == 0 && (mode == RoundHalfUp || quo.Bit(0) == 1) {
+			quo.Add(quo, big.NewInt(int64(scaled.Sign())))
+		}
+	}
+	return Decimal{unscaled: quo, scale: scale}
+}
+
+// pow10 返回 10 的 n 次幂。
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// isDigits 判断字符串是否只包含十进制数字，空字符串返回 true。
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package mypackage
+
+import (
+	"errors"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q) returned error: %v", s, err)
+	}
+	return d
+}
+
+// TestParseDecimal 测试十进制数的解析和格式化
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0", "0"},
+		{"12.34", "12.34"},
+		{"-0.05", "-0.05"},
+		{"+7", "7"},
+		{".5", "0.5"},
+		{"100.000", "100.000"},
+	}
+
+	for _, test := range tests {
+		if result := mustParse(t, test.input).String(); result != test.expected {
+			t.Errorf("ParseDecimal(%q).String() = %q; want %q", test.input, result, test.expected)
+		}
+	}
+
+	for _, input := range []string{"", "-", "1.", "1.2.3", "abc", "1e5", "--1"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Errorf("ParseDecimal(%q) should return error", input)
+		}
+	}
+}
+
+// TestDecimalRounding 测试各种舍入模式
+func TestDecimalRounding(t *testing.T) {
+	tests := []struct {
+		input    string
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.675", RoundHalfEven, "2.68"},
+		{"2.665", RoundHalfEven, "2.66"},
+		{"-2.665", RoundHalfEven, "-2.66"},
+		{"2.665", RoundHalfUp, "2.67"},
+		{"-2.665", RoundHalfUp, "-2.67"},
+		{"2.669", RoundTruncate, "2.66"},
+		{"-2.669", RoundTruncate, "-2.66"},
+		{"2.6649", RoundHalfUp, "2.66"},
+		{"2.6", RoundHalfEven, "2.60"},
+	}
+
+	for _, test := range tests {
+		result := mustParse(t, test.input).Round(2, test.mode).String()
+		if result != test.expected {
+			t.Errorf("Round(%s, %s) = %s; want %s", test.input, test.mode, result, test.expected)
+		}
+	}
+}
+
+// TestDecimalRoundNegativeScale 负的小数位数与 NewDecimal 一样被拒绝
+func TestDecimalRoundNegativeScale(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Round(-1) did not panic")
+		}
+	}()
+	mustParse(t, "12.5").Round(-1, RoundHalfUp)
+}
+
+// TestCalculateDecimal 测试计算器的十进制模式
+func TestCalculateDecimal(t *testing.T) {
+	calc := NewCalculator("billing")
+
+	tests := []struct {
+		a, b      string
+		operation string
+		expected  string
+	}{
+		{"0.1", "0.2", "add", "0.30"},
+		{"10", "3", "divide", "3.33"},
+		{"-10", "3", "divide", "-3.33"},
+		{"19.99", "3", "multiply", "59.97"},
+		{"1.005", "0", "subtract", "1.00"},
+	}
+
+	for _, test := range tests {
+		result, err := calc.CalculateDecimal(mustParse(t, test.a), mustParse(t, test.b), test.operation)
+		if err != nil {
+			t.Fatalf("CalculateDecimal(%s, %s, %s) returned error: %v", test.a, test.b, test.operation, err)
+		}
+		if result.String() != test.expected {
+			t.Errorf("CalculateDecimal(%s, %s, %s) = %s; want %s", test.a, test.b, test.operation, result, test.expected)
+		}
+	}
+
+	// 精度和舍入模式都会影响结果
+	calc.SetPrecision(4)
+	calc.SetRoundingMode(RoundTruncate)
+	result, _ := calc.CalculateDecimal(mustParse(t, "2"), mustParse(t, "3"), "divide")
+	if result.String() != "0.6666" {
+		t.Errorf("2 / 3 with precision 4 and truncate = %s; want 0.6666", result)
+	}
+
+	if _, err := calc.CalculateDecimal(mustParse(t, "1"), Decimal{}, "divide"); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("divide by zero err = %v; want ErrDivisionByZero", err)
+	}
+	if _, err := calc.CalculateDecimal(Decimal{}, Decimal{}, "pow"); !errors.Is(err, ErrUnsupportedOp) {
+		t.Errorf("pow err = %v; want ErrUnsupportedOp", err)
+	}
+	calc.SetPrecision(-1)
+	var rangeErr ErrOutOfRange
+	if _, err := calc.CalculateDecimal(Decimal{}, Decimal{}, "add"); !errors.As(err, &rangeErr) || rangeErr.Arg != "precision" {
+		t.Errorf("negative precision err = %v; want ErrOutOfRange{precision}", err)
+	}
+}
@@ -0,0 +1,36 @@
+package mypackage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 包级别的哨兵错误，调用方可以通过 errors.Is 进行匹配。
+var (
+	// ErrOverflow 表示计算结果超出支持的范围
+	ErrOverflow = errors.New("计算结果溢出")
+	// ErrDivisionByZero 表示除法或取模运算的除数为零
+	ErrDivisionByZero = errors.New("除数不能为零")
+	// ErrUnsupportedOp 表示计算器没有注册所请求的操作
+	ErrUnsupportedOp = errors.New("不支持的操作")
+)
+
+// ErrOutOfRange 表示某个参数超出了支持的范围。
+//
+// 调用方可以通过 errors.As 取出具体是哪个参数以及它的值：
+//
+//	var rangeErr mypackage.ErrOutOfRange
+//	if errors.As(err, &rangeErr) {
+//	    fmt.Println(rangeErr.Arg, rangeErr.Value)
+//	}
+type ErrOutOfRange struct {
+	// Arg 参数名称，例如 "a" 或 "b"
+	Arg string
+	// Value 参数的实际值
+	Value int
+}
+
+// Error 实现了 error 接口。
+func (e ErrOutOfRange) Error() string {
+	return fmt.Sprintf("参数%s超出范围: %d", e.Arg, e.Value)
+}
@@ -0,0 +1,198 @@
+package mypackage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Func 表示一个可以在表达式中调用的函数。
+type Func func(args ...float64) (float64, error)
+
+// builtinFuncs 表达式默认支持的函数
+var builtinFuncs = map[string]Func{
+	"sqrt": func(args ...float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("需要 1 个参数")
+		}
+		if args[0] < 0 {
+			return 0, errors.New("不能对负数开平方")
+		}
+		return math.Sqrt(args[0]), nil
+	},
+	"abs": func(args ...float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("需要 1 个参数")
+		}
+		return math.Abs(args[0]), nil
+	},
+	"min": func(args ...float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("至少需要 1 个参数")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	},
+	"max": func(args ...float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("至少需要 1 个参数")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	},
+}
+
+// RegisterFunc 注册一个可以在表达式中调用的函数，同名函数会被覆盖。
+//
+// 参数：
+//   name - 函数名称
+//   fn - 函数的实现
+//
+// 示例：
+//    calc.RegisterFunc("double", func(args ...float64) (float64, error) {
+//        return 2 * args[0], nil
+//    })
+func (c *Calculator) RegisterFunc(name string, fn Func) {
+	if c.funcs == nil {
+		c.funcs = make(map[string]Func, len(builtinFuncs)+1)
+		for builtin, f := range builtinFuncs {
+			c.funcs[builtin] = f
+		}
+	}
+	c.funcs[name] = fn
+}
+
+// Eval 解析并计算表达式，例如 "3 + 4 * (2 - 1)"。
+//
+// 表达式使用 float64 计算，不受计算器整数范围的限制。
+//
+// 参数：
+//   expr - 表达式字符串
+//
+// 返回值：
+//   float64 - 计算结果
+//   error - 解析或求值失败时返回带列号的 *ExprError
+func (c *Calculator) Eval(expr string) (float64, error) {
+	return c.EvalWith(expr, nil)
+}
+
+// EvalWith 解析并计算表达式，变量的值从 env 中读取。
+//
+// 参数：
+//   expr - 表达式字符串
+//   env - 变量名到值的映射，可以为 nil
+//
+// 返回值：
+//   float64 - 计算结果
+//   error - 解析或求值失败时返回带列号的 *ExprError
+//
+// 示例：
+//    total, err := calc.EvalWith("price * qty - discount", map[string]float64{
+//        "price": 9.5, "qty": 3, "discount": 1,
+//    })
+func (c *Calculator) EvalWith(expr string, env map[string]float64) (float64, error) {
+	root, err := Parse(expr)
+	if err != nil {
+		return 0, err
+	}
+	return c.EvalExpr(root, env)
+}
+
+// EvalExpr 计算已经解析好的语法树，适合对同一个表达式反复求值。
+//
+// 参数：
+//   e - Parse 返回的语法树
+//   env - 变量名到值的映射，可以为 nil
+//
+// 返回值：
+//   float64 - 计算结果
+//   error - 求值失败时返回带列号的 *ExprError
+func (c *Calculator) EvalExpr(e Expr, env map[string]float64) (float64, error) {
+	switch e := e.(type) {
+	case *NumberLit:
+		return e.Value, nil
+	case *Ident:
+		value, ok := env[e.Name]
+		if !ok {
+			return 0, &ExprError{Col: e.Col, Msg: fmt.Sprintf("未定义的变量 %s", e.Name)}
+		}
+		return value, nil
+	case *UnaryExpr:
+		x, err := c.EvalExpr(e.X, env)
+		if err != nil {
+			return 0, err
+		}
+		if e.Op == '-' {
+			return -x, nil
+		}
+		return x, nil
+	case *BinaryExpr:
+		x, err := c.EvalExpr(e.X, env)
+		if err != nil {
+			return 0, err
+		}
+		y, err := c.EvalExpr(e.Y, env)
+		if err != nil {
+			return 0, err
+		}
+		return evalBinary(e, x, y)
+	case *CallExpr:
+		fn, ok := c.funcTable()[e.Func]
+		if !ok {
+			return 0, &ExprError{Col: e.Col, Msg: fmt.Sprintf("未定义的函数 %s", e.Func), Err: ErrUnsupportedOp}
+		}
+		args := make([]float64, len(e.Args))
+		for i, arg := range e.Args {
+			value, err := c.EvalExpr(arg, env)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = value
+		}
+		result, err := fn(args...)
+		if err != nil {
+			return 0, &ExprError{Col: e.Col, Msg: fmt.Sprintf("%s: %v", e.Func, err), Err: err}
+		}
+		return result, nil
+	default:
+		return 0, fmt.Errorf("未知的表达式节点 %T", e)
+	}
+}
+
+// evalBinary 计算二元运算，错误位置指向运算符
+func evalBinary(e *BinaryExpr, x, y float64) (float64, error) {
+	switch e.Op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	case '/', '%':
+		if y == 0 {
+			return 0, &ExprError{Col: e.Col, Msg: ErrDivisionByZero.Error(), Err: ErrDivisionByZero}
+		}
+		if e.Op == '/' {
+			return x / y, nil
+		}
+		return math.Mod(x, y), nil
+	case '^':
+		return math.Pow(x, y), nil
+	default:
+		return 0, &ExprError{Col: e.Col, Msg: fmt.Sprintf("不支持的运算符 %c", e.Op), Err: ErrUnsupportedOp}
+	}
+}
+
+// funcTable 返回当前生效的函数表，零值计算器使用内置函数。
+func (c *Calculator) funcTable() map[string]Func {
+	if c.funcs == nil {
+		return builtinFuncs
+	}
+	return c.funcs
+}
@@ -0,0 +1,342 @@
+package mypackage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ExprError 表示表达式解析或求值过程中的错误。
+//
+// Col 是出错位置的列号，从 1 开始按字符计数；
+// 如果错误由其他错误引起（例如 ErrDivisionByZero），可以通过 errors.Is 匹配。
+type ExprError struct {
+	// Col 出错位置的列号
+	Col int
+	// Msg 错误描述
+	Msg string
+	// Err 底层错误，可能为 nil
+	Err error
+}
+
+// Error 实现了 error 接口。
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("第%d列: %s", e.Col, e.Msg)
+}
+
+// Unwrap 返回底层错误，用于 errors.Is 和 errors.As。
+func (e *ExprError) Unwrap() error {
+	return e.Err
+}
+
+// Expr 是表达式抽象语法树的节点。
+type Expr interface {
+	// Pos 返回节点在源表达式中的列号
+	Pos() int
+	// String 返回节点的完全加括号形式，便于调试
+	String() string
+}
+
+// NumberLit 数字字面量，例如 3.14
+type NumberLit struct {
+	Col   int
+	Value float64
+}
+
+// Ident 变量引用，值来自求值时的环境
+type Ident struct {
+	Col  int
+	Name string
+}
+
+// UnaryExpr 一元运算，Op 为 '+' 或 '-'
+type UnaryExpr struct {
+	Col int
+	Op  rune
+	X   Expr
+}
+
+// BinaryExpr 二元运算，Op 为 '+'、'-'、'*'、'/'、'%' 或 '^'
+type BinaryExpr struct {
+	Col  int
+	Op   rune
+	X, Y Expr
+}
+
+// CallExpr 函数调用，例如 max(a, b)
+type CallExpr struct {
+	Col  int
+	Func string
+	Args []Expr
+}
+
+func (e *NumberLit) Pos() int  { return e.Col }
+func (e *Ident) Pos() int      { return e.Col }
+func (e *UnaryExpr) Pos() int  { return e.Col }
+func (e *BinaryExpr) Pos() int { return e.Col }
+func (e *CallExpr) Pos() int   { return e.Col }
+
+func (e *NumberLit) String() string {
+	return strconv.FormatFloat(e.Value, 'g', -1, 64)
+}
+
+func (e *Ident) String() string {
+	return e.Name
+}
+
+func (e *UnaryExpr) String() string {
+	return fmt.Sprintf("(%c%s)", e.Op, e.X)
+}
+
+func (e *BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %c %s)", e.X, e.Op, e.Y)
+}
+
+func (e *CallExpr) String() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", e.Func, strings.Join(args, ", "))
+}
+
+// Parse 将表达式字符串解析为抽象语法树。
+//
+// 支持的语法（优先级从低到高）：
+//   加减        a + b, a - b
+//   乘除取模    a * b, a / b, a % b
+//   一元正负    -a, +a
+//   乘方        a ^ b（右结合，-2 ^ 2 等于 -4）
+//   基本单元    数字、变量、函数调用 f(x, y)、括号
+//
+// 参数：
+//   expr - 表达式字符串
+//
+// 返回值：
+//   Expr - 语法树的根节点
+//   error - 语法错误时返回带列号的 *ExprError
+func Parse(expr string) (Expr, error) {
+	p := &parser{lex: lexer{src: []rune(expr)}}
+	p.next()
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("多余的输入 %q", p.tok.text)
+	}
+	return root, nil
+}
+
+// tokenKind 词法单元的类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp // 运算符、括号和逗号
+	tokInvalid
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+// lexer 按字符扫描表达式并产生词法单元
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func (l *lexer) scan() token {
+	for l.pos < len(l.src) && unicode.IsSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	col := start + 1
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, col: col}
+	}
+
+	r := l.src[l.pos]
+	switch {
+	case isDigit(r) || r == '.':
+		l.scanDigits()
+		if l.pos < len(l.src) && l.src[l.pos] == '.' {
+			l.pos++
+			l.scanDigits()
+		}
+		// 可选的指数部分，例如 1e-3
+		if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+			l.pos++
+			if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+				l.pos++
+			}
+			l.scanDigits()
+		}
+		return token{kind: tokNumber, text: string(l.src[start:l.pos]), col: col}
+	case r == '_' || unicode.IsLetter(r):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: string(l.src[start:l.pos]), col: col}
+	case strings.ContainsRune("+-*/%^(),", r):
+		l.pos++
+		return token{kind: tokOp, text: string(r), col: col}
+	default:
+		l.pos++
+		return token{kind: tokInvalid, text: string(r), col: col}
+	}
+}
+
+func (l *lexer) scanDigits() {
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// parser 递归下降语法分析器，tok 为当前向前看的词法单元
+type parser struct {
+	lex lexer
+	tok token
+}
+
+func (p *parser) next() {
+	p.tok = p.lex.scan()
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &ExprError{Col: p.tok.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// isOp 判断当前词法单元是否为给定运算符之一
+func (p *parser) isOp(ops string) bool {
+	return p.tok.kind == tokOp && strings.Contains(ops, p.tok.text)
+}
+
+// expr = term { ("+" | "-") term }
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseBinary("+-", p.parseTerm)
+}
+
+// term = unary { ("*" | "/" | "%") unary }
+func (p *parser) parseTerm() (Expr, error) {
+	return p.parseBinary("*/%", p.parseUnary)
+}
+
+// parseBinary 解析由 ops 中的左结合运算符连接的操作数序列
+func (p *parser) parseBinary(ops string, operand func() (Expr, error)) (Expr, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(ops) {
+		op := p.tok
+		p.next()
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x = &BinaryExpr{Col: op.col, Op: rune(op.text[0]), X: x, Y: y}
+	}
+	return x, nil
+}
+
+// unary = ("+" | "-") unary | power
+func (p *parser) parseUnary() (Expr, error) {
+	if p.isOp("+-") {
+		op := p.tok
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Col: op.col, Op: rune(op.text[0]), X: x}, nil
+	}
+	return p.parsePower()
+}
+
+// power = primary [ "^" unary ]
+func (p *parser) parsePower() (Expr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") {
+		op := p.tok
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Col: op.col, Op: '^', X: x, Y: y}, nil
+	}
+	return x, nil
+}
+
+// primary = number | ident [ "(" [ expr { "," expr } ] ")" ] | "(" expr ")"
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("无效的数字 %q", tok.text)
+		}
+		p.next()
+		return &NumberLit{Col: tok.col, Value: value}, nil
+	case tok.kind == tokIdent:
+		p.next()
+		if !p.isOp("(") {
+			return &Ident{Col: tok.col, Name: tok.text}, nil
+		}
+		p.next()
+		call := &CallExpr{Col: tok.col, Func: tok.text}
+		if p.isOp(")") {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			if !p.isOp(",") {
+				break
+			}
+			p.next()
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf("函数 %s 的参数列表缺少 ')'", call.Func)
+		}
+		p.next()
+		return call, nil
+	case p.isOp("("):
+		p.next()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf("缺少 ')'，与第%d列的 '(' 匹配", tok.col)
+		}
+		p.next()
+		return x, nil
+	case tok.kind == tokEOF:
+		return nil, p.errorf("表达式意外结束")
+	case tok.kind == tokInvalid:
+		return nil, p.errorf("无效的字符 %q", tok.text)
+	default:
+		return nil, p.errorf("意外的 %q", tok.text)
+	}
+}
@@ -0,0 +1,144 @@
+package mypackage
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestParse 测试运算符优先级和结合性
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3 + 4 * (2 - 1)", "(3 + (4 * (2 - 1)))"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"-2 ^ 2", "(-(2 ^ 2))"},
+		{"2 ^ -1", "(2 ^ (-1))"},
+		{"--x", "(-(-x))"},
+		{"max(a, b + 1, 3) % 4", "(max(a, (b + 1), 3) % 4)"},
+		{"f()", "f()"},
+		{"1.5e3 / .5", "(1500 / 0.5)"},
+	}
+
+	for _, test := range tests {
+		root, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if root.String() != test.expected {
+			t.Errorf("Parse(%q) = %s; want %s", test.input, root, test.expected)
+		}
+	}
+}
+
+// TestParseErrors 测试语法错误的列号
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		col   int
+	}{
+		{"", 1},
+		{"1 +", 4},
+		{"(1 + 2", 7},
+		{"1 + 2)", 6},
+		{"2 * # 3", 5},
+		{"max(1, 2", 9},
+		{"1 2", 3},
+		{"求和 + ", 6},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.input)
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("Parse(%q) err = %v; want *ExprError", test.input, err)
+			continue
+		}
+		if exprErr.Col != test.col {
+			t.Errorf("Parse(%q) col = %d; want %d (%v)", test.input, exprErr.Col, test.col, err)
+		}
+	}
+}
+
+// TestEval 测试表达式求值
+func TestEval(t *testing.T) {
+	calc := NewCalculator("expr")
+	env := map[string]float64{"x": 3, "y": 4, "rate": 0.5}
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3 + 4 * (2 - 1)", 7},
+		{"-x + y", 1},
+		{"sqrt(x*x + y*y)", 5},
+		{"min(x, y, 1) + max(x, y)", 5},
+		{"abs(-2.5)", 2.5},
+		{"7 % 4", 3},
+		{"2 ^ 10", 1024},
+		{"100 * rate", 50},
+	}
+
+	for _, test := range tests {
+		result, err := calc.EvalWith(test.input, env)
+		if err != nil {
+			t.Errorf("EvalWith(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("EvalWith(%q) = %g; want %g", test.input, result, test.expected)
+		}
+	}
+}
+
+// TestEvalErrors 测试求值错误的列号和底层错误
+func TestEvalErrors(t *testing.T) {
+	calc := NewCalculator("expr")
+
+	tests := []struct {
+		input string
+		col   int
+		err   error
+	}{
+		{"1 + z", 5, nil},
+		{"10 / (x - 3)", 4, ErrDivisionByZero},
+		{"2 * cbrt(8)", 5, ErrUnsupportedOp},
+		{"sqrt(1, 2)", 1, nil},
+	}
+
+	for _, test := range tests {
+		_, err := calc.EvalWith(test.input, map[string]float64{"x": 3})
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("EvalWith(%q) err = %v; want *ExprError", test.input, err)
+			continue
+		}
+		if exprErr.Col != test.col {
+			t.Errorf("EvalWith(%q) col = %d; want %d", test.input, exprErr.Col, test.col)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("EvalWith(%q) err = %v; want %v", test.input, err, test.err)
+		}
+	}
+}
+
+// TestRegisterFunc 测试注册自定义函数
+func TestRegisterFunc(t *testing.T) {
+	calc := NewCalculator("expr")
+	calc.RegisterFunc("cbrt", func(args ...float64) (float64, error) {
+		return math.Cbrt(args[0]), nil
+	})
+
+	result, err := calc.Eval("2 * cbrt(8) + sqrt(4)")
+	if err != nil || result != 6 {
+		t.Errorf("Eval = %g, %v; want 6, nil", result, err)
+	}
+
+	if _, err := NewCalculator("other").Eval("cbrt(8)"); !errors.Is(err, ErrUnsupportedOp) {
+		t.Errorf("other calculator err = %v; want ErrUnsupportedOp", err)
+	}
+}
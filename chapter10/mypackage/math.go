@@ -8,8 +8,8 @@
 package mypackage
 
 import (
-	"errors"
 	"fmt"
+	"sort"
 )
 
 // 包级别常量
@@ -44,7 +44,7 @@ func init() {
 //
 // 返回值：
 //   int - 两个整数的和
-//   error - 如果发生错误返回错误信息
+//   error - 参数越界时返回 ErrOutOfRange，结果溢出时返回 ErrOverflow
 //
 // 示例：
 //    result, err := mypackage.Add(3, 4)
@@ -53,24 +53,20 @@ func init() {
 //    }
 //    fmt.Println(result) // 输出: 7
 func Add(a, b int) (int, error) {
-	// 输入验证
-	if a < MinInt || a > MaxInt {
-		return 0, errors.New("参数a超出范围")
-	}
-	if b < MinInt || b > MaxInt {
-		return 0, errors.New("参数b超出范围")
-	}
-	
-	// 溢出检查
-	if a > 0 && b > 0 && a > MaxInt-b {
-		return 0, errors.New("计算结果溢出")
-	}
-	if a < 0 && b < 0 && a < MinInt-b {
-		return 0, errors.New("计算结果溢出")
-	}
-	
-	operations++
-	return a + b, nil
+	return defaultArith.add(a, b)
+}
+
+// Subtract 计算两个整数的差。
+//
+// 参数：
+//   a - 被减数
+//   b - 减数
+//
+// 返回值：
+//   int - a - b 的结果
+//   error - 参数越界时返回 ErrOutOfRange，结果溢出时返回 ErrOverflow
+func Subtract(a, b int) (int, error) {
+	return defaultArith.subtract(a, b)
 }
 
 // Multiply 计算两个整数的乘积。
@@ -83,35 +79,50 @@ func Add(a, b int) (int, error) {
 //
 // 返回值：
 //   int - 两个整数的乘积
-//   error - 如果发生错误返回错误信息
+//   error - 参数越界时返回 ErrOutOfRange，结果溢出时返回 ErrOverflow
 func Multiply(a, b int) (int, error) {
-	// 输入验证
-	if a < MinInt || a > MaxInt {
-		return 0, errors.New("参数a超出范围")
-	}
-	if b < MinInt || b > MaxInt {
-		return 0, errors.New("参数b超出范围")
-	}
-	
-	// 特殊情况
-	if a == 0 || b == 0 {
-		operations++
-		return 0, nil
-	}
-	
-	// 溢出检查
-	if a > 0 && b > 0 && a > MaxInt/b {
-		return 0, errors.New("计算结果溢出")
-	}
-	if a < 0 && b < 0 && a < MaxInt/b {
-		return 0, errors.New("计算结果溢出")
-	}
-	if (a > 0 && b < 0 && b < MinInt/a) || (a < 0 && b > 0 && a < MinInt/b) {
-		return 0, errors.New("计算结果溢出")
-	}
-	
-	operations++
-	return a * b, nil
+	return defaultArith.multiply(a, b)
+}
+
+// Divide 计算两个整数的商，结果向零截断。
+//
+// 参数：
+//   a - 被除数
+//   b - 除数
+//
+// 返回值：
+//   int - a / b 的结果
+//   error - 参数越界时返回 ErrOutOfRange，除数为零时返回 ErrDivisionByZero
+func Divide(a, b int) (int, error) {
+	return defaultArith.divide(a, b)
+}
+
+// Mod 计算两个整数相除的余数，符号与被除数相同。
+//
+// 参数：
+//   a - 被除数
+//   b - 除数
+//
+// 返回值：
+//   int - a % b 的结果
+//   error - 参数越界时返回 ErrOutOfRange，除数为零时返回 ErrDivisionByZero
+func Mod(a, b int) (int, error) {
+	return defaultArith.mod(a, b)
+}
+
+// Pow 计算 a 的 b 次幂。
+//
+// 整数幂运算不支持负指数，负的 b 会被视为参数越界。
+//
+// 参数：
+//   a - 底数
+//   b - 指数，必须大于等于 0
+//
+// 返回值：
+//   int - a 的 b 次幂
+//   error - 参数越界时返回 ErrOutOfRange，结果溢出时返回 ErrOverflow
+func Pow(a, b int) (int, error) {
+	return defaultArith.pow(a, b)
 }
 
 // GetOperationCount 返回已执行的操作次数。
@@ -131,33 +142,98 @@ func ResetOperationCount() {
 	operations = 0
 }
 
+// Operation 表示一个可以注册到计算器中的二元整数运算。
+type Operation func(a, b int) (int, error)
+
+// builtinOperations 零值计算器使用的内置操作，等价于包级别函数
+var builtinOperations = defaultArith.table()
+
 // Calculator 计算器结构体，演示了面向对象的设计。
 type Calculator struct {
 	// Name 计算器名称
 	Name string
 	// precision 精度（私有字段）
 	precision int
+	// arith 整数运算的取值范围和溢出处理方式（私有字段）
+	arith arith
+	// rounding 十进制模式的舍入方式（私有字段）
+	rounding RoundingMode
+	// operations 已注册的操作（私有字段）
+	operations map[string]Operation
+	// funcs 表达式中可以调用的函数（私有字段）
+	funcs map[string]Func
 }
 
 // NewCalculator 创建一个新的计算器实例。
 //
-// 这是一个构造函数，演示了Go语言中创建对象的常用模式。
+// 这是一个构造函数，演示了Go语言中创建对象的常用模式，
+// 可选的函数式选项用于定制计算器的取值范围和溢出处理方式。
 //
 // 参数：
 //   name - 计算器名称
+//   opts - 可选配置，例如 WithBounds、WithOverflowMode、WithNativeRange
 //
 // 返回值：
 //   *Calculator - 计算器实例指针
-func NewCalculator(name string) *Calculator {
-	return &Calculator{
+//
+// 示例：
+//    calc := mypackage.NewCalculator("ids", mypackage.WithNativeRange())
+//    counter := mypackage.NewCalculator("counter",
+//        mypackage.WithBounds(0, 255),
+//        mypackage.WithOverflowMode(mypackage.OverflowSaturate))
+func NewCalculator(name string, opts ...Option) *Calculator {
+	c := &Calculator{
 		Name:      name,
 		precision: DefaultPrecision,
+		arith:     defaultArith,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.operations = c.arith.table()
+	return c
+}
+
+// Register 注册一个自定义操作，同名操作会被覆盖。
+//
+// 这个方法演示了如何通过注册表扩展结构体的行为。
+//
+// 参数：
+//   name - 操作名称，即 Calculate 的 operation 参数
+//   op - 操作的实现
+//
+// 示例：
+//    calc.Register("max", func(a, b int) (int, error) {
+//        if a > b {
+//            return a, nil
+//        }
+//        return b, nil
+//    })
+func (c *Calculator) Register(name string, op Operation) {
+	if c.operations == nil {
+		c.arith = defaultArith
+		c.operations = c.arith.table()
+	}
+	c.operations[name] = op
+}
+
+// Operations 返回计算器支持的所有操作名称，按字母顺序排列。
+//
+// 返回值：
+//   []string - 操作名称列表
+func (c *Calculator) Operations() []string {
+	names := make([]string, 0, len(c.lookupTable()))
+	for name := range c.lookupTable() {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
 
 // SetPrecision 设置计算器的精度。
 //
 // 这个方法演示了结构体方法的定义和使用。
+// 精度即 CalculateDecimal 结果保留的小数位数。
 //
 // 参数：
 //   precision - 新的精度值
@@ -175,25 +251,32 @@ func (c *Calculator) GetPrecision() int {
 
 // Calculate 执行计算操作。
 //
-// 这个方法演示了结构体方法如何使用包级别函数。
+// 这个方法演示了结构体方法如何通过注册表分派到具体的操作。
 //
 // 参数：
 //   a - 第一个操作数
 //   b - 第二个操作数
-//   operation - 操作类型 ("add" 或 "multiply")
+//   operation - 操作类型，内置 "add"、"subtract"、"multiply"、
+//               "divide"、"mod" 和 "pow"，也可以是通过 Register 注册的操作
 //
 // 返回值：
 //   int - 计算结果
-//   error - 如果发生错误返回错误信息
+//   error - 操作未注册时返回包装了 ErrUnsupportedOp 的错误，
+//           否则返回操作本身的错误
 func (c *Calculator) Calculate(a, b int, operation string) (int, error) {
-	switch operation {
-	case "add":
-		return Add(a, b)
-	case "multiply":
-		return Multiply(a, b)
-	default:
-		return 0, errors.New("不支持的操作")
+	op, ok := c.lookupTable()[operation]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedOp, operation)
 	}
+	return op(a, b)
+}
+
+// lookupTable 返回当前生效的操作表，零值计算器使用包级别的内置操作。
+func (c *Calculator) lookupTable() map[string]Operation {
+	if c.operations == nil {
+		return builtinOperations
+	}
+	return c.operations
 }
 
 // String 返回计算器的字符串表示。
@@ -209,4 +292,4 @@ func (c *Calculator) String() string {
 // 私有辅助函数，演示了包内部的实现细节
 func validateInput(value int) bool {
 	return value >= MinInt && value <= MaxInt
-} 
\ No newline at end of file
+}
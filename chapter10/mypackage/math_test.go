@@ -0,0 +1,109 @@
+package mypackage
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestArithmetic 测试各个包级别运算函数
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       Operation
+		a, b     int
+		expected int
+		err      error
+	}{
+		{"add", Add, 3, 4, 7, nil},
+		{"add overflow", Add, MaxInt, 1, 0, ErrOverflow},
+		{"subtract", Subtract, 3, 4, -1, nil},
+		{"subtract overflow", Subtract, MinInt, 1, 0, ErrOverflow},
+		{"multiply", Multiply, -6, 7, -42, nil},
+		{"multiply overflow", Multiply, 1001, 1000, 0, ErrOverflow},
+		{"divide", Divide, -7, 2, -3, nil},
+		{"divide by zero", Divide, 7, 0, 0, ErrDivisionByZero},
+		{"mod", Mod, -7, 2, -1, nil},
+		{"mod by zero", Mod, 7, 0, 0, ErrDivisionByZero},
+		{"pow", Pow, -3, 3, -27, nil},
+		{"pow zero exponent", Pow, 0, 0, 1, nil},
+		{"pow overflow", Pow, 10, 7, 0, ErrOverflow},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.op(test.a, test.b)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("err = %v; want %v", err, test.err)
+			}
+			if result != test.expected {
+				t.Errorf("result = %d; want %d", result, test.expected)
+			}
+		})
+	}
+}
+
+// TestOutOfRange 测试越界参数可以通过 errors.As 取出
+func TestOutOfRange(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b int
+		arg  string
+	}{
+		{"a too large", MaxInt + 1, 0, "a"},
+		{"b too small", 0, MinInt - 1, "b"},
+	}
+
+	for _, test := range tests {
+		_, err := Add(test.a, test.b)
+		var rangeErr ErrOutOfRange
+		if !errors.As(err, &rangeErr) {
+			t.Fatalf("%s: err = %v; want ErrOutOfRange", test.name, err)
+		}
+		if rangeErr.Arg != test.arg {
+			t.Errorf("%s: Arg = %q; want %q", test.name, rangeErr.Arg, test.arg)
+		}
+	}
+
+	_, err := Pow(2, -1)
+	var rangeErr ErrOutOfRange
+	if !errors.As(err, &rangeErr) || rangeErr.Arg != "b" || rangeErr.Value != -1 {
+		t.Errorf("Pow(2, -1) err = %v; want ErrOutOfRange{b, -1}", err)
+	}
+}
+
+// TestCalculatorRegistry 测试计算器的操作注册表
+func TestCalculatorRegistry(t *testing.T) {
+	calc := NewCalculator("test")
+
+	result, err := calc.Calculate(17, 5, "mod")
+	if err != nil || result != 2 {
+		t.Errorf("Calculate(17, 5, mod) = %d, %v; want 2, nil", result, err)
+	}
+
+	_, err = calc.Calculate(1, 2, "max")
+	if !errors.Is(err, ErrUnsupportedOp) {
+		t.Errorf("Calculate(1, 2, max) err = %v; want ErrUnsupportedOp", err)
+	}
+
+	calc.Register("max", func(a, b int) (int, error) {
+		if a > b {
+			return a, nil
+		}
+		return b, nil
+	})
+	result, err = calc.Calculate(1, 2, "max")
+	if err != nil || result != 2 {
+		t.Errorf("Calculate(1, 2, max) = %d, %v; want 2, nil", result, err)
+	}
+
+	// 注册只影响当前计算器
+	if _, err := NewCalculator("other").Calculate(1, 2, "max"); !errors.Is(err, ErrUnsupportedOp) {
+		t.Errorf("other calculator err = %v; want ErrUnsupportedOp", err)
+	}
+
+	// 零值计算器使用内置操作
+	var zero Calculator
+	if result, err := zero.Calculate(2, 10, "pow"); err != nil || result != 1024 {
+		t.Errorf("zero Calculate(2, 10, pow) = %d, %v; want 1024, nil", result, err)
+	}
+}
@@ -0,0 +1,78 @@
+package mypackage
+
+import "fmt"
+
+// OverflowMode 表示计算结果超出范围时的处理方式。
+type OverflowMode int
+
+const (
+	// OverflowError 结果越界时返回 ErrOverflow（默认）
+	OverflowError OverflowMode = iota
+	// OverflowSaturate 结果越界时返回最接近的边界值
+	OverflowSaturate
+)
+
+// String 返回溢出处理方式的名称。
+func (m OverflowMode) String() string {
+	switch m {
+	case OverflowError:
+		return "error"
+	case OverflowSaturate:
+		return "saturate"
+	default:
+		return fmt.Sprintf("OverflowMode(%d)", int(m))
+	}
+}
+
+// Option 是 NewCalculator 的函数式选项。
+//
+// 这个类型演示了Go语言中常见的函数式选项模式：
+// 构造函数保持简单的签名，同时允许调用方按需定制。
+type Option func(*Calculator)
+
+// WithBounds 设置操作数和结果允许的范围 [min, max]。
+//
+// 参数：
+//   min - 允许的最小值
+//   max - 允许的最大值，必须大于等于 min
+//
+// 示例：
+//    calc := mypackage.NewCalculator("counter", mypackage.WithBounds(0, 255))
+func WithBounds(min, max int) Option {
+	if min > max {
+		panic(fmt.Sprintf("mypackage: 无效的范围 [%d, %d]", min, max))
+	}
+	return func(c *Calculator) {
+		c.arith.min, c.arith.max = min, max
+	}
+}
+
+// WithNativeRange 使用原生 int 的全部范围（64位平台上即 int64），
+// 溢出通过 math/bits 检测而不是依赖 MinInt/MaxInt。
+func WithNativeRange() Option {
+	return func(c *Calculator) {
+		c.arith.min, c.arith.max = nativeArith.min, nativeArith.max
+	}
+}
+
+// WithOverflowMode 设置结果越界时的处理方式。
+//
+// 参数：
+//   mode - OverflowError 返回错误，OverflowSaturate 返回边界值
+func WithOverflowMode(mode OverflowMode) Option {
+	return func(c *Calculator) {
+		c.arith.saturate = mode == OverflowSaturate
+	}
+}
+
+// Bounds 返回计算器允许的取值范围。
+//
+// 返回值：
+//   min - 允许的最小值
+//   max - 允许的最大值
+func (c *Calculator) Bounds() (min, max int) {
+	if c.operations == nil {
+		return defaultArith.min, defaultArith.max
+	}
+	return c.arith.min, c.arith.max
+}
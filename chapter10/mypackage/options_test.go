@@ -0,0 +1,142 @@
+package mypackage
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestWithBounds 测试自定义范围
+func TestWithBounds(t *testing.T) {
+	calc := NewCalculator("counter", WithBounds(0, 255))
+	if min, max := calc.Bounds(); min != 0 || max != 255 {
+		t.Errorf("Bounds() = %d, %d; want 0, 255", min, max)
+	}
+
+	if result, err := calc.Calculate(200, 55, "add"); err != nil || result != 255 {
+		t.Errorf("Calculate(200, 55, add) = %d, %v; want 255, nil", result, err)
+	}
+	if _, err := calc.Calculate(200, 56, "add"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Calculate(200, 56, add) err = %v; want ErrOverflow", err)
+	}
+	if _, err := calc.Calculate(1, 2, "subtract"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Calculate(1, 2, subtract) err = %v; want ErrOverflow", err)
+	}
+	var rangeErr ErrOutOfRange
+	if _, err := calc.Calculate(-1, 2, "add"); !errors.As(err, &rangeErr) || rangeErr.Arg != "a" {
+		t.Errorf("Calculate(-1, 2, add) err = %v; want ErrOutOfRange{a}", err)
+	}
+
+	// 包级别函数保持默认范围
+	if _, err := Add(200, 56); err != nil {
+		t.Errorf("Add(200, 56) returned error: %v", err)
+	}
+}
+
+// TestAsymmetricBounds 测试不对称的范围：中间结果越界不影响最终结果
+func TestAsymmetricBounds(t *testing.T) {
+	calc := NewCalculator("counter", WithBounds(-1000, 50))
+	saturate := NewCalculator("counter", WithBounds(-1000, 50), WithOverflowMode(OverflowSaturate))
+
+	tests := []struct {
+		a, b      int
+		operation string
+		expected  int
+		err       error
+		saturated int
+	}{
+		{-10, 3, "pow", -1000, nil, -1000},
+		{-2, 9, "pow", -512, nil, -512},
+		{7, 2, "pow", 49, nil, 49},
+		{-10, 2, "pow", 0, ErrOverflow, 50},
+		{-10, 4, "pow", 0, ErrOverflow, 50},
+		{-4, 5, "pow", 0, ErrOverflow, -1000},
+		{-40, 25, "multiply", -1000, nil, -1000},
+	}
+
+	for _, test := range tests {
+		result, err := calc.Calculate(test.a, test.b, test.operation)
+		if !errors.Is(err, test.err) || result != test.expected {
+			t.Errorf("Calculate(%d, %d, %s) = %d, %v; want %d, %v",
+				test.a, test.b, test.operation, result, err, test.expected, test.err)
+		}
+		result, err = saturate.Calculate(test.a, test.b, test.operation)
+		if err != nil || result != test.saturated {
+			t.Errorf("saturate Calculate(%d, %d, %s) = %d, %v; want %d, nil",
+				test.a, test.b, test.operation, result, err, test.saturated)
+		}
+	}
+}
+
+// TestOverflowSaturate 测试饱和溢出
+func TestOverflowSaturate(t *testing.T) {
+	calc := NewCalculator("counter", WithBounds(-100, 100), WithOverflowMode(OverflowSaturate))
+
+	tests := []struct {
+		a, b      int
+		operation string
+		expected  int
+	}{
+		{90, 20, "add", 100},
+		{-90, 20, "subtract", -100},
+		{-50, 3, "multiply", -100},
+		{-3, 5, "pow", -100},
+		{-3, 4, "pow", 81},
+		{10, 3, "pow", 100},
+	}
+
+	for _, test := range tests {
+		result, err := calc.Calculate(test.a, test.b, test.operation)
+		if err != nil || result != test.expected {
+			t.Errorf("Calculate(%d, %d, %s) = %d, %v; want %d, nil",
+				test.a, test.b, test.operation, result, err, test.expected)
+		}
+	}
+
+	// 除零不是溢出，仍然返回错误
+	if _, err := calc.Calculate(1, 0, "divide"); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Calculate(1, 0, divide) err = %v; want ErrDivisionByZero", err)
+	}
+}
+
+// TestNativeRange 测试原生 int 范围的溢出检测
+func TestNativeRange(t *testing.T) {
+	if math.MaxInt == math.MaxInt32 {
+		t.Skip("native range tests assume a 64-bit int")
+	}
+	calc := NewCalculator("ids", WithNativeRange())
+
+	tests := []struct {
+		name      string
+		a, b      int
+		operation string
+		expected  int
+		err       error
+	}{
+		{"large add", math.MaxInt - 1, 1, "add", math.MaxInt, nil},
+		{"add overflow", math.MaxInt, 1, "add", 0, ErrOverflow},
+		{"subtract overflow", math.MinInt, 1, "subtract", 0, ErrOverflow},
+		{"subtract to min", -1, math.MaxInt, "subtract", math.MinInt, nil},
+		{"multiply to min", math.MinInt / 2, 2, "multiply", math.MinInt, nil},
+		{"multiply overflow", math.MaxInt/2 + 1, 2, "multiply", 0, ErrOverflow},
+		{"negative multiply overflow", math.MinInt, -1, "multiply", 0, ErrOverflow},
+		{"divide overflow", math.MinInt, -1, "divide", 0, ErrOverflow},
+		{"mod min", math.MinInt, -1, "mod", 0, nil},
+		{"pow", 2, 62, "pow", math.MaxInt/2 + 1, nil},
+		{"pow overflow", 2, 64, "pow", 0, ErrOverflow},
+		{"pow to min", -2, 63, "pow", math.MinInt, nil},
+		{"negative pow overflow", -3, 41, "pow", 0, ErrOverflow},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := calc.Calculate(test.a, test.b, test.operation)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("err = %v; want %v", err, test.err)
+			}
+			if result != test.expected {
+				t.Errorf("result = %d; want %d", result, test.expected)
+			}
+		})
+	}
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	
 	// 导入本地包
-	"./mypackage"
+	"go-programming-language/chapter10/mypackage"
 )
 
 func main() {
@@ -57,6 +58,85 @@ func main() {
 	}
 	fmt.Printf("计算器计算 7 * 8 = %d\n", calcResult)
 	
+	// 使用新增的运算
+	for _, op := range []string{"subtract", "divide", "mod", "pow"} {
+		calcResult, err = calc.Calculate(9, 4, op)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("计算器计算 %s(9, 4) = %d\n", op, calcResult)
+	}
+	
+	// 注册自定义操作
+	calc.Register("max", func(a, b int) (int, error) {
+		if a > b {
+			return a, nil
+		}
+		return b, nil
+	})
+	fmt.Printf("支持的操作: %v\n", calc.Operations())
+	
+	// 十进制模式：精度决定结果保留的小数位数
+	fmt.Println("\n=== 十进制模式 ===")
+	billing := mypackage.NewCalculator("Billing")
+	price, _ := mypackage.ParseDecimal("19.99")
+	parts, _ := mypackage.ParseDecimal("3")
+	share, err := billing.CalculateDecimal(price, parts, "divide")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("19.99 / 3 = %s (舍入模式: %s)\n", share, billing.GetRoundingMode())
+	billing.SetPrecision(4)
+	billing.SetRoundingMode(mypackage.RoundTruncate)
+	share, _ = billing.CalculateDecimal(price, parts, "divide")
+	fmt.Printf("19.99 / 3 = %s (舍入模式: %s)\n", share, billing.GetRoundingMode())
+	
+	// 函数式选项：自定义范围和溢出处理方式
+	fmt.Println("\n=== 函数式选项 ===")
+	counter := mypackage.NewCalculator("Counter",
+		mypackage.WithBounds(0, 255),
+		mypackage.WithOverflowMode(mypackage.OverflowSaturate))
+	saturated, _ := counter.Calculate(200, 100, "add")
+	fmt.Printf("范围 [0, 255] 内饱和计算 200 + 100 = %d\n", saturated)
+	ids := mypackage.NewCalculator("IDs", mypackage.WithNativeRange())
+	big, err := ids.Calculate(mypackage.MaxInt, mypackage.MaxInt, "multiply")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("原生范围计算 %d * %d = %d\n", mypackage.MaxInt, mypackage.MaxInt, big)
+	
+	// 表达式求值
+	fmt.Println("\n=== 表达式求值 ===")
+	value, err := calc.Eval("3 + 4 * (2 - 1)")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("3 + 4 * (2 - 1) = %g\n", value)
+	env := map[string]float64{"x": 3, "y": 4}
+	value, err = calc.EvalWith("sqrt(x^2 + y^2) + max(x, y)", env)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("sqrt(x^2 + y^2) + max(x, y) = %g (x=3, y=4)\n", value)
+	if _, err := calc.Eval("2 * (3 + "); err != nil {
+		fmt.Printf("语法错误: %v\n", err)
+	}
+	
+	// 使用类型化错误
+	fmt.Println("\n=== 错误处理 ===")
+	if _, err := mypackage.Add(mypackage.MaxInt+1, 1); err != nil {
+		var rangeErr mypackage.ErrOutOfRange
+		if errors.As(err, &rangeErr) {
+			fmt.Printf("参数 %s 越界，值为 %d\n", rangeErr.Arg, rangeErr.Value)
+		}
+	}
+	if _, err := mypackage.Multiply(mypackage.MaxInt, 2); errors.Is(err, mypackage.ErrOverflow) {
+		fmt.Printf("检测到溢出: %v\n", err)
+	}
+	if _, err := calc.Calculate(1, 2, "sqrt"); errors.Is(err, mypackage.ErrUnsupportedOp) {
+		fmt.Printf("检测到不支持的操作: %v\n", err)
+	}
+	
 	// 最终操作次数
 	fmt.Printf("最终操作次数: %d\n", mypackage.GetOperationCount())
 	
@@ -8,37 +8,43 @@ import (
 	"strings"
 )
 
+// Operation 一次计算的结构化记录
+type Operation struct {
+	Op     string
+	A, B   int
+	Result int
+}
+
+// String 返回计算记录的可读形式，例如 "2 + 3 = 5"
+func (o Operation) String() string {
+	return fmt.Sprintf("%d %s %d = %d", o.A, o.Op, o.B, o.Result)
+}
+
 // Calculator 计算器结构体，用于演示测试
 type Calculator struct {
-	history []string
+	history []Operation
 }
 
 // NewCalculator 创建一个新的计算器
 func NewCalculator() *Calculator {
 	return &Calculator{
-		history: make([]string, 0),
+		history: make([]Operation, 0),
 	}
 }
 
 // Add 加法运算
 func (c *Calculator) Add(a, b int) int {
-	result := a + b
-	c.history = append(c.history, fmt.Sprintf("%d + %d = %d", a, b, result))
-	return result
+	return c.record("+", a, b, a+b)
 }
 
 // Subtract 减法运算
 func (c *Calculator) Subtract(a, b int) int {
-	result := a - b
-	c.history = append(c.history, fmt.Sprintf("%d - %d = %d", a, b, result))
-	return result
+	return c.record("-", a, b, a-b)
 }
 
 // Multiply 乘法运算
 func (c *Calculator) Multiply(a, b int) int {
-	result := a * b
-	c.history = append(c.history, fmt.Sprintf("%d * %d = %d", a, b, result))
-	return result
+	return c.record("*", a, b, a*b)
 }
 
 // Divide 除法运算
@@ -46,19 +52,41 @@ func (c *Calculator) Divide(a, b int) (int, error) {
 	if b == 0 {
 		return 0, errors.New("division by zero")
 	}
-	result := a / b
-	c.history = append(c.history, fmt.Sprintf("%d / %d = %d", a, b, result))
-	return result, nil
+	return c.record("/", a, b, a/b), nil
+}
+
+// record 记录一次计算并返回结果
+func (c *Calculator) record(op string, a, b, result int) int {
+	c.history = append(c.history, Operation{Op: op, A: a, B: b, Result: result})
+	return result
 }
 
 // GetHistory 获取计算历史
 func (c *Calculator) GetHistory() []string {
-	return c.history
+	history := make([]string, len(c.history))
+	for i, op := range c.history {
+		history[i] = op.String()
+	}
+	return history
+}
+
+// Operations 获取结构化的计算历史
+func (c *Calculator) Operations() []Operation {
+	return append([]Operation(nil), c.history...)
+}
+
+// Undo 撤销最后一次计算，没有可撤销的计算时返回 false
+func (c *Calculator) Undo() bool {
+	if len(c.history) == 0 {
+		return false
+	}
+	c.history = c.history[:len(c.history)-1]
+	return true
 }
 
 // Clear 清空计算历史
 func (c *Calculator) Clear() {
-	c.history = make([]string, 0)
+	c.history = make([]Operation, 0)
 }
 
 // 数学工具函数
@@ -52,6 +52,28 @@ func TestCalculator(t *testing.T) {
 	}
 }
 
+// TestCalculatorUndo 测试撤销和结构化历史
+func TestCalculatorUndo(t *testing.T) {
+	calc := NewCalculator()
+	if calc.Undo() {
+		t.Error("Undo() on empty history should return false")
+	}
+
+	calc.Add(2, 3)
+	calc.Multiply(4, 5)
+	if !calc.Undo() {
+		t.Fatal("Undo() returned false")
+	}
+
+	expected := []Operation{{Op: "+", A: 2, B: 3, Result: 5}}
+	if !reflect.DeepEqual(calc.Operations(), expected) {
+		t.Errorf("Operations() = %v; want %v", calc.Operations(), expected)
+	}
+	if history := calc.GetHistory(); len(history) != 1 || history[0] != "2 + 3 = 5" {
+		t.Errorf("GetHistory() = %q; want [\"2 + 3 = 5\"]", history)
+	}
+}
+
 // TestIsPrime 测试质数判断
 func TestIsPrime(t *testing.T) {
 	tests := []struct {